@@ -6,14 +6,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/maumercado/task-queue-go/internal/api"
 	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/encryption"
 	"github.com/maumercado/task-queue-go/internal/events"
 	"github.com/maumercado/task-queue-go/internal/logger"
 	"github.com/maumercado/task-queue-go/internal/queue"
+	"github.com/maumercado/task-queue-go/internal/redact"
+	"github.com/maumercado/task-queue-go/internal/tlsutil"
 )
 
 func main() {
@@ -41,8 +45,30 @@ func main() {
 		}
 	}()
 
+	// Hide sensitive payload fields in logs
+	redactor, err := redact.New(&cfg.Redaction)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid redaction config")
+	}
+	redact.SetDefault(redactor)
+
+	// Encrypt task payloads and results at rest if configured
+	sealer, err := encryption.NewSealer(&cfg.Encryption)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid encryption config")
+	}
+	redisQueue.SetSealer(sealer)
+
 	// Create DLQ
 	dlq := queue.NewDLQ(redisQueue.Client())
+	dlq.SetSealer(sealer)
+
+	// Index DLQ entries written before the task ID index existed
+	if n, err := dlq.Reindex(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to rebuild DLQ index")
+	} else if n > 0 {
+		log.Info().Int("entries", n).Msg("Rebuilt DLQ index")
+	}
 
 	// Create event publisher
 	publisher := events.NewRedisPubSub(redisQueue.Client())
@@ -52,11 +78,24 @@ func main() {
 		}
 	}()
 
-	// Create and start scheduler for scheduled tasks
-	scheduler := queue.NewScheduler(redisQueue.Client(), redisQueue)
+	// Create scheduler for scheduled tasks, unless it runs as cmd/scheduler
+	var scheduler *queue.Scheduler
+	if cfg.Scheduler.Embedded {
+		scheduler = queue.NewSchedulerWithConfig(redisQueue.Client(), redisQueue, &cfg.Scheduler)
+		scheduler.SetPublisher(publisher)
+
+		// DLQ retention runs on the elected scheduler leader
+		if dlqJanitor := queue.NewDLQJanitor(redisQueue.Client(), &cfg.DLQ); dlqJanitor.Enabled() {
+			dlqJanitor.SetTenants(redisQueue.Tenants())
+			scheduler.RunWhileLeader(dlqJanitor.LeaderJob())
+		}
+	}
 
 	// Create server
-	server := api.NewServer(cfg, redisQueue, dlq, publisher)
+	server, err := api.NewServer(cfg, redisQueue, dlq, publisher)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create server")
+	}
 
 	// Create HTTP server
 	httpServer := &http.Server{
@@ -66,6 +105,33 @@ func main() {
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
+	if cfg.Server.TLS.Enabled() {
+		if httpServer.TLSConfig, err = tlsutil.ServerConfig(&cfg.Server.TLS); err != nil {
+			log.Fatal().Err(err).Msg("Invalid server TLS config")
+		}
+	}
+
+	// Admin routes, metrics and pprof listen separately so they can be
+	// firewalled from the public API
+	var adminServer *http.Server
+	if handler := server.AdminHandler(); handler != nil {
+		host := cfg.Server.AdminHost
+		if host == "" {
+			host = cfg.Server.Host
+		}
+		adminServer = &http.Server{
+			Addr:         fmt.Sprintf("%s:%d", host, cfg.Server.AdminPort),
+			Handler:      handler,
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			IdleTimeout:  cfg.Server.IdleTimeout,
+		}
+		if cfg.Server.AdminTLS.Enabled() {
+			if adminServer.TLSConfig, err = tlsutil.ServerConfig(&cfg.Server.AdminTLS); err != nil {
+				log.Fatal().Err(err).Msg("Invalid admin TLS config")
+			}
+		}
+	}
 
 	// Start WebSocket hub
 	ctx, cancel := context.WithCancel(context.Background())
@@ -73,19 +139,38 @@ func main() {
 	server.Start(ctx)
 
 	// Start scheduler
-	scheduler.Start(ctx)
+	if scheduler != nil {
+		scheduler.Start(ctx)
+	} else {
+		log.Info().Msg("Embedded scheduler disabled; run cmd/scheduler")
+	}
 
 	// Start HTTP server
 	go func() {
 		log.Info().
 			Str("addr", httpServer.Addr).
+			Bool("tls", httpServer.TLSConfig != nil).
 			Msg("HTTP server listening")
 
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := listenAndServe(httpServer); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("HTTP server error")
 		}
 	}()
 
+	// Start admin server
+	if adminServer != nil {
+		go func() {
+			log.Info().
+				Str("addr", adminServer.Addr).
+				Bool("tls", adminServer.TLSConfig != nil).
+				Msg("Admin server listening")
+
+			if err := listenAndServe(adminServer); err != nil && err != http.ErrServerClosed {
+				log.Fatal().Err(err).Msg("Admin server error")
+			}
+		}()
+	}
+
 	// Wait for shutdown signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -98,15 +183,38 @@ func main() {
 	defer shutdownCancel()
 
 	// Stop scheduler
-	scheduler.Stop()
-
-	// Stop WebSocket hub
-	server.Stop()
+	if scheduler != nil {
+		scheduler.Stop()
+	}
 
-	// Shutdown HTTP server
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Error().Err(err).Msg("HTTP server shutdown error")
+	// Shutdown HTTP servers
+	var wg sync.WaitGroup
+	for _, srv := range []*http.Server{httpServer, adminServer} {
+		if srv == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				log.Error().Err(err).Str("addr", srv.Addr).Msg("HTTP server shutdown error")
+			}
+		}(srv)
 	}
+	wg.Wait()
+
+	// Stop WebSocket hub, redrive runners and the audit file once requests
+	// have drained
+	server.Stop()
 
 	log.Info().Msg("Server stopped")
 }
+
+// listenAndServe serves over TLS if the server has a TLS config; its
+// certificates come from the config, not from files passed here
+func listenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
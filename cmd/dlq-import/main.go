@@ -0,0 +1,77 @@
+// Command dlq-import restores DLQ entries from archives written by the DLQ janitor.
+//
+// Usage:
+//
+//	dlq-import <archive.ndjson[.gz]>...
+//
+// Redis connection settings are read from the usual config file and environment.
+// Tasks already in the DLQ are skipped, so re-running an import is safe.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/queue"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: dlq-import <archive.ndjson[.gz]>...")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	redisQueue, err := queue.NewRedisQueue(&cfg.Redis, &cfg.Queue)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to Redis: %v\n", err)
+		os.Exit(1)
+	}
+	defer redisQueue.Close()
+
+	dlq := queue.NewDLQ(redisQueue.Client())
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	failed := false
+	for _, path := range os.Args[1:] {
+		result, err := importFile(ctx, dlq, path)
+		fmt.Printf("%s: restored=%d skipped=%d invalid=%d", path, result.Restored, result.Skipped, result.Invalid)
+		if result.Truncated {
+			fmt.Print(" (truncated archive)")
+		}
+		fmt.Println()
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func importFile(ctx context.Context, dlq *queue.DLQ, path string) (queue.DLQImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return queue.DLQImportResult{}, err
+	}
+	defer f.Close()
+
+	return queue.ImportArchive(ctx, dlq, f)
+}
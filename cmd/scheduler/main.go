@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/events"
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/queue"
+)
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Initialize logger
+	logger.Init(cfg.LogLevel, os.Getenv("ENV") != "production")
+
+	log := logger.Get()
+	log.Info().Str("instance", queue.InstanceID()).Msg("Starting scheduler...")
+
+	// Create Redis queue
+	redisQueue, err := queue.NewRedisQueue(&cfg.Redis, &cfg.Queue)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create Redis queue")
+	}
+	defer func() {
+		if err := redisQueue.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close Redis queue")
+		}
+	}()
+
+	// Create event publisher
+	publisher := events.NewRedisPubSub(redisQueue.Client())
+	defer func() {
+		if err := publisher.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close event publisher")
+		}
+	}()
+
+	// Create scheduler; every replica runs it, only the elected leader does work
+	scheduler := queue.NewSchedulerWithConfig(redisQueue.Client(), redisQueue, &cfg.Scheduler)
+	scheduler.SetPublisher(publisher)
+
+	// DLQ retention runs on the leader
+	dlqJanitor := queue.NewDLQJanitor(redisQueue.Client(), &cfg.DLQ)
+	dlqJanitor.SetTenants(redisQueue.Tenants())
+	if dlqJanitor.Enabled() {
+		scheduler.RunWhileLeader(dlqJanitor.LeaderJob())
+	}
+
+	// Health and metrics server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := redisQueue.Client().Ping(r.Context()).Err(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "unhealthy",
+				"redis":  "disconnected",
+				"error":  err.Error(),
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "healthy",
+			"redis":     "connected",
+			"scheduler": scheduler.Status(),
+		})
+	})
+	if cfg.Metrics.Enabled {
+		mux.Handle(cfg.Metrics.Path, promhttp.Handler())
+	}
+
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Scheduler.HealthPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start scheduler
+	scheduler.Start(ctx)
+
+	// Start health server
+	go func() {
+		log.Info().
+			Str("addr", httpServer.Addr).
+			Msg("Scheduler health server listening")
+
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Health server error")
+		}
+	}()
+
+	// Wait for shutdown signal
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Info().Msg("Shutting down scheduler...")
+
+	// Stop scheduler first so the lease is released and a standby takes over
+	scheduler.Stop()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Health server shutdown error")
+	}
+
+	log.Info().Msg("Scheduler stopped")
+}
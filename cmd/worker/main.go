@@ -9,11 +9,14 @@ import (
 	"time"
 
 	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/encryption"
 	"github.com/maumercado/task-queue-go/internal/events"
 	"github.com/maumercado/task-queue-go/internal/logger"
 	"github.com/maumercado/task-queue-go/internal/queue"
+	"github.com/maumercado/task-queue-go/internal/redact"
 	"github.com/maumercado/task-queue-go/internal/task"
 	"github.com/maumercado/task-queue-go/internal/worker"
+	"github.com/maumercado/task-queue-go/internal/worker/handlers"
 )
 
 func main() {
@@ -41,8 +44,28 @@ func main() {
 		}
 	}()
 
+	// Serve only the configured tenants (all when empty)
+	if err := redisQueue.ServeTenants(cfg.Worker.Tenants); err != nil {
+		log.Fatal().Err(err).Msg("Invalid worker tenants")
+	}
+
+	// Hide sensitive payload fields in logs
+	redactor, err := redact.New(&cfg.Redaction)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid redaction config")
+	}
+	redact.SetDefault(redactor)
+
+	// Encrypt task payloads and results at rest if configured
+	sealer, err := encryption.NewSealer(&cfg.Encryption)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid encryption config")
+	}
+	redisQueue.SetSealer(sealer)
+
 	// Create DLQ
 	dlq := queue.NewDLQ(redisQueue.Client())
+	dlq.SetSealer(sealer)
 
 	// Create event publisher
 	publisher := events.NewRedisPubSub(redisQueue.Client())
@@ -53,15 +76,38 @@ func main() {
 	}()
 
 	// Register task handlers
-	handlers := map[string]worker.TaskHandler{
+	taskHandlers := map[string]worker.TaskHandler{
 		"echo":    echoHandler,
 		"sleep":   sleepHandler,
 		"compute": computeHandler,
 		"fail":    failHandler,
 	}
 
+	// Register external process handlers from config
+	processHandlers, err := handlers.ProcessHandlers(cfg.Worker.Processes)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid process handler config")
+	}
+	for taskType, h := range processHandlers {
+		taskHandlers[taskType] = h
+		log.Info().Str("type", taskType).Msg("Registered process handler")
+	}
+
+	// Register HTTP push-delivery handlers from config
+	pushHandlers, err := handlers.PushHandlers(cfg.Worker.Endpoints)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid endpoint handler config")
+	}
+	for taskType, h := range pushHandlers {
+		if _, exists := taskHandlers[taskType]; exists {
+			log.Fatal().Str("type", taskType).Msg("Task type bound to both a process and an endpoint")
+		}
+		taskHandlers[taskType] = h
+		log.Info().Str("type", taskType).Msg("Registered push handler")
+	}
+
 	// Create worker pool (queue config drives retry policy)
-	pool := worker.NewPool(&cfg.Worker, &cfg.Queue, redisQueue, dlq, handlers, publisher)
+	pool := worker.NewPool(&cfg.Worker, &cfg.Queue, redisQueue, dlq, taskHandlers, publisher)
 
 	// Start worker pool
 	ctx, cancel := context.WithCancel(context.Background())
@@ -94,7 +140,7 @@ func main() {
 func echoHandler(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
 	logger.Info().
 		Str("task_id", t.ID).
-		Interface("payload", t.Payload).
+		Interface("payload", redact.Data(t.Type, t.Payload)).
 		Msg("Echo handler processing task")
 
 	return map[string]interface{}{
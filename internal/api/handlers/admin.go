@@ -3,10 +3,16 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
+	"github.com/maumercado/task-queue-go/internal/apikey"
+	"github.com/maumercado/task-queue-go/internal/audit"
 	"github.com/maumercado/task-queue-go/internal/events"
 	"github.com/maumercado/task-queue-go/internal/logger"
 	"github.com/maumercado/task-queue-go/internal/queue"
@@ -18,14 +24,21 @@ import (
 type AdminHandler struct {
 	queue     *queue.RedisQueue
 	dlq       *queue.DLQ
+	redrive   *queue.RedriveManager
+	keys      *apikey.Store
+	audit     *audit.Log // nil when the audit log is disabled
 	publisher *events.RedisPubSub
+	filter    taskFilter
 }
 
 // NewAdminHandler creates a new admin handler
-func NewAdminHandler(q *queue.RedisQueue, dlq *queue.DLQ, publisher *events.RedisPubSub) *AdminHandler {
+func NewAdminHandler(q *queue.RedisQueue, dlq *queue.DLQ, redrive *queue.RedriveManager, keys *apikey.Store, auditLog *audit.Log, publisher *events.RedisPubSub) *AdminHandler {
 	return &AdminHandler{
 		queue:     q,
 		dlq:       dlq,
+		redrive:   redrive,
+		keys:      keys,
+		audit:     auditLog,
 		publisher: publisher,
 	}
 }
@@ -84,7 +97,7 @@ func (h *AdminHandler) GetWorker(w http.ResponseWriter, r *http.Request) {
 
 // GetQueues handles GET /admin/queues
 func (h *AdminHandler) GetQueues(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.queue.GetQueueStats(r.Context())
+	stats, err := h.queueFor(r).GetQueueStats(r.Context())
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to get queue stats")
 		h.respondError(w, http.StatusInternalServerError, "failed to get queue statistics")
@@ -92,27 +105,135 @@ func (h *AdminHandler) GetQueues(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// DLQ size
-	if dlqSize, err := h.dlq.Size(r.Context()); err == nil {
+	if dlqSize, err := h.dlqFor(r).Size(r.Context()); err == nil {
 		stats.DLQSize = dlqSize
 	}
 
 	h.respondJSON(w, http.StatusOK, stats)
 }
 
+const (
+	defaultDLQPageSize = 100
+	maxDLQPageSize     = 1000
+)
+
 // ListDLQ handles GET /admin/dlq
+// Query params: limit, cursor, type, reason, error, added_after, added_before (RFC 3339)
 func (h *AdminHandler) ListDLQ(w http.ResponseWriter, r *http.Request) {
-	entries, err := h.dlq.List(r.Context(), 100, "")
+	filter, cursor, limit, err := parseDLQQuery(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.dlqFor(r).ListPage(r.Context(), filter, cursor, limit)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to list DLQ")
 		h.respondError(w, http.StatusInternalServerError, "failed to list DLQ")
 		return
 	}
 
-	size, _ := h.dlq.Size(r.Context())
+	for i := range page.Entries {
+		h.filter.dlqEntry(r, &page.Entries[i])
+	}
+	size, _ := h.dlqFor(r).Size(r.Context())
 
-	h.respondJSON(w, http.StatusOK, map[string]interface{}{
-		"entries": entries,
+	response := map[string]interface{}{
+		"entries": page.Entries,
 		"size":    size,
+	}
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// parseDLQQuery reads the filter, cursor and page size from the query string
+func parseDLQQuery(r *http.Request) (queue.DLQFilter, string, int64, error) {
+	q := r.URL.Query()
+	filter := queue.DLQFilter{
+		TaskType:      q.Get("type"),
+		Reason:        q.Get("reason"),
+		ErrorContains: q.Get("error"),
+	}
+
+	limit := int64(defaultDLQPageSize)
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return filter, "", 0, fmt.Errorf("limit must be a positive integer")
+		}
+		limit = min(n, maxDLQPageSize)
+	}
+
+	for param, dst := range map[string]*time.Time{
+		"added_after":  &filter.AddedAfter,
+		"added_before": &filter.AddedBefore,
+	} {
+		if v := q.Get(param); v != "" {
+			ts, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, "", 0, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+			}
+			*dst = ts
+		}
+	}
+
+	return filter, q.Get("cursor"), limit, nil
+}
+
+// GetDLQEntry handles GET /admin/dlq/{taskID}
+func (h *AdminHandler) GetDLQEntry(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if taskID == "" {
+		h.respondError(w, http.StatusBadRequest, "task ID is required")
+		return
+	}
+
+	entry, err := h.dlqFor(r).Get(r.Context(), taskID)
+	if err != nil {
+		if err == task.ErrTaskNotFound {
+			h.respondError(w, http.StatusNotFound, "task not found in DLQ")
+			return
+		}
+		logger.Error().Err(err).Str("task_id", taskID).Msg("failed to get DLQ entry")
+		h.respondError(w, http.StatusInternalServerError, "failed to get DLQ entry")
+		return
+	}
+
+	h.filter.dlqEntry(r, entry)
+	h.respondJSON(w, http.StatusOK, entry)
+}
+
+// DeleteDLQEntry handles DELETE /admin/dlq/{taskID}
+func (h *AdminHandler) DeleteDLQEntry(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if taskID == "" {
+		h.respondError(w, http.StatusBadRequest, "task ID is required")
+		return
+	}
+
+	entry, err := h.dlqFor(r).Get(r.Context(), taskID)
+	if err != nil {
+		if err == task.ErrTaskNotFound {
+			h.respondError(w, http.StatusNotFound, "task not found in DLQ")
+			return
+		}
+		logger.Error().Err(err).Str("task_id", taskID).Msg("failed to get DLQ entry")
+		h.respondError(w, http.StatusInternalServerError, "failed to delete DLQ entry")
+		return
+	}
+
+	if err := h.dlqFor(r).Remove(r.Context(), taskID, entry.MessageID); err != nil {
+		logger.Error().Err(err).Str("task_id", taskID).Msg("failed to delete DLQ entry")
+		h.respondError(w, http.StatusInternalServerError, "failed to delete DLQ entry")
+		return
+	}
+
+	logger.Info().Str("task_id", taskID).Msg("DLQ entry deleted")
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "DLQ entry deleted",
+		"task_id": taskID,
 	})
 }
 
@@ -132,7 +253,7 @@ func (h *AdminHandler) RetryDLQ(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.RetryAll {
-		count, err := h.dlq.RetryAll(r.Context(), h.queue)
+		count, err := h.dlqFor(r).RetryAll(r.Context(), h.queueFor(r))
 		if err != nil {
 			logger.Error().Err(err).Msg("failed to retry all DLQ tasks")
 			h.respondError(w, http.StatusInternalServerError, "failed to retry DLQ tasks")
@@ -151,7 +272,7 @@ func (h *AdminHandler) RetryDLQ(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.dlq.Retry(r.Context(), h.queue, req.TaskID, req.MessageID); err != nil {
+	if err := h.dlqFor(r).Retry(r.Context(), h.queueFor(r), req.TaskID, req.MessageID); err != nil {
 		if err == task.ErrTaskNotFound {
 			h.respondError(w, http.StatusNotFound, "task not found in DLQ")
 			return
@@ -169,7 +290,7 @@ func (h *AdminHandler) RetryDLQ(w http.ResponseWriter, r *http.Request) {
 
 // ClearDLQ handles DELETE /admin/dlq
 func (h *AdminHandler) ClearDLQ(w http.ResponseWriter, r *http.Request) {
-	if err := h.dlq.Clear(r.Context()); err != nil {
+	if err := h.dlqFor(r).Clear(r.Context()); err != nil {
 		logger.Error().Err(err).Msg("failed to clear DLQ")
 		h.respondError(w, http.StatusInternalServerError, "failed to clear DLQ")
 		return
@@ -192,10 +313,28 @@ func (h *AdminHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+	resp := map[string]interface{}{
 		"status": "healthy",
 		"redis":  "connected",
-	})
+	}
+
+	// Scheduler leadership is informational and never fails the health check
+	leader, err := queue.GetSchedulerLeader(r.Context(), h.queue.Client())
+	if err != nil {
+		logger.Warn().Err(err).Msg("failed to read scheduler leader")
+	}
+	resp["scheduler"] = schedulerHealth(leader, queue.InstanceID())
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// schedulerHealth describes scheduler leadership from this instance's point of view
+func schedulerHealth(leader *queue.SchedulerLeader, instance string) map[string]interface{} {
+	return map[string]interface{}{
+		"instance":  instance,
+		"leader":    leader,
+		"is_leader": leader != nil && leader.Instance == instance,
+	}
 }
 
 // RetryTask handles POST /admin/tasks/{taskID}/retry
@@ -206,7 +345,7 @@ func (h *AdminHandler) RetryTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t, err := h.queue.GetTask(r.Context(), taskID)
+	t, err := h.queueFor(r).GetTask(r.Context(), taskID)
 	if err != nil {
 		if err == task.ErrTaskNotFound {
 			h.respondError(w, http.StatusNotFound, "task not found")
@@ -231,14 +370,14 @@ func (h *AdminHandler) RetryTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update task in storage
-	if err := h.queue.UpdateTask(r.Context(), t); err != nil {
+	if err := h.queueFor(r).UpdateTask(r.Context(), t); err != nil {
 		logger.Error().Err(err).Str("task_id", taskID).Msg("failed to update task")
 		h.respondError(w, http.StatusInternalServerError, "failed to retry task")
 		return
 	}
 
 	// Re-enqueue task
-	if err := h.queue.Enqueue(r.Context(), t); err != nil {
+	if err := h.queueFor(r).Enqueue(r.Context(), t); err != nil {
 		logger.Error().Err(err).Str("task_id", taskID).Msg("failed to enqueue task")
 		h.respondError(w, http.StatusInternalServerError, "failed to retry task")
 		return
@@ -340,8 +479,8 @@ func (h *AdminHandler) PurgeQueue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get stream name
-	streamName := "tasks:" + priority
+	// Get the stream of the request's tenant
+	streamName := h.queueFor(r).QueueStream(p)
 
 	// Delete the stream (removes all messages)
 	if err := h.queue.Client().Del(r.Context(), streamName).Err(); err != nil {
@@ -364,6 +503,16 @@ func (h *AdminHandler) PurgeQueue(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// queueFor returns the queue view of the request's tenant
+func (h *AdminHandler) queueFor(r *http.Request) *queue.RedisQueue {
+	return h.queue.ForTenant(apiMiddleware.TenantFromContext(r.Context()))
+}
+
+// dlqFor returns the DLQ view of the request's tenant
+func (h *AdminHandler) dlqFor(r *http.Request) *queue.DLQ {
+	return h.dlq.ForTenant(apiMiddleware.TenantFromContext(r.Context()))
+}
+
 func (h *AdminHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
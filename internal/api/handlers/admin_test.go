@@ -6,10 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/queue"
 )
 
 func TestAdminHandler_respondJSON(t *testing.T) {
@@ -183,3 +186,68 @@ func TestRetryDLQRequest_RetryAll(t *testing.T) {
 	assert.True(t, decoded.RetryAll)
 	assert.Empty(t, decoded.TaskID)
 }
+
+func TestParseDLQQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet,
+		"/admin/dlq?type=email&reason=permanent+failure&error=smtp&limit=5000&cursor=1-0&added_after=2024-01-15T10:00:00Z", nil)
+
+	filter, cursor, limit, err := parseDLQQuery(req)
+	require.NoError(t, err)
+	assert.Equal(t, "email", filter.TaskType)
+	assert.Equal(t, "permanent failure", filter.Reason)
+	assert.Equal(t, "smtp", filter.ErrorContains)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), filter.AddedAfter)
+	assert.True(t, filter.AddedBefore.IsZero())
+	assert.Equal(t, "1-0", cursor)
+	assert.Equal(t, int64(maxDLQPageSize), limit, "limit is capped")
+
+	_, _, limit, err = parseDLQQuery(httptest.NewRequest(http.MethodGet, "/admin/dlq", nil))
+	require.NoError(t, err)
+	assert.Equal(t, int64(defaultDLQPageSize), limit)
+}
+
+func TestAdminHandler_ListDLQ_InvalidQuery(t *testing.T) {
+	h := &AdminHandler{}
+
+	for _, query := range []string{"limit=0", "limit=abc", "added_before=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/dlq?"+query, nil)
+		w := httptest.NewRecorder()
+
+		h.ListDLQ(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestAdminHandler_DLQEntry_MissingID(t *testing.T) {
+	h := &AdminHandler{}
+
+	for _, handle := range []http.HandlerFunc{h.GetDLQEntry, h.DeleteDLQEntry} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/dlq/", nil)
+		w := httptest.NewRecorder()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("taskID", "")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		handle(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestSchedulerHealth(t *testing.T) {
+	leader := &queue.SchedulerLeader{Instance: "api-1-1-abcd1234", Fence: 7, LeaseRemainingMs: 4200}
+
+	h := schedulerHealth(leader, "api-1-1-abcd1234")
+	assert.Equal(t, true, h["is_leader"])
+	assert.Equal(t, leader, h["leader"])
+
+	h = schedulerHealth(leader, "api-2-1-ffff0000")
+	assert.Equal(t, false, h["is_leader"])
+	assert.Equal(t, "api-2-1-ffff0000", h["instance"])
+
+	h = schedulerHealth(nil, "api-2-1-ffff0000")
+	assert.Equal(t, false, h["is_leader"])
+	assert.Nil(t, h["leader"])
+}
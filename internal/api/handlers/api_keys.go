@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
+	"github.com/maumercado/task-queue-go/internal/apikey"
+	"github.com/maumercado/task-queue-go/internal/logger"
+)
+
+// defaultRotationGrace is how long a rotated key keeps working by default
+const defaultRotationGrace = 24 * time.Hour
+
+// CreateAPIKeyRequest represents a request to create an API key
+type CreateAPIKeyRequest struct {
+	Name         string     `json:"name"`
+	Roles        []string   `json:"roles"`
+	TaskTypes    []string   `json:"task_types,omitempty"`
+	ReadOnly     bool       `json:"read_only,omitempty"`
+	Tenant       string     `json:"tenant,omitempty"`
+	RateLimitRPS int        `json:"rate_limit_rps,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	TTLSeconds   int64      `json:"ttl_seconds,omitempty"` // Alternative to expires_at
+}
+
+// ExpireAPIKeyRequest represents the optional body of an expire request
+type ExpireAPIKeyRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Defaults to now
+}
+
+// RotateAPIKeyRequest represents the optional body of a rotate request
+type RotateAPIKeyRequest struct {
+	GraceSeconds *int64 `json:"grace_seconds,omitempty"` // How long the old key keeps working
+}
+
+// APIKeyResponse is an API key as returned by the admin API
+type APIKeyResponse struct {
+	*apikey.Key
+	Status string `json:"status"`
+}
+
+// CreatedAPIKeyResponse carries a new key's secret, which is only shown once
+type CreatedAPIKeyResponse struct {
+	Key    string          `json:"key"`
+	APIKey *APIKeyResponse `json:"api_key"`
+}
+
+// toStoreRequest validates the request and converts it for the key store
+func (req *CreateAPIKeyRequest) toStoreRequest(now time.Time, hasTenant func(string) bool) (apikey.CreateRequest, error) {
+	out := apikey.CreateRequest{
+		Name:         req.Name,
+		Roles:        req.Roles,
+		TaskTypes:    req.TaskTypes,
+		ReadOnly:     req.ReadOnly,
+		Tenant:       req.Tenant,
+		RateLimitRPS: req.RateLimitRPS,
+		ExpiresAt:    req.ExpiresAt,
+	}
+
+	if req.Name == "" {
+		return out, fmt.Errorf("name is required")
+	}
+	if len(req.Roles) == 0 {
+		return out, fmt.Errorf("at least one role is required")
+	}
+	for _, role := range req.Roles {
+		if !apiMiddleware.IsKnownRole(role) {
+			return out, fmt.Errorf("unknown role: %s", role)
+		}
+	}
+	for _, taskType := range req.TaskTypes {
+		if taskType == "" {
+			return out, fmt.Errorf("task_types must not contain empty types")
+		}
+	}
+	if req.Tenant != "" && !hasTenant(req.Tenant) {
+		return out, fmt.Errorf("unknown tenant: %s", req.Tenant)
+	}
+	if req.RateLimitRPS < 0 {
+		return out, fmt.Errorf("rate_limit_rps must not be negative")
+	}
+
+	switch {
+	case req.ExpiresAt != nil && req.TTLSeconds != 0:
+		return out, fmt.Errorf("set expires_at or ttl_seconds, not both")
+	case req.TTLSeconds < 0:
+		return out, fmt.Errorf("ttl_seconds must be positive")
+	case req.TTLSeconds > 0:
+		at := now.Add(time.Duration(req.TTLSeconds) * time.Second)
+		out.ExpiresAt = &at
+	case req.ExpiresAt != nil && !req.ExpiresAt.After(now):
+		return out, fmt.Errorf("expires_at must be in the future")
+	}
+
+	return out, nil
+}
+
+// CreateAPIKey handles POST /admin/api-keys
+func (h *AdminHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	storeReq, err := req.toStoreRequest(time.Now().UTC(), h.queue.HasTenant)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, secret, err := h.keys.Create(r.Context(), storeReq)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to create api key")
+		h.respondError(w, http.StatusInternalServerError, "failed to create api key")
+		return
+	}
+
+	logger.Info().
+		Str("key_id", key.ID).
+		Str("name", key.Name).
+		Strs("roles", key.Roles).
+		Str("tenant", key.Tenant).
+		Msg("api key created")
+
+	h.respondJSON(w, http.StatusCreated, CreatedAPIKeyResponse{
+		Key:    secret,
+		APIKey: newAPIKeyResponse(key),
+	})
+}
+
+// ListAPIKeys handles GET /admin/api-keys
+func (h *AdminHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keys.List(r.Context())
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to list api keys")
+		h.respondError(w, http.StatusInternalServerError, "failed to list api keys")
+		return
+	}
+
+	out := make([]*APIKeyResponse, len(keys))
+	for i, key := range keys {
+		out[i] = newAPIKeyResponse(key)
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"api_keys": out,
+		"count":    len(out),
+	})
+}
+
+// GetAPIKey handles GET /admin/api-keys/{keyID}
+func (h *AdminHandler) GetAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "keyID")
+	key, err := h.keys.Get(r.Context(), keyID)
+	if err != nil {
+		h.respondAPIKeyError(w, keyID, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newAPIKeyResponse(key))
+}
+
+// RevokeAPIKey handles POST /admin/api-keys/{keyID}/revoke
+func (h *AdminHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "keyID")
+	key, err := h.keys.Revoke(r.Context(), keyID)
+	if err != nil {
+		h.respondAPIKeyError(w, keyID, err)
+		return
+	}
+
+	logger.Info().Str("key_id", keyID).Msg("api key revoked")
+	h.respondJSON(w, http.StatusOK, newAPIKeyResponse(key))
+}
+
+// ExpireAPIKey handles POST /admin/api-keys/{keyID}/expire
+func (h *AdminHandler) ExpireAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "keyID")
+
+	// The body is optional
+	var req ExpireAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	at := time.Now().UTC()
+	if req.ExpiresAt != nil {
+		at = *req.ExpiresAt
+	}
+
+	key, err := h.keys.Expire(r.Context(), keyID, at)
+	if err != nil {
+		h.respondAPIKeyError(w, keyID, err)
+		return
+	}
+
+	logger.Info().Str("key_id", keyID).Time("expires_at", at).Msg("api key expiry set")
+	h.respondJSON(w, http.StatusOK, newAPIKeyResponse(key))
+}
+
+// RotateAPIKey handles POST /admin/api-keys/{keyID}/rotate
+func (h *AdminHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "keyID")
+
+	// The body is optional
+	var req RotateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	grace := defaultRotationGrace
+	if req.GraceSeconds != nil {
+		if *req.GraceSeconds < 0 {
+			h.respondError(w, http.StatusBadRequest, "grace_seconds must not be negative")
+			return
+		}
+		grace = time.Duration(*req.GraceSeconds) * time.Second
+	}
+
+	key, secret, err := h.keys.Rotate(r.Context(), keyID, grace)
+	if err != nil {
+		h.respondAPIKeyError(w, keyID, err)
+		return
+	}
+
+	logger.Info().
+		Str("key_id", keyID).
+		Str("new_key_id", key.ID).
+		Dur("grace", grace).
+		Msg("api key rotated")
+
+	h.respondJSON(w, http.StatusCreated, CreatedAPIKeyResponse{
+		Key:    secret,
+		APIKey: newAPIKeyResponse(key),
+	})
+}
+
+func newAPIKeyResponse(key *apikey.Key) *APIKeyResponse {
+	return &APIKeyResponse{Key: key, Status: key.Status(time.Now())}
+}
+
+func (h *AdminHandler) respondAPIKeyError(w http.ResponseWriter, keyID string, err error) {
+	switch {
+	case errors.Is(err, apikey.ErrKeyNotFound):
+		h.respondError(w, http.StatusNotFound, "api key not found")
+	case errors.Is(err, apikey.ErrKeyRevoked), errors.Is(err, apikey.ErrKeyExpired):
+		h.respondError(w, http.StatusConflict, err.Error())
+	default:
+		logger.Error().Err(err).Str("key_id", keyID).Msg("api key operation failed")
+		h.respondError(w, http.StatusInternalServerError, "api key operation failed")
+	}
+}
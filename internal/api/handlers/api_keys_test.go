@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/apikey"
+)
+
+func TestCreateAPIKeyRequest_toStoreRequest(t *testing.T) {
+	now := time.Now().UTC()
+	hasTenant := func(tenant string) bool { return tenant == "acme" }
+
+	req := CreateAPIKeyRequest{
+		Name:         "ci",
+		Roles:        []string{"submitter"},
+		TaskTypes:    []string{"email"},
+		Tenant:       "acme",
+		RateLimitRPS: 5,
+		TTLSeconds:   3600,
+	}
+	out, err := req.toStoreRequest(now, hasTenant)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", out.Name)
+	assert.Equal(t, []string{"email"}, out.TaskTypes)
+	assert.Equal(t, "acme", out.Tenant)
+	assert.Equal(t, 5, out.RateLimitRPS)
+	require.NotNil(t, out.ExpiresAt)
+	assert.Equal(t, now.Add(time.Hour), *out.ExpiresAt)
+
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	bad := []CreateAPIKeyRequest{
+		{Roles: []string{"viewer"}},
+		{Name: "ci"},
+		{Name: "ci", Roles: []string{"superuser"}},
+		{Name: "ci", Roles: []string{"viewer"}, TaskTypes: []string{""}},
+		{Name: "ci", Roles: []string{"viewer"}, Tenant: "globex"},
+		{Name: "ci", Roles: []string{"viewer"}, RateLimitRPS: -1},
+		{Name: "ci", Roles: []string{"viewer"}, TTLSeconds: -1},
+		{Name: "ci", Roles: []string{"viewer"}, ExpiresAt: &past},
+		{Name: "ci", Roles: []string{"viewer"}, ExpiresAt: &future, TTLSeconds: 60},
+	}
+	for _, r := range bad {
+		_, err := r.toStoreRequest(now, hasTenant)
+		assert.Error(t, err, "%+v", r)
+	}
+}
+
+func TestAPIKeyResponse_JSON(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	key := &apikey.Key{ID: "id-1", Name: "ci", Hash: "secret-hash", Roles: []string{"viewer"}, RevokedAt: &past}
+
+	data, err := json.Marshal(newAPIKeyResponse(key))
+	require.NoError(t, err)
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, "id-1", out["id"])
+	assert.Equal(t, "revoked", out["status"])
+	assert.NotContains(t, string(data), "secret-hash")
+}
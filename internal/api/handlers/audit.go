@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
+	"github.com/maumercado/task-queue-go/internal/audit"
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/queue"
+)
+
+// ListAudit handles GET /admin/audit
+// Query params: limit, cursor, actor, action (exact or prefix ending in "."),
+// outcome, tenant, since, until (RFC 3339)
+func (h *AdminHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		h.respondError(w, http.StatusNotFound, "audit log is disabled")
+		return
+	}
+
+	filter, err := parseAuditQuery(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Tenant-scoped requests only see their own tenant's entries
+	if apiMiddleware.TenantScoped(r.Context()) {
+		tenant := apiMiddleware.TenantFromContext(r.Context())
+		filter.Tenant = &tenant
+	}
+
+	entries, next, err := h.audit.Query(r.Context(), filter)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to query audit log")
+		h.respondError(w, http.StatusInternalServerError, "failed to query audit log")
+		return
+	}
+	if entries == nil {
+		entries = []*audit.Entry{}
+	}
+
+	response := map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	}
+	if next != "" {
+		response["next_cursor"] = next
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// parseAuditQuery reads the audit filter from the query string
+func parseAuditQuery(r *http.Request) (audit.Filter, error) {
+	q := r.URL.Query()
+	filter := audit.Filter{
+		Actor:   q.Get("actor"),
+		Action:  q.Get("action"),
+		Outcome: q.Get("outcome"),
+		Before:  q.Get("cursor"),
+	}
+
+	switch filter.Outcome {
+	case "", audit.OutcomeSuccess, audit.OutcomeDenied, audit.OutcomeFailure:
+	default:
+		return filter, fmt.Errorf("outcome must be success, denied or failure")
+	}
+
+	if q.Has("tenant") {
+		tenant := queue.ParseTenantName(q.Get("tenant"))
+		filter.Tenant = &tenant
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = min(n, audit.MaxQueryLimit)
+	}
+
+	for param, dst := range map[string]*time.Time{
+		"since": &filter.Since,
+		"until": &filter.Until,
+	} {
+		if v := q.Get(param); v != "" {
+			ts, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+			}
+			*dst = ts
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/audit"
+)
+
+func TestParseAuditQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet,
+		"/admin/audit?actor=alice&action=dlq.&outcome=denied&tenant=default&since=2024-01-15T10:00:00Z&limit=5000&cursor=1-0", nil)
+
+	filter, err := parseAuditQuery(req)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", filter.Actor)
+	assert.Equal(t, "dlq.", filter.Action)
+	assert.Equal(t, audit.OutcomeDenied, filter.Outcome)
+	require.NotNil(t, filter.Tenant)
+	assert.Equal(t, "", *filter.Tenant)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), filter.Since)
+	assert.True(t, filter.Until.IsZero())
+	assert.Equal(t, audit.MaxQueryLimit, filter.Limit)
+	assert.Equal(t, "1-0", filter.Before)
+
+	filter, err = parseAuditQuery(httptest.NewRequest(http.MethodGet, "/admin/audit", nil))
+	require.NoError(t, err)
+	assert.Nil(t, filter.Tenant)
+
+	for _, query := range []string{"outcome=maybe", "limit=0", "limit=x", "since=yesterday", "until=2024-01-15"} {
+		_, err := parseAuditQuery(httptest.NewRequest(http.MethodGet, "/admin/audit?"+query, nil))
+		assert.Error(t, err, query)
+	}
+}
+
+func TestAdminHandler_ListAudit_Disabled(t *testing.T) {
+	h := &AdminHandler{}
+	w := httptest.NewRecorder()
+
+	h.ListAudit(w, httptest.NewRequest(http.MethodGet, "/admin/audit", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
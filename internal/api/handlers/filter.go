@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
+	"github.com/maumercado/task-queue-go/internal/queue"
+	"github.com/maumercado/task-queue-go/internal/redact"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// taskFilter hides task data from callers without the roles to see it: the
+// payloads and results of encrypted tasks, and sensitive fields of the rest
+type taskFilter struct {
+	encryptedReaders []string // Roles that may read encrypted payloads and results
+	redactor         *redact.Redactor
+	revealRoles      []string // Roles that see sensitive fields unredacted
+}
+
+// SetEncryptedReaders restricts the decrypted payloads and results of
+// encrypted tasks to callers holding one of roles
+func (h *TaskHandler) SetEncryptedReaders(roles []string) {
+	h.filter.encryptedReaders = roles
+}
+
+// SetRedaction redacts sensitive payload and result fields in responses to
+// callers holding none of revealRoles
+func (h *TaskHandler) SetRedaction(r *redact.Redactor, revealRoles []string) {
+	h.filter.redactor = r
+	h.filter.revealRoles = revealRoles
+}
+
+// SetEncryptedReaders restricts the decrypted payloads and results of
+// encrypted tasks in the DLQ and run-now responses to callers holding one of
+// roles
+func (h *AdminHandler) SetEncryptedReaders(roles []string) {
+	h.filter.encryptedReaders = roles
+}
+
+// SetRedaction redacts sensitive payload and result fields in the DLQ and
+// run-now responses for callers holding none of revealRoles
+func (h *AdminHandler) SetRedaction(r *redact.Redactor, revealRoles []string) {
+	h.filter.redactor = r
+	h.filter.revealRoles = revealRoles
+}
+
+// response converts a task for a response to r's caller
+func (f *taskFilter) response(r *http.Request, t *task.Task) *task.TaskResponse {
+	resp := t.ToResponse()
+	if resp.Encrypted && !apiMiddleware.CallerHasAnyRole(r.Context(), f.encryptedReaders) {
+		resp.Payload = nil
+		resp.Result = nil
+	}
+	if !apiMiddleware.CallerHasAnyRole(r.Context(), f.revealRoles) {
+		f.redactor.Response(resp)
+	}
+	return resp
+}
+
+// dlqEntry filters the task of a DLQ entry for r's caller. The entry gets a
+// copy, so the task itself is not modified.
+func (f *taskFilter) dlqEntry(r *http.Request, entry *queue.DLQEntry) {
+	t := entry.Task
+	if t == nil {
+		return
+	}
+	if (t.Encrypted || t.Sealed != nil) && !apiMiddleware.CallerHasAnyRole(r.Context(), f.encryptedReaders) {
+		redacted := *t
+		redacted.Payload = nil
+		redacted.Result = nil
+		entry.Task = &redacted
+		return
+	}
+	if !apiMiddleware.CallerHasAnyRole(r.Context(), f.revealRoles) {
+		entry.Task = f.redactor.Task(t)
+	}
+}
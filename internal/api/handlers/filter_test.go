@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/queue"
+	"github.com/maumercado/task-queue-go/internal/redact"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func requestAs(claims *apiMiddleware.Claims) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/x", nil)
+	if claims == nil {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), apiMiddleware.UserContextKey, claims))
+}
+
+func TestTaskFilter_Encrypted(t *testing.T) {
+	f := &taskFilter{encryptedReaders: []string{apiMiddleware.RoleSubmitter}}
+	tk := task.New("email", map[string]interface{}{"to": "a@example.com"}, task.PriorityNormal)
+	tk.Result = map[string]interface{}{"ok": true}
+
+	// Unencrypted tasks are never hidden
+	resp := f.response(requestAs(&apiMiddleware.Claims{Role: apiMiddleware.RoleViewer}), tk)
+	assert.False(t, resp.Encrypted)
+	assert.NotNil(t, resp.Payload)
+
+	tk.Encrypted = true
+	for _, tc := range []struct {
+		name     string
+		claims   *apiMiddleware.Claims
+		readable bool
+	}{
+		{"no auth", nil, true},
+		{"reader role", &apiMiddleware.Claims{Role: apiMiddleware.RoleSubmitter}, true},
+		{"implied reader role", &apiMiddleware.Claims{Role: apiMiddleware.RoleOperator}, true},
+		{"other role", &apiMiddleware.Claims{Role: apiMiddleware.RoleViewer}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := f.response(requestAs(tc.claims), tk)
+			assert.True(t, resp.Encrypted)
+			if tc.readable {
+				assert.NotNil(t, resp.Payload)
+				assert.NotNil(t, resp.Result)
+			} else {
+				assert.Nil(t, resp.Payload)
+				assert.Nil(t, resp.Result)
+			}
+		})
+	}
+}
+
+func TestTaskFilter_Redaction(t *testing.T) {
+	redactor, err := redact.New(&config.RedactionConfig{
+		Fields: []string{"password"},
+		Rules:  []config.RedactionRule{{TaskType: "payment", Fields: []string{"$.card.number"}}},
+	})
+	require.NoError(t, err)
+	f := &taskFilter{redactor: redactor, revealRoles: []string{apiMiddleware.RoleOperator}}
+
+	tk := task.New("payment", map[string]interface{}{
+		"password": "hunter2",
+		"card":     map[string]interface{}{"number": "4111111111111111", "brand": "visa"},
+	}, task.PriorityNormal)
+
+	resp := f.response(requestAs(&apiMiddleware.Claims{Role: apiMiddleware.RoleSubmitter}), tk)
+	assert.Equal(t, redact.Value, resp.Payload["password"])
+	assert.Equal(t, redact.Value, resp.Payload["card"].(map[string]interface{})["number"])
+	assert.Equal(t, "visa", resp.Payload["card"].(map[string]interface{})["brand"])
+	assert.Equal(t, "hunter2", tk.Payload["password"], "the task itself must not be modified")
+
+	for _, claims := range []*apiMiddleware.Claims{nil, {Role: apiMiddleware.RoleOperator}, {Role: apiMiddleware.RoleAdmin}} {
+		resp := f.response(requestAs(claims), tk)
+		assert.Equal(t, "hunter2", resp.Payload["password"])
+	}
+}
+
+func TestAdminHandler_DLQEntryFilter(t *testing.T) {
+	redactor, err := redact.New(&config.RedactionConfig{Fields: []string{"password"}})
+	require.NoError(t, err)
+
+	h := &AdminHandler{}
+	h.SetEncryptedReaders([]string{apiMiddleware.RoleSubmitter})
+	h.SetRedaction(redactor, []string{apiMiddleware.RoleOperator})
+	viewer := requestAs(&apiMiddleware.Claims{Role: apiMiddleware.RoleViewer})
+
+	encrypted := task.New("email", map[string]interface{}{"to": "a@example.com"}, task.PriorityNormal)
+	encrypted.Encrypted = true
+	entry := &queue.DLQEntry{Task: encrypted}
+	h.filter.dlqEntry(viewer, entry)
+	assert.Nil(t, entry.Task.Payload)
+	assert.NotNil(t, encrypted.Payload, "the stored task must not be modified")
+
+	entry = &queue.DLQEntry{Task: encrypted}
+	h.filter.dlqEntry(requestAs(&apiMiddleware.Claims{Role: apiMiddleware.RoleAdmin}), entry)
+	assert.NotNil(t, entry.Task.Payload)
+
+	plain := task.New("login", map[string]interface{}{"user": "bob", "password": "hunter2"}, task.PriorityNormal)
+	entry = &queue.DLQEntry{Task: plain}
+	h.filter.dlqEntry(viewer, entry)
+	assert.Equal(t, redact.Value, entry.Task.Payload["password"])
+	assert.Equal(t, "bob", entry.Task.Payload["user"])
+	assert.Equal(t, "hunter2", plain.Payload["password"])
+
+	entry = &queue.DLQEntry{Task: plain}
+	h.filter.dlqEntry(requestAs(&apiMiddleware.Claims{Role: apiMiddleware.RoleOperator}), entry)
+	assert.Equal(t, "hunter2", entry.Task.Payload["password"])
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/queue"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// RedriveRequest represents a request to start a DLQ redrive job
+type RedriveRequest struct {
+	Filter struct {
+		Type        string     `json:"type,omitempty"`
+		Reason      string     `json:"reason,omitempty"`
+		Error       string     `json:"error,omitempty"`
+		AddedAfter  *time.Time `json:"added_after,omitempty"`
+		AddedBefore *time.Time `json:"added_before,omitempty"`
+	} `json:"filter"`
+	PayloadPatch  map[string]interface{} `json:"payload_patch,omitempty"`
+	MetadataPatch map[string]interface{} `json:"metadata_patch,omitempty"`
+	Priority      string                 `json:"priority,omitempty"` // low, normal, high, critical
+	RatePerSecond float64                `json:"rate_per_second,omitempty"`
+}
+
+// toQueueRequest validates the request and converts it for the redrive manager
+func (req *RedriveRequest) toQueueRequest() (queue.RedriveRequest, error) {
+	out := queue.RedriveRequest{
+		Filter: queue.DLQFilter{
+			TaskType:      req.Filter.Type,
+			Reason:        req.Filter.Reason,
+			ErrorContains: req.Filter.Error,
+		},
+		Patch: queue.RedrivePatch{
+			Payload:  req.PayloadPatch,
+			Metadata: req.MetadataPatch,
+		},
+		RatePerSecond: req.RatePerSecond,
+	}
+	if req.Filter.AddedAfter != nil {
+		out.Filter.AddedAfter = *req.Filter.AddedAfter
+	}
+	if req.Filter.AddedBefore != nil {
+		out.Filter.AddedBefore = *req.Filter.AddedBefore
+	}
+
+	if req.Priority != "" {
+		p := task.ParsePriority(req.Priority)
+		if p.String() != req.Priority {
+			return out, fmt.Errorf("invalid priority: %s", req.Priority)
+		}
+		out.Priority = &p
+	}
+	if req.RatePerSecond < 0 {
+		return out, fmt.Errorf("rate_per_second must not be negative")
+	}
+
+	return out, nil
+}
+
+// CreateRedrive handles POST /admin/dlq/redrive
+func (h *AdminHandler) CreateRedrive(w http.ResponseWriter, r *http.Request) {
+	var req RedriveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	redriveReq, err := req.toQueueRequest()
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	redriveReq.Tenant = apiMiddleware.TenantFromContext(r.Context())
+
+	job, err := h.redrive.Create(r.Context(), redriveReq)
+	if err != nil {
+		if errors.Is(err, queue.ErrRedriveInvalidPatch) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Error().Err(err).Msg("failed to create redrive job")
+		h.respondError(w, http.StatusInternalServerError, "failed to create redrive job")
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, job)
+}
+
+// ListRedrives handles GET /admin/dlq/redrive
+func (h *AdminHandler) ListRedrives(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.redrive.List(r.Context(), 100)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to list redrive jobs")
+		h.respondError(w, http.StatusInternalServerError, "failed to list redrive jobs")
+		return
+	}
+
+	// Unscoped admins see the jobs of every tenant
+	if apiMiddleware.TenantScoped(r.Context()) {
+		tenant := apiMiddleware.TenantFromContext(r.Context())
+		jobs = slices.DeleteFunc(jobs, func(job *queue.RedriveJob) bool {
+			return job.Tenant != tenant
+		})
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// GetRedrive handles GET /admin/dlq/redrive/{jobID}
+func (h *AdminHandler) GetRedrive(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobID")
+	if jobID == "" {
+		h.respondError(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	job, err := h.tenantRedrive(r, jobID)
+	if err != nil {
+		h.respondRedriveError(w, jobID, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, job)
+}
+
+// GetRedriveOutcomes handles GET /admin/dlq/redrive/{jobID}/outcomes?offset=&limit=
+func (h *AdminHandler) GetRedriveOutcomes(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobID")
+	if jobID == "" {
+		h.respondError(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	offset, limit := int64(0), int64(defaultDLQPageSize)
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			h.respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			h.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxDLQPageSize)
+	}
+
+	if _, err := h.tenantRedrive(r, jobID); err != nil {
+		h.respondRedriveError(w, jobID, err)
+		return
+	}
+
+	outcomes, err := h.redrive.Outcomes(r.Context(), jobID, offset, limit)
+	if err != nil {
+		h.respondRedriveError(w, jobID, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"outcomes": outcomes,
+		"offset":   offset,
+		"count":    len(outcomes),
+	})
+}
+
+// PauseRedrive handles POST /admin/dlq/redrive/{jobID}/pause
+func (h *AdminHandler) PauseRedrive(w http.ResponseWriter, r *http.Request) {
+	h.controlRedrive(w, r, "pause", h.redrive.Pause)
+}
+
+// ResumeRedrive handles POST /admin/dlq/redrive/{jobID}/resume
+func (h *AdminHandler) ResumeRedrive(w http.ResponseWriter, r *http.Request) {
+	h.controlRedrive(w, r, "resume", h.redrive.Resume)
+}
+
+// CancelRedrive handles POST /admin/dlq/redrive/{jobID}/cancel
+func (h *AdminHandler) CancelRedrive(w http.ResponseWriter, r *http.Request) {
+	h.controlRedrive(w, r, "cancel", h.redrive.Cancel)
+}
+
+func (h *AdminHandler) controlRedrive(w http.ResponseWriter, r *http.Request, action string, fn func(ctx context.Context, jobID string) error) {
+	jobID := chi.URLParam(r, "jobID")
+	if jobID == "" {
+		h.respondError(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	if _, err := h.tenantRedrive(r, jobID); err != nil {
+		h.respondRedriveError(w, jobID, err)
+		return
+	}
+	if err := fn(r.Context(), jobID); err != nil {
+		h.respondRedriveError(w, jobID, err)
+		return
+	}
+
+	logger.Info().Str("job_id", jobID).Str("action", action).Msg("redrive job control requested")
+	h.respondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"message": action + " requested",
+		"job_id":  jobID,
+	})
+}
+
+// tenantRedrive returns a job if it is visible to the request's tenant; jobs of
+// other tenants are reported as not found
+func (h *AdminHandler) tenantRedrive(r *http.Request, jobID string) (*queue.RedriveJob, error) {
+	job, err := h.redrive.Get(r.Context(), jobID)
+	if err != nil {
+		return nil, err
+	}
+	if apiMiddleware.TenantScoped(r.Context()) && job.Tenant != apiMiddleware.TenantFromContext(r.Context()) {
+		return nil, queue.ErrRedriveNotFound
+	}
+	return job, nil
+}
+
+func (h *AdminHandler) respondRedriveError(w http.ResponseWriter, jobID string, err error) {
+	switch {
+	case errors.Is(err, queue.ErrRedriveNotFound):
+		h.respondError(w, http.StatusNotFound, "redrive job not found")
+	case errors.Is(err, queue.ErrRedriveInvalidState):
+		h.respondError(w, http.StatusConflict, err.Error())
+	default:
+		logger.Error().Err(err).Str("job_id", jobID).Msg("redrive job operation failed")
+		h.respondError(w, http.StatusInternalServerError, "redrive job operation failed")
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func TestRedriveRequest_toQueueRequest(t *testing.T) {
+	req := RedriveRequest{Priority: "critical", RatePerSecond: 5}
+	req.Filter.Type = "email"
+	req.Filter.Error = "smtp"
+
+	out, err := req.toQueueRequest()
+	require.NoError(t, err)
+	assert.Equal(t, "email", out.Filter.TaskType)
+	assert.Equal(t, "smtp", out.Filter.ErrorContains)
+	require.NotNil(t, out.Priority)
+	assert.Equal(t, task.PriorityCritical, *out.Priority)
+	assert.Equal(t, 5.0, out.RatePerSecond)
+
+	_, err = (&RedriveRequest{Priority: "urgent"}).toQueueRequest()
+	assert.Error(t, err)
+
+	_, err = (&RedriveRequest{RatePerSecond: -1}).toQueueRequest()
+	assert.Error(t, err)
+}
+
+func TestAdminHandler_CreateRedrive_InvalidBody(t *testing.T) {
+	h := &AdminHandler{}
+
+	for _, body := range []string{"not json", `{"priority": "urgent"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/dlq/redrive", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		h.CreateRedrive(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestAdminHandler_Redrive_MissingID(t *testing.T) {
+	h := &AdminHandler{}
+
+	for _, handle := range []http.HandlerFunc{h.GetRedrive, h.GetRedriveOutcomes, h.PauseRedrive, h.ResumeRedrive, h.CancelRedrive} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/dlq/redrive/", nil)
+		w := httptest.NewRecorder()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("jobID", "")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		handle(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/queue"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// RunNowRequest represents the optional body of a single-task run-now
+type RunNowRequest struct {
+	Priority string `json:"priority,omitempty"` // low, normal, high, critical
+}
+
+// BulkRunNowRequest represents a request to run every matching deferred task now
+type BulkRunNowRequest struct {
+	Filter struct {
+		Type  string `json:"type,omitempty"`
+		State string `json:"state,omitempty"` // scheduled or retrying; empty matches both
+	} `json:"filter"`
+	Priority string `json:"priority,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// toQueueFilter validates the request and converts it for the queue
+func (req *BulkRunNowRequest) toQueueFilter() (queue.RunNowFilter, *task.Priority, error) {
+	filter := queue.RunNowFilter{
+		TaskType: req.Filter.Type,
+		Limit:    req.Limit,
+	}
+
+	if req.Filter.State != "" {
+		state := task.ParseState(req.Filter.State)
+		if state != task.StateScheduled && state != task.StateRetrying {
+			return filter, nil, fmt.Errorf("state must be scheduled or retrying")
+		}
+		filter.State = &state
+	}
+	if req.Limit < 0 {
+		return filter, nil, fmt.Errorf("limit must not be negative")
+	}
+	// Guard against accidentally running every deferred task
+	if req.Filter.Type == "" && req.Filter.State == "" {
+		return filter, nil, fmt.Errorf("filter must set type or state")
+	}
+
+	priority, err := parsePriorityOverride(req.Priority)
+	return filter, priority, err
+}
+
+// parsePriorityOverride parses an optional priority name
+func parsePriorityOverride(s string) (*task.Priority, error) {
+	if s == "" {
+		return nil, nil
+	}
+	p := task.ParsePriority(s)
+	if p.String() != s {
+		return nil, fmt.Errorf("invalid priority: %s", s)
+	}
+	return &p, nil
+}
+
+// RunTaskNow handles POST /admin/tasks/{taskID}/run-now
+func (h *AdminHandler) RunTaskNow(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if taskID == "" {
+		h.respondError(w, http.StatusBadRequest, "task ID is required")
+		return
+	}
+
+	// The body is optional
+	var req RunNowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	priority, err := parsePriorityOverride(req.Priority)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	t, err := h.queueFor(r).RunNow(r.Context(), taskID, priority)
+	switch {
+	case err == nil:
+	case errors.Is(err, task.ErrTaskNotFound):
+		h.respondError(w, http.StatusNotFound, "task not found")
+		return
+	case errors.Is(err, queue.ErrTaskNotDeferred):
+		h.respondError(w, http.StatusConflict, "only scheduled or retrying tasks can be run now")
+		return
+	case errors.Is(err, queue.ErrTaskEditConflict):
+		h.respondError(w, http.StatusConflict, err.Error())
+		return
+	default:
+		logger.Error().Err(err).Str("task_id", taskID).Msg("failed to run task now")
+		h.respondError(w, http.StatusInternalServerError, "failed to run task now")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.filter.response(r, t))
+}
+
+// RunTasksNow handles POST /admin/tasks/run-now
+func (h *AdminHandler) RunTasksNow(w http.ResponseWriter, r *http.Request) {
+	var req BulkRunNowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	filter, priority, err := req.toQueueFilter()
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.queueFor(r).RunNowMatching(r.Context(), filter, priority)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to run tasks now")
+		h.respondError(w, http.StatusInternalServerError, "failed to run tasks now")
+		return
+	}
+
+	logger.Info().
+		Str("type", filter.TaskType).
+		Int("matched", result.Matched).
+		Int("activated", result.Activated).
+		Msg("deferred tasks run now")
+	h.respondJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func TestBulkRunNowRequest_toQueueFilter(t *testing.T) {
+	req := BulkRunNowRequest{Priority: "high", Limit: 50}
+	req.Filter.Type = "email"
+	req.Filter.State = "retrying"
+
+	filter, priority, err := req.toQueueFilter()
+	require.NoError(t, err)
+	assert.Equal(t, "email", filter.TaskType)
+	require.NotNil(t, filter.State)
+	assert.Equal(t, task.StateRetrying, *filter.State)
+	assert.Equal(t, 50, filter.Limit)
+	require.NotNil(t, priority)
+	assert.Equal(t, task.PriorityHigh, *priority)
+
+	req = BulkRunNowRequest{}
+	req.Filter.Type = "email"
+	filter, priority, err = req.toQueueFilter()
+	require.NoError(t, err)
+	assert.Nil(t, filter.State)
+	assert.Nil(t, priority)
+
+	bad := []BulkRunNowRequest{{}, {Limit: -1}, {Priority: "urgent"}}
+	bad[1].Filter.Type = "email"
+	bad[2].Filter.Type = "email"
+	for _, state := range []string{"pending", "running", "bogus"} {
+		r := BulkRunNowRequest{}
+		r.Filter.State = state
+		bad = append(bad, r)
+	}
+	for _, r := range bad {
+		_, _, err := r.toQueueFilter()
+		assert.Error(t, err, "%+v", r)
+	}
+}
+
+func TestAdminHandler_RunTaskNow_InvalidRequest(t *testing.T) {
+	h := &AdminHandler{}
+
+	for _, tc := range []struct{ id, body string }{
+		{"", ""},
+		{"abc", "not json"},
+		{"abc", `{"priority": "urgent"}`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/tasks/x/run-now", strings.NewReader(tc.body))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("taskID", tc.id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+
+		h.RunTaskNow(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, tc.body)
+	}
+}
+
+func TestAdminHandler_RunTasksNow_InvalidBody(t *testing.T) {
+	h := &AdminHandler{}
+
+	for _, body := range []string{"not json", `{}`, `{"filter": {"state": "pending"}}`} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/tasks/run-now", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		h.RunTasksNow(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
@@ -3,11 +3,15 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
 	"github.com/maumercado/task-queue-go/internal/events"
 	"github.com/maumercado/task-queue-go/internal/logger"
 	"github.com/maumercado/task-queue-go/internal/metrics"
@@ -15,7 +19,8 @@ import (
 	"github.com/maumercado/task-queue-go/internal/task"
 )
 
-// ScheduleTaskFunc is a function type for scheduling tasks
+// ScheduleTaskFunc is a function type for scheduling tasks; it is
+// responsible for encrypting the task if configured
 type ScheduleTaskFunc func(ctx context.Context, t *task.Task, scheduledAt time.Time) error
 
 // TaskHandler handles task-related HTTP requests
@@ -26,6 +31,7 @@ type TaskHandler struct {
 	maxQueueSize      int64
 	defaultMaxRetries int
 	publisher         *events.RedisPubSub
+	filter            taskFilter
 }
 
 // NewTaskHandler creates a new task handler
@@ -53,10 +59,21 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, http.StatusBadRequest, "task type is required")
 		return
 	}
+	if msg := validateExpiration(&req, time.Now().UTC()); msg != "" {
+		h.respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if claims := apiMiddleware.GetUser(r.Context()); claims != nil && !claims.MaySubmit(req.Type) {
+		h.respondError(w, http.StatusForbidden, "API key may not submit tasks of type "+req.Type)
+		return
+	}
+
+	tenant := apiMiddleware.TenantFromContext(r.Context())
+	q := h.queue.ForTenant(tenant)
 
 	// Check queue capacity (backpressure)
 	if h.maxQueueSize > 0 {
-		depths, err := h.queue.GetQueueDepth(r.Context())
+		depths, err := q.GetQueueDepth(r.Context())
 		if err == nil {
 			var total int64
 			for _, depth := range depths {
@@ -69,8 +86,18 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Check the tenant's quota of waiting tasks
+	if maxQueued := q.TenantConfig(tenant).MaxQueued; maxQueued > 0 {
+		backlog, err := q.Backlog(r.Context())
+		if err == nil && backlog >= maxQueued {
+			h.respondError(w, http.StatusTooManyRequests, "tenant queue quota exceeded")
+			return
+		}
+	}
+
 	// Create task
 	t := task.FromRequest(&req)
+	t.Tenant = tenant
 
 	// Apply config default for max_retries when client omits it (request value <= 0).
 	// A client sending explicit 0 also gets the default (treat 0 as "use default").
@@ -101,12 +128,12 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		metrics.RecordScheduledTask()
 		h.publishTaskEvent(r.Context(), events.EventTaskSubmitted, t, nil)
 
-		h.respondJSON(w, http.StatusCreated, t.ToResponse())
+		h.respondJSON(w, http.StatusCreated, h.filter.response(r, t))
 		return
 	}
 
 	// Enqueue task immediately
-	if err := h.queue.Enqueue(r.Context(), t); err != nil {
+	if err := q.Enqueue(r.Context(), t); err != nil {
 		logger.Error().Err(err).Str("task_id", t.ID).Msg("failed to enqueue task")
 		h.respondError(w, http.StatusInternalServerError, "failed to enqueue task")
 		return
@@ -121,7 +148,26 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 	metrics.RecordTaskSubmission(t.Type, t.Priority.String())
 	h.publishTaskEvent(r.Context(), events.EventTaskSubmitted, t, nil)
 
-	h.respondJSON(w, http.StatusCreated, t.ToResponse())
+	h.respondJSON(w, http.StatusCreated, h.filter.response(r, t))
+}
+
+// validateExpiration checks expires_at/ttl and returns an error message, or "" if valid
+func validateExpiration(req *task.CreateTaskRequest, now time.Time) string {
+	if req.ExpiresAt != nil && req.TTL != 0 {
+		return "only one of expires_at and ttl may be set"
+	}
+	if req.TTL < 0 {
+		return "ttl must be a positive number of seconds"
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return "expires_at must be in the future"
+		}
+		if req.ScheduledAt != nil && !req.ExpiresAt.After(*req.ScheduledAt) {
+			return "expires_at must be after scheduled_at"
+		}
+	}
+	return ""
 }
 
 // Get handles GET /api/v1/tasks/{taskID}
@@ -132,7 +178,7 @@ func (h *TaskHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t, err := h.queue.GetTask(r.Context(), taskID)
+	t, err := h.queueFor(r).GetTask(r.Context(), taskID)
 	if err != nil {
 		if err == task.ErrTaskNotFound {
 			h.respondError(w, http.StatusNotFound, "task not found")
@@ -143,7 +189,133 @@ func (h *TaskHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, t.ToResponse())
+	w.Header().Set("ETag", taskETag(t.Version))
+	h.respondJSON(w, http.StatusOK, h.filter.response(r, t))
+}
+
+// UpdateTaskRequest represents the API request for editing a queued task.
+// Payload and metadata are JSON merge patches (RFC 7396).
+type UpdateTaskRequest struct {
+	Priority    *int                   `json:"priority,omitempty"`
+	ScheduledAt *time.Time             `json:"scheduled_at,omitempty"`
+	Payload     map[string]interface{} `json:"payload,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"` // Values must be strings or null
+	Version     *int64                 `json:"version,omitempty"`  // Alternative to If-Match
+}
+
+// Update handles PATCH /api/v1/tasks/{taskID}
+func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if taskID == "" {
+		h.respondError(w, http.StatusBadRequest, "task ID is required")
+		return
+	}
+
+	var req UpdateTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	edit, err := buildTaskEdit(&req, ifMatch)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Content edits are read-modify-write on the client side, so they must say
+	// which version they were based on (or explicitly match any with "*")
+	if (edit.Payload != nil || edit.Metadata != nil) && edit.IfVersion == nil && ifMatch == "" {
+		h.respondError(w, http.StatusPreconditionRequired, "payload and metadata edits require If-Match or version")
+		return
+	}
+
+	t, err := h.queueFor(r).EditTask(r.Context(), taskID, edit)
+	switch {
+	case err == nil:
+	case errors.Is(err, task.ErrTaskNotFound):
+		h.respondError(w, http.StatusNotFound, "task not found")
+		return
+	case errors.Is(err, queue.ErrTaskVersionMismatch):
+		w.Header().Set("ETag", taskETag(t.Version))
+		h.respondError(w, http.StatusPreconditionFailed, "task has been modified since the given version")
+		return
+	case errors.Is(err, queue.ErrTaskNotEditable):
+		h.respondError(w, http.StatusConflict, "task cannot be edited in state "+t.State.String())
+		return
+	case errors.Is(err, queue.ErrTaskEditConflict):
+		h.respondError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, queue.ErrInvalidTaskEdit):
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	default:
+		logger.Error().Err(err).Str("task_id", taskID).Msg("failed to update task")
+		h.respondError(w, http.StatusInternalServerError, "failed to update task")
+		return
+	}
+
+	logger.Info().Str("task_id", taskID).Int64("version", t.Version).Msg("task updated")
+	h.publishTaskEvent(r.Context(), events.EventTaskUpdated, t, map[string]interface{}{
+		"version": t.Version,
+	})
+
+	w.Header().Set("ETag", taskETag(t.Version))
+	h.respondJSON(w, http.StatusOK, h.filter.response(r, t))
+}
+
+// buildTaskEdit validates an update request and converts it to a queue edit.
+// If-Match takes precedence over the version field.
+func buildTaskEdit(req *UpdateTaskRequest, ifMatch string) (queue.TaskEdit, error) {
+	edit := queue.TaskEdit{
+		ScheduledAt: req.ScheduledAt,
+		Payload:     req.Payload,
+		Metadata:    req.Metadata,
+		IfVersion:   req.Version,
+	}
+
+	if req.Priority != nil {
+		if *req.Priority < int(task.PriorityLow) || *req.Priority > int(task.PriorityCritical) {
+			return edit, errors.New("priority must be between 0 and 3")
+		}
+		p := task.Priority(*req.Priority)
+		edit.Priority = &p
+	}
+
+	if ifMatch != "" {
+		version, ok := parseTaskETag(ifMatch)
+		if !ok {
+			return edit, errors.New("invalid If-Match header")
+		}
+		edit.IfVersion = version
+	}
+
+	if err := edit.Validate(); err != nil {
+		return edit, err
+	}
+	return edit, nil
+}
+
+// taskETag formats a task version as a strong entity tag
+func taskETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseTaskETag parses an If-Match value. "*" matches any version and yields nil.
+func parseTaskETag(s string) (*int64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return nil, true
+	}
+	s = strings.TrimPrefix(s, "W/")
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return nil, false
+	}
+	version, err := strconv.ParseInt(s[1:len(s)-1], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &version, true
 }
 
 // Cancel handles DELETE /api/v1/tasks/{taskID}
@@ -154,7 +326,8 @@ func (h *TaskHandler) Cancel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t, err := h.queue.GetTask(r.Context(), taskID)
+	q := h.queueFor(r)
+	t, err := q.GetTask(r.Context(), taskID)
 	if err != nil {
 		if err == task.ErrTaskNotFound {
 			h.respondError(w, http.StatusNotFound, "task not found")
@@ -185,20 +358,20 @@ func (h *TaskHandler) Cancel(w http.ResponseWriter, r *http.Request) {
 	// Remove from scheduled sorted set so scheduler does not reactivate.
 	// Applies to both scheduled (future first run) and retrying (backoff delay).
 	if t.State == task.StateScheduled || t.State == task.StateRetrying {
-		if err := h.queue.RemoveScheduledTask(r.Context(), taskID); err != nil {
+		if err := q.RemoveScheduledTask(r.Context(), taskID); err != nil {
 			logger.Warn().Err(err).Str("task_id", taskID).Msg("failed to remove task from scheduled set")
 			// Non-fatal: scheduler will skip canceled tasks safely.
 		}
 	}
 
-	if err := h.queue.UpdateTask(r.Context(), t); err != nil {
+	if err := q.UpdateTask(r.Context(), t); err != nil {
 		logger.Error().Err(err).Str("task_id", taskID).Msg("failed to update task")
 		h.respondError(w, http.StatusInternalServerError, "failed to cancel task")
 		return
 	}
 
 	logger.Info().Str("task_id", taskID).Msg("task canceled")
-	h.respondJSON(w, http.StatusOK, t.ToResponse())
+	h.respondJSON(w, http.StatusOK, h.filter.response(r, t))
 }
 
 // ListResponse represents the response for listing tasks
@@ -209,7 +382,8 @@ type ListResponse struct {
 
 // List handles GET /api/v1/tasks — returns rich queue inspection data.
 func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.queue.GetQueueStats(r.Context())
+	tenant := apiMiddleware.TenantFromContext(r.Context())
+	stats, err := h.queue.ForTenant(tenant).GetQueueStats(r.Context())
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to get queue stats")
 		h.respondError(w, http.StatusInternalServerError, "failed to list tasks")
@@ -218,11 +392,17 @@ func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	// Attach DLQ size
 	if h.dlq != nil {
-		if dlqSize, err := h.dlq.Size(r.Context()); err == nil {
+		if dlqSize, err := h.dlq.ForTenant(tenant).Size(r.Context()); err == nil {
 			stats.DLQSize = dlqSize
 		}
 	}
 
+	// The gauges describe the default tenant; tenant stats stay out of them
+	if tenant != "" {
+		h.respondJSON(w, http.StatusOK, stats)
+		return
+	}
+
 	// Update Prometheus gauges
 	for priority, ps := range stats.Queues {
 		metrics.UpdateQueueBacklog(priority, float64(ps.Queued))
@@ -234,6 +414,11 @@ func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, stats)
 }
 
+// queueFor returns the queue view of the request's tenant
+func (h *TaskHandler) queueFor(r *http.Request) *queue.RedisQueue {
+	return h.queue.ForTenant(apiMiddleware.TenantFromContext(r.Context()))
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -267,6 +452,9 @@ func (h *TaskHandler) publishTaskEvent(ctx context.Context, eventType events.Eve
 		"state":    t.State.String(),
 		"attempts": t.Attempts,
 	}
+	if t.Tenant != "" {
+		data["tenant"] = t.Tenant
+	}
 	if t.ScheduledAt != nil {
 		data["scheduled_at"] = t.ScheduledAt
 	}
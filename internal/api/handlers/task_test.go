@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -93,6 +94,49 @@ func TestTaskHandler_Create_MissingType(t *testing.T) {
 	assert.Equal(t, "task type is required", response.Message)
 }
 
+func TestValidateExpiration(t *testing.T) {
+	now := time.Now().UTC()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	later := now.Add(2 * time.Hour)
+
+	tests := []struct {
+		name string
+		req  task.CreateTaskRequest
+		want string
+	}{
+		{"none", task.CreateTaskRequest{}, ""},
+		{"ttl", task.CreateTaskRequest{TTL: 30}, ""},
+		{"expires_at", task.CreateTaskRequest{ExpiresAt: &future}, ""},
+		{"both", task.CreateTaskRequest{TTL: 30, ExpiresAt: &future}, "only one of expires_at and ttl may be set"},
+		{"negative ttl", task.CreateTaskRequest{TTL: -1}, "ttl must be a positive number of seconds"},
+		{"past", task.CreateTaskRequest{ExpiresAt: &past}, "expires_at must be in the future"},
+		{"before schedule", task.CreateTaskRequest{ExpiresAt: &future, ScheduledAt: &later}, "expires_at must be after scheduled_at"},
+		{"after schedule", task.CreateTaskRequest{ExpiresAt: &later, ScheduledAt: &future}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, validateExpiration(&tt.req, now))
+		})
+	}
+}
+
+func TestTaskHandler_Create_ExpiredDeadline(t *testing.T) {
+	h := &TaskHandler{}
+
+	past := time.Now().Add(-time.Minute)
+	body, _ := json.Marshal(task.CreateTaskRequest{Type: "push", ExpiresAt: &past})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.Create(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestTaskHandler_Get_MissingID(t *testing.T) {
 	h := &TaskHandler{}
 
@@ -236,3 +280,63 @@ func TestListResponse_Struct(t *testing.T) {
 	assert.Len(t, decoded.Tasks, 1)
 	assert.Equal(t, "task-1", decoded.Tasks[0].ID)
 }
+
+func TestParseTaskETag(t *testing.T) {
+	v, ok := parseTaskETag(`"7"`)
+	require.True(t, ok)
+	assert.Equal(t, int64(7), *v)
+
+	v, ok = parseTaskETag(` W/"3" `)
+	require.True(t, ok)
+	assert.Equal(t, int64(3), *v)
+
+	v, ok = parseTaskETag("*")
+	assert.True(t, ok)
+	assert.Nil(t, v)
+
+	for _, bad := range []string{"7", `"x"`, `"`, ""} {
+		_, ok := parseTaskETag(bad)
+		assert.False(t, ok, bad)
+	}
+
+	assert.Equal(t, `"12"`, taskETag(12))
+}
+
+func TestBuildTaskEdit(t *testing.T) {
+	high := int(task.PriorityHigh)
+	bad := 7
+	version := int64(2)
+
+	edit, err := buildTaskEdit(&UpdateTaskRequest{Priority: &high, Version: &version}, "")
+	require.NoError(t, err)
+	assert.Equal(t, task.PriorityHigh, *edit.Priority)
+	assert.Equal(t, int64(2), *edit.IfVersion)
+
+	edit, err = buildTaskEdit(&UpdateTaskRequest{Priority: &high, Version: &version}, `"5"`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *edit.IfVersion, "If-Match takes precedence")
+
+	_, err = buildTaskEdit(&UpdateTaskRequest{Priority: &bad}, "")
+	assert.Error(t, err)
+
+	_, err = buildTaskEdit(&UpdateTaskRequest{Priority: &high}, "nope")
+	assert.Error(t, err)
+
+	_, err = buildTaskEdit(&UpdateTaskRequest{}, "")
+	assert.Error(t, err, "empty edits are rejected")
+}
+
+func TestTaskHandler_Update_PayloadRequiresPrecondition(t *testing.T) {
+	h := &TaskHandler{}
+
+	body := bytes.NewBufferString(`{"payload": {"to": "x@example.com"}}`)
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/tasks/abc", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("taskID", "abc")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	h.Update(w, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+}
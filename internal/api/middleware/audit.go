@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/maumercado/task-queue-go/internal/audit"
+)
+
+// AuditRecorder stores audit entries
+type AuditRecorder interface {
+	Record(ctx context.Context, e *audit.Entry)
+}
+
+// Audit returns a middleware that records every state-changing request (any
+// method but GET, HEAD and OPTIONS). actions names routes by method and chi
+// pattern, e.g. "DELETE /admin/queues/{priority}"; unnamed routes are recorded
+// under the method and pattern.
+//
+// It must run before Auth, so rejected credentials and throttled requests are
+// recorded too; Auth and Tenant report the actor and tenant back to it. Without
+// claims the actor is "anonymous".
+func Audit(rec AuditRecorder, actions map[string]string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isReadMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id := &auditIdentity{}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), auditIdentityKey, id)))
+
+			route := r.Method + " " + r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = r.Method + " " + rctx.RoutePattern()
+			}
+			action, ok := actions[route]
+			if !ok {
+				action = route
+			}
+
+			actor := "anonymous"
+			claims := id.claims
+			if claims == nil {
+				claims = GetUser(r.Context())
+			}
+			if claims != nil {
+				actor = claims.UserID
+			}
+			tenant := TenantFromContext(r.Context())
+			if id.tenant != nil {
+				tenant = *id.tenant
+			}
+
+			peer := PeerAddr(r.Context())
+			if peer == "" {
+				peer = sourceIP(r.RemoteAddr)
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // Nothing written
+			}
+			rec.Record(context.WithoutCancel(r.Context()), &audit.Entry{
+				Time:         time.Now().UTC(),
+				Actor:        actor,
+				Tenant:       tenant,
+				Action:       action,
+				Target:       r.URL.Path,
+				Method:       r.Method,
+				RequestID:    middleware.GetReqID(r.Context()),
+				SourceIP:     peer,
+				ForwardedFor: strings.Join(r.Header.Values("X-Forwarded-For"), ", "),
+				Status:       status,
+				Outcome:      audit.OutcomeFor(status),
+			})
+		})
+	}
+}
+
+// auditIdentityKey carries the identity resolved for an audited request
+const auditIdentityKey contextKey = "audit_identity"
+
+// auditIdentity is filled in by Auth and Tenant, which run after Audit
+type auditIdentity struct {
+	claims *Claims
+	tenant *string
+}
+
+// noteAuditUser reports the authenticated caller to Audit, if it runs
+func noteAuditUser(ctx context.Context, claims *Claims) {
+	if id, ok := ctx.Value(auditIdentityKey).(*auditIdentity); ok {
+		id.claims = claims
+	}
+}
+
+// noteAuditTenant reports the request tenant to Audit, if it runs
+func noteAuditTenant(ctx context.Context, tenant string) {
+	if id, ok := ctx.Value(auditIdentityKey).(*auditIdentity); ok {
+		id.tenant = &tenant
+	}
+}
+
+// sourceIP strips the port from a remote address (RealIP may have already)
+func sourceIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/audit"
+)
+
+type recordedEntries []*audit.Entry
+
+func (r *recordedEntries) Record(_ context.Context, e *audit.Entry) {
+	*r = append(*r, e)
+}
+
+func TestAudit(t *testing.T) {
+	var rec recordedEntries
+	actions := map[string]string{
+		"POST /api/v1/tasks":            "task.create",
+		"DELETE /api/v1/tasks/{taskID}": "task.cancel",
+	}
+
+	router := chi.NewRouter()
+	router.Route("/api/v1", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := context.WithValue(r.Context(), UserContextKey, &Claims{UserID: "alice"})
+				next.ServeHTTP(w, r.WithContext(WithTenant(ctx, "billing")))
+			})
+		})
+		r.Use(Audit(&rec, actions))
+		r.Route("/tasks", func(r chi.Router) {
+			r.Get("/{taskID}", func(w http.ResponseWriter, r *http.Request) {})
+			r.Post("/", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) })
+			r.Delete("/{taskID}", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) })
+			r.Patch("/{taskID}", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusForbidden) })
+		})
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t1", nil), // Not audited
+		httptest.NewRequest(http.MethodPost, "/api/v1/tasks", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/v1/tasks/t1", nil),
+		httptest.NewRequest(http.MethodPatch, "/api/v1/tasks/t1", nil),
+	} {
+		req.RemoteAddr = "10.0.0.1:5555"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, rec, 3)
+
+	assert.Equal(t, "task.create", rec[0].Action)
+	assert.Equal(t, "alice", rec[0].Actor)
+	assert.Equal(t, "billing", rec[0].Tenant)
+	assert.Equal(t, "10.0.0.1", rec[0].SourceIP)
+	assert.Equal(t, http.StatusCreated, rec[0].Status)
+	assert.Equal(t, audit.OutcomeSuccess, rec[0].Outcome)
+
+	assert.Equal(t, "task.cancel", rec[1].Action)
+	assert.Equal(t, "/api/v1/tasks/t1", rec[1].Target)
+	assert.Equal(t, audit.OutcomeFailure, rec[1].Outcome)
+
+	// Unnamed routes fall back to method and pattern
+	assert.Equal(t, "PATCH /api/v1/tasks/{taskID}", rec[2].Action)
+	assert.Equal(t, audit.OutcomeDenied, rec[2].Outcome)
+}
+
+func TestAudit_Anonymous(t *testing.T) {
+	var rec recordedEntries
+	handler := Audit(&rec, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/admin/dlq", nil))
+
+	require.Len(t, rec, 1)
+	assert.Equal(t, "anonymous", rec[0].Actor)
+	assert.Equal(t, http.StatusOK, rec[0].Status)
+}
+
+func TestAudit_BeforeAuth(t *testing.T) {
+	var rec recordedEntries
+	auth := &AuthConfig{Enabled: true, APIKeys: map[string]APIKey{"k1": {Name: "ci", Roles: []string{RoleAdmin}, Tenant: "billing"}}}
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(RealIP(trusted))
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(Audit(&rec, nil))
+		r.Use(Auth(auth))
+		r.Use(Tenant(func(string) bool { return true }))
+		r.Delete("/dlq", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	// Rejected credentials are recorded
+	req := httptest.NewRequest(http.MethodDelete, "/admin/dlq", nil)
+	req.RemoteAddr = "203.0.113.9:5555"
+	req.Header.Set("X-API-Key", "wrong")
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// The identity resolved by Auth and Tenant is recorded
+	req = httptest.NewRequest(http.MethodDelete, "/admin/dlq", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("X-API-Key", "k1")
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, rec, 2)
+	assert.Equal(t, "anonymous", rec[0].Actor)
+	assert.Equal(t, http.StatusUnauthorized, rec[0].Status)
+	assert.Equal(t, audit.OutcomeDenied, rec[0].Outcome)
+	assert.Equal(t, "203.0.113.9", rec[0].SourceIP, "forwarded headers cannot forge the source")
+	assert.Equal(t, "1.2.3.4", rec[0].ForwardedFor)
+
+	assert.Equal(t, "apikey:ci", rec[1].Actor)
+	assert.Equal(t, "billing", rec[1].Tenant)
+	assert.Equal(t, "10.0.0.1", rec[1].SourceIP)
+	assert.Equal(t, "198.51.100.7", rec[1].ForwardedFor)
+}
@@ -2,10 +2,21 @@ package middleware
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/maumercado/task-queue-go/internal/apikey"
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/queue"
 )
 
 type contextKey string
@@ -18,18 +29,145 @@ const (
 type AuthConfig struct {
 	Enabled   bool
 	JWTSecret string
-	APIKeys   map[string]bool
+	JWT       *JWTVerifier      // nil = HS256 with JWTSecret
+	APIKeys   map[string]APIKey // Keyed by the secret key value
+	Keys      *apikey.Store     // Keys managed at /admin/api-keys; nil = config keys only
+
+	// ClientCerts maps verified TLS client certificates to identities, keyed by
+	// subject common name or RFC 2253 distinguished name
+	ClientCerts map[string]ClientCert
+
+	// AllowQueryToken also accepts credentials in the api_key and access_token
+	// query parameters, for clients such as browsers that cannot set headers
+	// on a WebSocket upgrade
+	AllowQueryToken bool
+}
+
+// APIKey is the identity an API key authenticates as
+type APIKey struct {
+	Name         string
+	Roles        []string
+	Tenant       string // Tenant the key is bound to ("" = none)
+	RateLimitRPS int    // Replaces the default per-client limit; 0 = default
+}
+
+// ClientCert is the identity a TLS client certificate authenticates as
+type ClientCert struct {
+	Name         string
+	Roles        []string
+	Tenant       string
+	RateLimitRPS int
+}
+
+// NewAuthConfig builds the middleware configuration from the application config,
+// rejecting invalid JWT settings, and API keys and client certificates that are
+// empty, duplicated or carry unknown roles or malformed tenants
+func NewAuthConfig(cfg *config.AuthConfig) (*AuthConfig, error) {
+	verifier, err := NewJWTVerifier(cfg.JWTSecret, &cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: %w", err)
+	}
+
+	out := &AuthConfig{
+		JWT:       verifier,
+		Enabled:   cfg.Enabled,
+		JWTSecret: cfg.JWTSecret,
+		APIKeys:   make(map[string]APIKey, len(cfg.APIKeys)),
+	}
+
+	for i, k := range cfg.APIKeys {
+		name := k.Name
+		if name == "" {
+			name = fmt.Sprintf("key-%d", i)
+		}
+		if k.Key == "" {
+			return nil, fmt.Errorf("api key %q has no key", name)
+		}
+		if _, exists := out.APIKeys[k.Key]; exists {
+			return nil, fmt.Errorf("api key %q is configured more than once", name)
+		}
+		if err := validateIdentity("api key "+strconv.Quote(name), k.Roles, k.Tenant, k.RateLimitRPS); err != nil {
+			return nil, err
+		}
+		out.APIKeys[k.Key] = APIKey{Name: name, Roles: k.Roles, Tenant: k.Tenant, RateLimitRPS: k.RateLimitRPS}
+	}
+
+	if len(cfg.ClientCerts) > 0 {
+		out.ClientCerts = make(map[string]ClientCert, len(cfg.ClientCerts))
+	}
+	for _, c := range cfg.ClientCerts {
+		if c.Subject == "" {
+			return nil, fmt.Errorf("client certificate %q has no subject", c.Name)
+		}
+		if _, exists := out.ClientCerts[c.Subject]; exists {
+			return nil, fmt.Errorf("client certificate %q is configured more than once", c.Subject)
+		}
+		name := c.Name
+		if name == "" {
+			name = c.Subject
+		}
+		if err := validateIdentity("client certificate "+strconv.Quote(c.Subject), c.Roles, c.Tenant, c.RateLimitRPS); err != nil {
+			return nil, err
+		}
+		out.ClientCerts[c.Subject] = ClientCert{Name: name, Roles: c.Roles, Tenant: c.Tenant, RateLimitRPS: c.RateLimitRPS}
+	}
+
+	return out, nil
+}
+
+// validateIdentity checks the roles, tenant and rate limit of a configured
+// credential; what names it in errors
+func validateIdentity(what string, roles []string, tenant string, rateLimitRPS int) error {
+	for _, role := range roles {
+		if !IsKnownRole(role) {
+			return fmt.Errorf("%s has unknown role %q", what, role)
+		}
+	}
+	if tenant != "" && !queue.ValidTenantName(tenant) {
+		return fmt.Errorf("%s has invalid tenant %q", what, tenant)
+	}
+	if rateLimitRPS < 0 {
+		return fmt.Errorf("%s has a negative rate limit", what)
+	}
+	return nil
 }
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID string   `json:"user_id"`
+	Role   string   `json:"role"`
+	Roles  []string `json:"roles,omitempty"`
+	Tenant string   `json:"tenant,omitempty"` // Binds the token to a tenant
+
+	// Scopes and limits of API keys; never read from tokens
+	KeyID        string   `json:"-"`
+	TaskTypes    []string `json:"-"` // Task types that may be submitted; empty = any
+	ReadOnly     bool     `json:"-"`
+	RateLimitRPS int      `json:"-"` // Replaces the default per-client limit
+
 	jwt.RegisteredClaims
 }
 
+// MaySubmit reports whether the identity may submit tasks of the given type
+func (c *Claims) MaySubmit(taskType string) bool {
+	return len(c.TaskTypes) == 0 || slices.Contains(c.TaskTypes, taskType)
+}
+
+// AllRoles returns the single role and the role list combined
+func (c *Claims) AllRoles() []string {
+	if c.Role == "" {
+		return c.Roles
+	}
+	return append([]string{c.Role}, c.Roles...)
+}
+
 // Auth returns an authentication middleware
 func Auth(cfg *AuthConfig) func(next http.Handler) http.Handler {
+	verifier := cfg.JWT
+	if verifier == nil {
+		verifier, _ = NewJWTVerifier(cfg.JWTSecret, &config.JWTConfig{})
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if !cfg.Enabled {
@@ -39,45 +177,147 @@ func Auth(cfg *AuthConfig) func(next http.Handler) http.Handler {
 
 			// Check for API key first
 			apiKey := r.Header.Get("X-API-Key")
+			if apiKey == "" && cfg.AllowQueryToken {
+				apiKey = r.URL.Query().Get("api_key")
+			}
 			if apiKey != "" {
-				if cfg.APIKeys[apiKey] {
-					next.ServeHTTP(w, r)
+				claims, status, msg := cfg.authenticateKey(r, apiKey)
+				if status != 0 {
+					respondAuthError(w, status, msg)
 					return
 				}
-				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				ctx := withUser(r.Context(), claims)
+				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
 			// Check for JWT token
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			tokenString, ok := bearerToken(r, cfg.AllowQueryToken)
+			if !ok {
+				// Fall back to a verified TLS client certificate
+				if cert := clientCert(r); cert != nil {
+					claims, found := cfg.authenticateCert(cert)
+					if !found {
+						logger.Debug().Str("subject", cert.Subject.String()).Msg("client certificate not mapped")
+						respondAuthError(w, http.StatusUnauthorized, "client certificate not authorized")
+						return
+					}
+					ctx := withUser(r.Context(), claims)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+				respondAuthError(w, http.StatusUnauthorized, "authorization header required")
 				return
 			}
-
-			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-			if tokenString == authHeader {
-				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			if tokenString == "" {
+				respondAuthError(w, http.StatusUnauthorized, "invalid authorization header format")
 				return
 			}
 
-			claims := &Claims{}
-			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-				return []byte(cfg.JWTSecret), nil
-			})
-
-			if err != nil || !token.Valid {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			claims, err := verifier.Verify(r.Context(), tokenString)
+			if err != nil {
+				logger.Debug().Err(err).Str("path", r.URL.Path).Msg("jwt rejected")
+				respondAuthError(w, http.StatusUnauthorized, "invalid token")
 				return
 			}
 
 			// Add claims to context
-			ctx := context.WithValue(r.Context(), UserContextKey, claims)
+			ctx := withUser(r.Context(), claims)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// withUser returns a context carrying the authenticated caller
+func withUser(ctx context.Context, claims *Claims) context.Context {
+	noteAuditUser(ctx, claims)
+	return context.WithValue(ctx, UserContextKey, claims)
+}
+
+// authenticateKey resolves an API key from the config or the key store and
+// applies its read-only scope. A non-zero status means the request is
+// rejected with msg.
+func (cfg *AuthConfig) authenticateKey(r *http.Request, secret string) (*Claims, int, string) {
+	if key, ok := cfg.APIKeys[secret]; ok {
+		return &Claims{UserID: "apikey:" + key.Name, Roles: key.Roles, Tenant: key.Tenant, RateLimitRPS: key.RateLimitRPS}, 0, ""
+	}
+	if cfg.Keys == nil {
+		return nil, http.StatusUnauthorized, "invalid API key"
+	}
+
+	key, err := cfg.Keys.Authenticate(r.Context(), secret)
+	switch {
+	case err == nil:
+	case errors.Is(err, apikey.ErrInvalidKey), errors.Is(err, apikey.ErrKeyRevoked), errors.Is(err, apikey.ErrKeyExpired):
+		return nil, http.StatusUnauthorized, err.Error()
+	default:
+		logger.Error().Err(err).Msg("failed to verify API key")
+		return nil, http.StatusServiceUnavailable, "failed to verify API key"
+	}
+
+	if key.ReadOnly && !isReadMethod(r.Method) {
+		return nil, http.StatusForbidden, "API key is read-only"
+	}
+
+	return &Claims{
+		UserID:       "apikey:" + key.ID,
+		Roles:        key.Roles,
+		Tenant:       key.Tenant,
+		KeyID:        key.ID,
+		TaskTypes:    key.TaskTypes,
+		ReadOnly:     key.ReadOnly,
+		RateLimitRPS: key.RateLimitRPS,
+	}, 0, ""
+}
+
+// clientCert returns the leaf of the request's verified client certificate
+// chain, or nil if the client did not present a certificate the listener
+// verified
+func clientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// authenticateCert maps a client certificate to its configured identity by
+// distinguished name, then common name
+func (cfg *AuthConfig) authenticateCert(cert *x509.Certificate) (*Claims, bool) {
+	id, ok := cfg.ClientCerts[cert.Subject.String()]
+	if !ok && cert.Subject.CommonName != "" {
+		id, ok = cfg.ClientCerts[cert.Subject.CommonName]
+	}
+	if !ok {
+		return nil, false
+	}
+	return &Claims{UserID: "cert:" + id.Name, Roles: id.Roles, Tenant: id.Tenant, RateLimitRPS: id.RateLimitRPS}, true
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// bearerToken extracts the JWT from the Authorization header (or the access_token
+// query parameter if allowed). ok is false if no credentials were sent at all;
+// an empty token with ok set means the header is malformed.
+func bearerToken(r *http.Request, allowQuery bool) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		if allowQuery {
+			if token := r.URL.Query().Get("access_token"); token != "" {
+				return token, true
+			}
+		}
+		return "", false
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		return "", true
+	}
+	return tokenString, true
+}
+
 // GetUser retrieves user claims from context
 func GetUser(ctx context.Context) *Claims {
 	claims, ok := ctx.Value(UserContextKey).(*Claims)
@@ -87,22 +327,44 @@ func GetUser(ctx context.Context) *Claims {
 	return claims
 }
 
-// RequireRole returns a middleware that requires a specific role
-func RequireRole(role string) func(next http.Handler) http.Handler {
+// RequireRole returns a middleware that requires at least one of the given roles.
+// Roles imply others (see HasRole), so admin passes every check.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims := GetUser(r.Context())
 			if claims == nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				respondAuthError(w, http.StatusUnauthorized, "authentication required")
 				return
 			}
 
-			if claims.Role != role && claims.Role != "admin" {
-				http.Error(w, "Forbidden", http.StatusForbidden)
-				return
+			for _, role := range roles {
+				if HasRole(claims, role) {
+					next.ServeHTTP(w, r)
+					return
+				}
 			}
 
-			next.ServeHTTP(w, r)
+			logger.Warn().
+				Str("user", claims.UserID).
+				Strs("roles", claims.AllRoles()).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Msg("forbidden")
+			respondAuthError(w, http.StatusForbidden, "requires role "+strings.Join(roles, " or "))
 		})
 	}
 }
+
+// respondAuthError writes the same JSON error body the API handlers use
+func respondAuthError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="taskqueue"`)
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":   http.StatusText(status),
+		"message": message,
+	})
+}
@@ -2,6 +2,9 @@ package middleware
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +12,9 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
 )
 
 func TestAuth_Disabled(t *testing.T) {
@@ -31,8 +37,8 @@ func TestAuth_Disabled(t *testing.T) {
 func TestAuth_ValidAPIKey(t *testing.T) {
 	cfg := &AuthConfig{
 		Enabled: true,
-		APIKeys: map[string]bool{
-			"valid-api-key": true,
+		APIKeys: map[string]APIKey{
+			"valid-api-key": {Name: "test", Roles: []string{RoleViewer}},
 		},
 	}
 
@@ -52,8 +58,8 @@ func TestAuth_ValidAPIKey(t *testing.T) {
 func TestAuth_InvalidAPIKey(t *testing.T) {
 	cfg := &AuthConfig{
 		Enabled: true,
-		APIKeys: map[string]bool{
-			"valid-api-key": true,
+		APIKeys: map[string]APIKey{
+			"valid-api-key": {Name: "test", Roles: []string{RoleViewer}},
 		},
 	}
 
@@ -276,3 +282,209 @@ func TestRequireRole_NoUser(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuth_APIKeyCarriesRoles(t *testing.T) {
+	cfg := &AuthConfig{
+		Enabled: true,
+		APIKeys: map[string]APIKey{
+			"ci-key": {Name: "ci", Roles: []string{RoleSubmitter}},
+		},
+	}
+
+	handler := Auth(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := GetUser(r.Context())
+		assert.NotNil(t, user)
+		assert.Equal(t, "apikey:ci", user.UserID)
+		assert.Equal(t, []string{RoleSubmitter}, user.AllRoles())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "ci-key")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuth_QueryToken(t *testing.T) {
+	cfg := &AuthConfig{
+		Enabled: true,
+		APIKeys: map[string]APIKey{"ws-key": {Name: "ws"}},
+	}
+
+	handler := Auth(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws?api_key=ws-key", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "query credentials are ignored by default")
+
+	cfg.AllowQueryToken = true
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuth_ErrorBody(t *testing.T) {
+	handler := Auth(&AuthConfig{Enabled: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"Unauthorized","message":"authorization header required"}`, w.Body.String())
+}
+
+func TestNewAuthConfig(t *testing.T) {
+	cfg, err := NewAuthConfig(&config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "ci", Key: "k1", Roles: []string{RoleSubmitter}},
+			{Key: "k2", Roles: []string{RoleViewer, RoleOperator}},
+			{Name: "acme", Key: "k3", Roles: []string{RoleSubmitter}, Tenant: "acme", RateLimitRPS: 50},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, APIKey{Name: "ci", Roles: []string{RoleSubmitter}}, cfg.APIKeys["k1"])
+	assert.Equal(t, "key-1", cfg.APIKeys["k2"].Name)
+	assert.Equal(t, "acme", cfg.APIKeys["k3"].Tenant)
+	assert.Equal(t, 50, cfg.APIKeys["k3"].RateLimitRPS)
+
+	invalid := [][]config.APIKeyConfig{
+		{{Name: "empty"}},
+		{{Key: "dup"}, {Key: "dup"}},
+		{{Key: "k", Roles: []string{"superuser"}}},
+		{{Key: "k", Tenant: "Not A Tenant"}},
+		{{Key: "k", RateLimitRPS: -1}},
+	}
+	for _, keys := range invalid {
+		_, err := NewAuthConfig(&config.AuthConfig{APIKeys: keys})
+		assert.Error(t, err)
+	}
+}
+
+func TestNewAuthConfig_ClientCerts(t *testing.T) {
+	cfg, err := NewAuthConfig(&config.AuthConfig{
+		ClientCerts: []config.ClientCertConfig{
+			{Subject: "billing", Roles: []string{RoleSubmitter}, Tenant: "billing"},
+			{Name: "ops", Subject: "CN=ops,O=Acme", Roles: []string{RoleAdmin}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ClientCert{Name: "billing", Roles: []string{RoleSubmitter}, Tenant: "billing"}, cfg.ClientCerts["billing"])
+	assert.Equal(t, "ops", cfg.ClientCerts["CN=ops,O=Acme"].Name)
+
+	invalid := [][]config.ClientCertConfig{
+		{{Name: "no-subject"}},
+		{{Subject: "dup"}, {Subject: "dup"}},
+		{{Subject: "s", Roles: []string{"superuser"}}},
+		{{Subject: "s", Tenant: "Not A Tenant"}},
+		{{Subject: "s", RateLimitRPS: -1}},
+	}
+	for _, certs := range invalid {
+		_, err := NewAuthConfig(&config.AuthConfig{ClientCerts: certs})
+		assert.Error(t, err)
+	}
+}
+
+func TestAuth_ClientCert(t *testing.T) {
+	cfg := &AuthConfig{
+		Enabled: true,
+		APIKeys: map[string]APIKey{"ci-key": {Name: "ci"}},
+		ClientCerts: map[string]ClientCert{
+			"billing":       {Name: "billing", Roles: []string{RoleSubmitter}, Tenant: "billing"},
+			"CN=ops,O=Acme": {Name: "ops", Roles: []string{RoleAdmin}},
+		},
+	}
+
+	var claims *Claims
+	handler := Auth(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims = GetUser(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	withCert := func(subject pkix.Name) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: subject}}},
+		}
+		return req
+	}
+
+	// Mapped by common name
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, withCert(pkix.Name{CommonName: "billing", Organization: []string{"Acme"}}))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "cert:billing", claims.UserID)
+	assert.Equal(t, "billing", claims.Tenant)
+
+	// Mapped by distinguished name; a different organization does not match
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, withCert(pkix.Name{CommonName: "ops", Organization: []string{"Acme"}}))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "cert:ops", claims.UserID)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, withCert(pkix.Name{CommonName: "ops", Organization: []string{"Evil"}}))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Explicit credentials take precedence over the certificate
+	req := withCert(pkix.Name{CommonName: "billing"})
+	req.Header.Set("X-API-Key", "ci-key")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "apikey:ci", claims.UserID)
+
+	// Unverified certificates are ignored
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "billing"}}}}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestClaims_MaySubmit(t *testing.T) {
+	assert.True(t, (&Claims{}).MaySubmit("email"))
+
+	claims := &Claims{TaskTypes: []string{"email", "sms"}}
+	assert.True(t, claims.MaySubmit("email"))
+	assert.False(t, claims.MaySubmit("report"))
+}
+
+func TestAuth_StoredKeyScopesNotReadFromToken(t *testing.T) {
+	secret := "test-secret"
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":    "user-1",
+		"KeyID":      "forged",
+		"TaskTypes":  []string{"email"},
+		"ReadOnly":   true,
+		"task_types": []string{"email"},
+	})
+	tokenString, err := token.SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	var claims *Claims
+	handler := Auth(&AuthConfig{Enabled: true, JWTSecret: secret})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims = GetUser(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, claims)
+	assert.Empty(t, claims.KeyID)
+	assert.Empty(t, claims.TaskTypes)
+	assert.False(t, claims.ReadOnly)
+}
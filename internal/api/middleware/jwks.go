@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/maumercado/task-queue-go/internal/logger"
+)
+
+// jwksMinRefresh limits how often an unknown key ID can trigger a refresh, so
+// tokens with made-up key IDs cannot flood the identity provider
+const jwksMinRefresh = 30 * time.Second
+
+// jwksMaxSize caps the size of a JWKS document
+const jwksMaxSize = 1 << 20
+
+// ErrUnknownKey is returned for tokens signed with a key that is not in the JWKS
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// JWKS caches the public keys of a JSON Web Key Set read from a URL or file.
+// Keys are reloaded after the refresh interval, and earlier when a token names
+// an unknown key ID, so keys rotated by the identity provider are picked up.
+// One reload runs at a time, outside the lock; cached keys are served meanwhile.
+type JWKS struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]jwk
+	loadedAt  time.Time
+	reloading chan struct{} // Closed when the running reload finishes; nil if none
+}
+
+// jwk is a parsed public key
+type jwk struct {
+	alg string // Empty if the key does not restrict its algorithm
+	key interface{}
+}
+
+// jwkJSON is a key as it appears in a JWKS document (RFC 7517)
+type jwkJSON struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewJWKS creates a key set backed by url or file (exactly one must be set)
+// and loads it once. A file that cannot be loaded is an error; a URL is
+// retried on first use, so the API can start while the provider is down.
+func NewJWKS(url, file string, refresh time.Duration) (*JWKS, error) {
+	if (url == "") == (file == "") {
+		return nil, fmt.Errorf("exactly one of jwks url and jwks file must be set")
+	}
+	if refresh <= 0 {
+		refresh = 5 * time.Minute
+	}
+
+	j := &JWKS{
+		url:      url,
+		file:     file,
+		refresh:  refresh,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		loadedAt: time.Now(),
+	}
+	keys, err := j.fetch(context.Background())
+	if err != nil {
+		if file != "" {
+			return nil, err
+		}
+		logger.Warn().Err(err).Str("url", url).Msg("failed to load JWKS, will retry")
+	}
+	j.keys = keys
+	return j, nil
+}
+
+// Key returns the public key with the given ID for a token signed with alg.
+// An empty kid matches the only key of a single-key set. A due reload runs in
+// the background while the key is cached; otherwise Key waits for it until ctx
+// is done.
+func (j *JWKS) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	j.mu.Lock()
+	k, ok := j.lookup(kid)
+	since := time.Since(j.loadedAt)
+	due := since >= j.refresh || (!ok && since >= jwksMinRefresh)
+	done, owner := j.reloading, false
+	if due && done == nil {
+		done, owner = make(chan struct{}), true
+		j.reloading = done
+		// Failed loads also count, so an unreachable provider is not hammered
+		j.loadedAt = time.Now()
+	}
+	j.mu.Unlock()
+
+	switch {
+	case owner && ok:
+		go j.reload(context.WithoutCancel(ctx), done)
+	case owner:
+		j.reload(ctx, done)
+	case !ok && done != nil:
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if !ok {
+		j.mu.Lock()
+		k, ok = j.lookup(kid)
+		j.mu.Unlock()
+	}
+
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if k.alg != "" && k.alg != alg {
+		return nil, fmt.Errorf("key %q is for %s, not %s", kid, k.alg, alg)
+	}
+	return k.key, nil
+}
+
+func (j *JWKS) lookup(kid string) (jwk, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, k := range j.keys {
+			return k, true
+		}
+	}
+	k, ok := j.keys[kid]
+	return k, ok
+}
+
+// reload fetches the key set, swaps it in and closes done
+func (j *JWKS) reload(ctx context.Context, done chan struct{}) {
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	if err == nil {
+		j.keys = keys
+	}
+	j.reloading = nil
+	j.mu.Unlock()
+	close(done)
+
+	if err != nil {
+		// Keep serving cached keys while the source is unavailable
+		logger.Warn().Err(err).Msg("failed to refresh JWKS")
+	}
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]jwk, error) {
+	data, err := j.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+func (j *JWKS) read(ctx context.Context) ([]byte, error) {
+	if j.file != "" {
+		data, err := os.ReadFile(j.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	return data, nil
+}
+
+// parseJWKS parses the signing keys of a JWKS document. Keys of unsupported
+// types or for encryption are skipped.
+func parseJWKS(data []byte) (map[string]jwk, error) {
+	var doc struct {
+		Keys []jwkJSON `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]jwk, len(doc.Keys))
+	for _, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", raw.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		keys[raw.Kid] = jwk{alg: raw.Alg, key: key}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes an RSA or EC key; other key types return nil
+func (k *jwkJSON) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("missing")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+)
+
+// JWTVerifier verifies JWTs against an algorithm allowlist, an HMAC secret
+// and/or a JWKS, and maps their claims to Claims
+type JWTVerifier struct {
+	secret      []byte
+	jwks        *JWKS
+	parser      *jwt.Parser
+	rolesClaim  string
+	tenantClaim string
+}
+
+// NewJWTVerifier builds a verifier from the application config. Asymmetric
+// algorithms require a JWKS URL or file.
+func NewJWTVerifier(secret string, cfg *config.JWTConfig) (*JWTVerifier, error) {
+	algorithms := cfg.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{"HS256"}
+	}
+
+	asymmetric := false
+	for _, alg := range algorithms {
+		switch jwt.GetSigningMethod(alg).(type) {
+		case *jwt.SigningMethodHMAC:
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+			asymmetric = true
+		default:
+			return nil, fmt.Errorf("unsupported jwt algorithm %q", alg)
+		}
+	}
+	if cfg.ClockSkew < 0 {
+		return nil, fmt.Errorf("jwt clock skew must not be negative")
+	}
+
+	v := &JWTVerifier{
+		secret:      []byte(secret),
+		rolesClaim:  cfg.RolesClaim,
+		tenantClaim: cfg.TenantClaim,
+	}
+	if v.tenantClaim == "" {
+		v.tenantClaim = "tenant"
+	}
+
+	if cfg.JWKSURL != "" || cfg.JWKSFile != "" {
+		jwks, err := NewJWKS(cfg.JWKSURL, cfg.JWKSFile, cfg.JWKSRefresh)
+		if err != nil {
+			return nil, err
+		}
+		v.jwks = jwks
+	} else if asymmetric {
+		return nil, fmt.Errorf("jwt algorithms %v require a jwks url or file", algorithms)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithLeeway(cfg.ClockSkew),
+		jwt.WithIssuedAt(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Verify parses and validates a token and returns its claims. ctx bounds any
+// JWKS fetch needed to find the key.
+func (v *JWTVerifier) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+	raw := jwt.MapClaims{}
+	keyFunc := func(token *jwt.Token) (interface{}, error) { return v.keyFunc(ctx, token) }
+	if _, err := v.parser.ParseWithClaims(tokenString, raw, keyFunc); err != nil {
+		return nil, err
+	}
+	return v.mapClaims(raw)
+}
+
+// keyFunc picks the verification key by algorithm family, so a token can
+// never be verified with a key meant for another kind of algorithm
+func (v *JWTVerifier) keyFunc(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(v.secret) == 0 {
+			return nil, errors.New("no jwt secret configured")
+		}
+		return v.secret, nil
+	default:
+		if v.jwks == nil {
+			return nil, errors.New("no jwks configured")
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.jwks.Key(ctx, kid, token.Method.Alg())
+	}
+}
+
+// mapClaims converts verified claims, applying the configured role and tenant
+// claims. Tokens without user_id use their subject.
+func (v *JWTVerifier) mapClaims(raw jwt.MapClaims) (*Claims, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	if err := json.Unmarshal(data, claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+
+	if claims.UserID == "" {
+		claims.UserID = claims.Subject
+	}
+
+	if v.rolesClaim != "" {
+		roles, err := stringsClaim(raw, v.rolesClaim)
+		if err != nil {
+			return nil, err
+		}
+		claims.Role = ""
+		claims.Roles = roles
+	}
+
+	tenants, err := stringsClaim(raw, v.tenantClaim)
+	if err != nil {
+		return nil, err
+	}
+	switch len(tenants) {
+	case 0:
+		claims.Tenant = ""
+	case 1:
+		claims.Tenant = tenants[0]
+	default:
+		return nil, fmt.Errorf("claim %s holds more than one tenant", v.tenantClaim)
+	}
+
+	return claims, nil
+}
+
+// stringsClaim reads a string or string list claim at a dotted path, such as
+// realm_access.roles. A missing claim is empty.
+func stringsClaim(raw map[string]interface{}, path string) ([]string, error) {
+	var value interface{} = raw
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		if value, ok = obj[part]; !ok {
+			return nil, nil
+		}
+	}
+
+	switch val := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if val == "" {
+			return nil, nil
+		}
+		return []string{val}, nil
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %s must hold strings", path)
+			}
+			if !slices.Contains(out, s) {
+				out = append(out, s)
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("claim %s must be a string or a list of strings", path)
+}
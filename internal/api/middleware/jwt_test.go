@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+)
+
+func b64(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": b64(key.N), "e": b64(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64(key.X), "y": b64(key.Y),
+	}
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func TestJWTVerifier_JWKSFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+
+	v, err := NewJWTVerifier("", &config.JWTConfig{
+		Algorithms: []string{"RS256", "ES256"},
+		JWKSFile:   path,
+		Issuer:     "https://idp.example.com",
+		Audience:   "taskqueue",
+	})
+	require.NoError(t, err)
+
+	valid := jwt.MapClaims{
+		"sub": "user-1",
+		"iss": "https://idp.example.com",
+		"aud": "taskqueue",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	claims, err := v.Verify(t.Context(), signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, valid))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodES256, "ec-1", ecKey, valid))
+	assert.NoError(t, err)
+
+	// Wrong key for the key ID
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodES256, "rsa-1", ecKey, valid))
+	assert.Error(t, err)
+
+	// Unknown key ID
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, valid))
+	assert.Error(t, err)
+
+	// Algorithm not allowed, even with a matching secret
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), valid))
+	assert.Error(t, err)
+
+	for claim, value := range map[string]interface{}{"iss": "https://evil.example.com", "aud": "other"} {
+		bad := jwt.MapClaims{}
+		for k, v := range valid {
+			bad[k] = v
+		}
+		bad[claim] = value
+		_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, bad))
+		assert.Error(t, err, claim)
+	}
+}
+
+func TestJWTVerifier_KeyRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("old", oldKey))
+
+	v, err := NewJWTVerifier("", &config.JWTConfig{Algorithms: []string{"RS256"}, JWKSFile: path})
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{"sub": "user-1"}
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodRS256, "old", oldKey, claims))
+	require.NoError(t, err)
+
+	// The provider rotates; an unknown key ID reloads the set once the minimum
+	// refresh interval has passed
+	writeJWKS(t, path, rsaJWK("new", newKey))
+	v.jwks.loadedAt = time.Now().Add(-jwksMinRefresh)
+
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodRS256, "new", newKey, claims))
+	require.NoError(t, err)
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodRS256, "old", oldKey, claims))
+	assert.Error(t, err)
+}
+
+func TestJWTVerifier_AlgorithmPinning(t *testing.T) {
+	v, err := NewJWTVerifier("secret", &config.JWTConfig{})
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{"user_id": "user-1"}
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), claims))
+	assert.NoError(t, err)
+
+	// Only HS256 is accepted by default
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodHS512, "", []byte("secret"), claims))
+	assert.Error(t, err)
+
+	// Unsigned tokens are never accepted
+	none := signToken(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, claims)
+	_, err = v.Verify(t.Context(), none)
+	assert.Error(t, err)
+}
+
+func TestJWTVerifier_ClockSkew(t *testing.T) {
+	v, err := NewJWTVerifier("secret", &config.JWTConfig{ClockSkew: time.Minute})
+	require.NoError(t, err)
+
+	recent := jwt.MapClaims{"user_id": "user-1", "exp": time.Now().Add(-30 * time.Second).Unix()}
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), recent))
+	assert.NoError(t, err)
+
+	old := jwt.MapClaims{"user_id": "user-1", "exp": time.Now().Add(-2 * time.Minute).Unix()}
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), old))
+	assert.Error(t, err)
+}
+
+func TestJWTVerifier_ClaimMapping(t *testing.T) {
+	v, err := NewJWTVerifier("secret", &config.JWTConfig{
+		RolesClaim:  "realm_access.roles",
+		TenantClaim: "org",
+	})
+	require.NoError(t, err)
+
+	claims, err := v.Verify(t.Context(), signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+		"sub":          "user-1",
+		"role":         "admin", // Ignored once a roles claim is configured
+		"tenant":       "ignored",
+		"realm_access": map[string]interface{}{"roles": []string{"viewer", "operator"}},
+		"org":          "billing",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Empty(t, claims.Role)
+	assert.Equal(t, []string{"viewer", "operator"}, claims.Roles)
+	assert.Equal(t, "billing", claims.Tenant)
+
+	_, err = v.Verify(t.Context(), signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+		"realm_access": map[string]interface{}{"roles": 42},
+	}))
+	assert.Error(t, err)
+}
+
+func TestNewJWTVerifier_Invalid(t *testing.T) {
+	bad := []config.JWTConfig{
+		{Algorithms: []string{"none"}},
+		{Algorithms: []string{"XS256"}},
+		{Algorithms: []string{"RS256"}}, // No JWKS
+		{JWKSFile: filepath.Join(t.TempDir(), "missing.json")},
+		{ClockSkew: -time.Second},
+	}
+	for _, cfg := range bad {
+		_, err := NewJWTVerifier("secret", &cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	enc := ecJWK("enc", ecKey)
+	enc["use"] = "enc"
+	data, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		ecJWK("sig", ecKey), enc, {"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+	}})
+	keys, err := parseJWKS(data)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Contains(t, keys, "sig")
+
+	offCurve := ecJWK("bad", ecKey)
+	offCurve["y"] = b64(big.NewInt(1))
+	data, _ = json.Marshal(map[string]interface{}{"keys": []map[string]string{offCurve}})
+	_, err = parseJWKS(data)
+	assert.Error(t, err)
+
+	_, err = parseJWKS([]byte(`{"keys":[]}`))
+	assert.Error(t, err)
+}
+
+func TestJWKS_ReloadOutsideLock(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	doc, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{rsaJWK("k1", key)}})
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			select { // A slow provider
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		_, _ = w.Write(doc)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	j, err := NewJWKS(srv.URL, "", time.Minute)
+	require.NoError(t, err)
+
+	// A stale set is reloaded in the background; the cached key is served meanwhile
+	j.mu.Lock()
+	j.loadedAt = time.Now().Add(-time.Hour)
+	j.mu.Unlock()
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		_, err := j.Key(t.Context(), "k1", "RS256")
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	}
+
+	// Unknown key IDs wait for the running reload, bounded by their context
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	_, err = j.Key(ctx, "k2", "RS256")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.Equal(t, int32(2), fetches.Load(), "one reload for all callers")
+}
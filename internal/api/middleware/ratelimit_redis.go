@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/logger"
+)
+
+const (
+	rateLimitKeyPrefix = "ratelimit:"
+
+	// rateLimitFallbackFor is how long limiting stays local after Redis fails,
+	// so an outage does not add a Redis timeout to every request
+	rateLimitFallbackFor = 5 * time.Second
+
+	// rateLimitSweepEvery is how often idle local buckets are dropped
+	rateLimitSweepEvery = time.Minute
+)
+
+// gcraScript applies the generic cell rate algorithm to one bucket, using the
+// Redis clock so all replicas agree. The bucket holds its theoretical arrival
+// time (TAT, µs) and expires once it would be full again.
+//
+// KEYS: bucket. ARGV: emission interval (µs), burst tolerance (µs).
+// Returns {allowed (0/1), remaining, retry after (µs), reset (µs)}.
+var gcraScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], string.format("%d", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now + tolerance - new_tat) / interval), 0, new_tat - now}
+`)
+
+// RateLimitResult is the outcome of one rate limit check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // Until the next request is allowed, if denied
+	Reset      time.Duration // Until the bucket is full again
+}
+
+// RedisRateLimiter enforces per-second limits shared by all API replicas. Each
+// bucket allows a burst of one second's worth of requests. While Redis is
+// unavailable it limits locally, per replica.
+type RedisRateLimiter struct {
+	client *redis.Client // nil = local only
+
+	mu        sync.Mutex
+	local     map[string]time.Time // Fallback buckets: key -> TAT
+	downUntil time.Time
+	lastSweep time.Time
+}
+
+// NewRedisRateLimiter creates a limiter; a nil client limits locally only
+func NewRedisRateLimiter(client *redis.Client) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client:    client,
+		local:     make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes one request from the bucket key, limited to rps (> 0)
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, rps int) RateLimitResult {
+	interval := max(time.Second/time.Duration(rps), time.Microsecond)
+	tolerance := interval * time.Duration(rps)
+
+	if l.client != nil && !l.redisDown() {
+		res, err := gcraScript.Run(ctx, l.client, []string{rateLimitKeyPrefix + key},
+			interval.Microseconds(), tolerance.Microseconds()).Int64Slice()
+		if err == nil && len(res) == 4 {
+			return RateLimitResult{
+				Allowed:    res[0] == 1,
+				Limit:      rps,
+				Remaining:  int(res[1]),
+				RetryAfter: time.Duration(res[2]) * time.Microsecond,
+				Reset:      time.Duration(res[3]) * time.Microsecond,
+			}
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			l.markDown(err)
+		}
+	}
+
+	return l.allowLocal(key, rps, interval, tolerance)
+}
+
+func (l *RedisRateLimiter) redisDown() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().Before(l.downUntil)
+}
+
+func (l *RedisRateLimiter) markDown(err error) {
+	l.mu.Lock()
+	l.downUntil = time.Now().Add(rateLimitFallbackFor)
+	l.mu.Unlock()
+	logger.Warn().Err(err).Dur("for", rateLimitFallbackFor).Msg("rate limiting locally, Redis unavailable")
+}
+
+func (l *RedisRateLimiter) allowLocal(key string, rps int, interval, tolerance time.Duration) RateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= rateLimitSweepEvery {
+		for k, tat := range l.local {
+			if tat.Before(now) {
+				delete(l.local, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	tat, res := gcra(now, l.local[key], interval, tolerance)
+	res.Limit = rps
+	if res.Allowed {
+		l.local[key] = tat
+	}
+	return res
+}
+
+// gcra is the generic cell rate algorithm gcraScript runs in Redis. It returns
+// the bucket's new theoretical arrival time, valid if the request is allowed.
+func gcra(now, tat time.Time, interval, tolerance time.Duration) (time.Time, RateLimitResult) {
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	if allowAt := newTAT.Add(-tolerance); now.Before(allowAt) {
+		return tat, RateLimitResult{RetryAfter: allowAt.Sub(now), Reset: tat.Sub(now)}
+	}
+	return newTAT, RateLimitResult{
+		Allowed:   true,
+		Remaining: int((tolerance - newTAT.Sub(now)) / interval),
+		Reset:     newTAT.Sub(now),
+	}
+}
+
+// RouteLimit limits requests to a route, per client. Method "" matches any
+// method; Pattern uses chi syntax ({param} matches one segment, a trailing /*
+// any suffix).
+type RouteLimit struct {
+	Method  string
+	Pattern string
+	RPS     int
+}
+
+// Matches reports whether the limit applies to a request
+func (rl *RouteLimit) Matches(method, path string) bool {
+	if rl.Method != "" && !strings.EqualFold(rl.Method, method) {
+		return false
+	}
+
+	pattern := strings.Split(strings.Trim(rl.Pattern, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, p := range pattern {
+		if p == "*" && i == len(pattern)-1 {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if p != segments[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(segments)
+}
+
+// ClientLimit returns a middleware that limits each client across all
+// replicas. Clients are identified by their credentials (API key or JWT
+// user), or by IP address when unauthenticated (see RealIP). A client's limit
+// is its API key's rate_limit_rps, or rps (0 = none); routes add per-route
+// limits. It must run after Auth.
+func ClientLimit(limiter *RedisRateLimiter, rps int, routes []RouteLimit) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := "ip:" + sourceIP(r.RemoteAddr)
+			limit := rps
+			if claims := GetUser(r.Context()); claims != nil {
+				client = "user:" + claims.UserID
+				if claims.RateLimitRPS > 0 {
+					limit = claims.RateLimitRPS
+				}
+			}
+
+			var tightest *RateLimitResult
+			check := func(key string, rps int) bool {
+				res := limiter.Allow(r.Context(), key, rps)
+				if tightest == nil || !res.Allowed || res.Remaining < tightest.Remaining {
+					tightest = &res
+				}
+				return res.Allowed
+			}
+
+			allowed := limit <= 0 || check(client, limit)
+			for i := range routes {
+				if !allowed {
+					break
+				}
+				if route := &routes[i]; route.RPS > 0 && route.Matches(r.Method, r.URL.Path) {
+					allowed = check("route:"+route.Method+" "+route.Pattern+":"+client, route.RPS)
+				}
+			}
+
+			if tightest != nil {
+				setRateLimitHeaders(w, tightest)
+			}
+			if !allowed {
+				logger.Warn().
+					Str("method", r.Method).
+					Str("path", r.URL.Path).
+					Str("client", client).
+					Msg("client rate limit exceeded")
+				respondRateLimited(w, tightest, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setRateLimitHeaders sets the RateLimit-* headers of the IETF httpapi
+// ratelimit-headers draft
+func setRateLimitHeaders(w http.ResponseWriter, res *RateLimitResult) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(max(res.Remaining, 0)))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+}
+
+// respondRateLimited writes a 429 with Retry-After
+func respondRateLimited(w http.ResponseWriter, res *RateLimitResult, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(res.RetryAfter), 1)))
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = w.Write([]byte(`{"error":"Too Many Requests","message":"` + message + `"}`))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCRA(t *testing.T) {
+	now := time.Now()
+	interval := 100 * time.Millisecond // 10 rps
+	tolerance := time.Second           // burst of 10
+
+	var tat time.Time
+	for i := 0; i < 10; i++ {
+		var res RateLimitResult
+		tat, res = gcra(now, tat, interval, tolerance)
+		require.True(t, res.Allowed, "request %d", i)
+		assert.Equal(t, 9-i, res.Remaining)
+	}
+
+	_, res := gcra(now, tat, interval, tolerance)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, interval, res.RetryAfter)
+	assert.Equal(t, time.Second, res.Reset)
+
+	// One interval later one request is allowed again
+	_, res = gcra(now.Add(interval), tat, interval, tolerance)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestRouteLimit_Matches(t *testing.T) {
+	rl := RouteLimit{Method: "POST", Pattern: "/admin/tasks/{taskID}/retry"}
+	assert.True(t, rl.Matches(http.MethodPost, "/admin/tasks/t1/retry"))
+	assert.True(t, rl.Matches("post", "/admin/tasks/t1/retry/"))
+	assert.False(t, rl.Matches(http.MethodGet, "/admin/tasks/t1/retry"))
+	assert.False(t, rl.Matches(http.MethodPost, "/admin/tasks/run-now"))
+	assert.False(t, rl.Matches(http.MethodPost, "/admin/tasks/t1/retry/x"))
+
+	wildcard := RouteLimit{Pattern: "/admin/dlq/*"}
+	assert.True(t, wildcard.Matches(http.MethodDelete, "/admin/dlq/t1"))
+	assert.True(t, wildcard.Matches(http.MethodPost, "/admin/dlq/redrive/j1/pause"))
+	assert.False(t, wildcard.Matches(http.MethodGet, "/admin/queues"))
+}
+
+func TestClientLimit(t *testing.T) {
+	routes := []RouteLimit{{Method: http.MethodPost, Pattern: "/api/v1/tasks", RPS: 1}}
+	handler := ClientLimit(NewRedisRateLimiter(nil), 3, routes)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(method string, claims *Claims, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/tasks", nil)
+		req.RemoteAddr = remoteAddr
+		if claims != nil {
+			req = req.WithContext(context.WithValue(req.Context(), UserContextKey, claims))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("per client with headers", func(t *testing.T) {
+		rr := send(http.MethodGet, nil, "10.0.0.1:1000")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "3", rr.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1", rr.Header().Get("RateLimit-Reset"))
+
+		// Another port of the same address is the same client
+		send(http.MethodGet, nil, "10.0.0.1:2000")
+		send(http.MethodGet, nil, "10.0.0.1:3000")
+		rr = send(http.MethodGet, nil, "10.0.0.1:4000")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, nil, "10.0.0.2:1000").Code)
+	})
+
+	t.Run("per route", func(t *testing.T) {
+		claims := &Claims{UserID: "alice"}
+		assert.Equal(t, http.StatusOK, send(http.MethodPost, claims, "10.0.0.3:1000").Code)
+		assert.Equal(t, http.StatusTooManyRequests, send(http.MethodPost, claims, "10.0.0.3:1000").Code)
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, claims, "10.0.0.3:1000").Code)
+	})
+
+	t.Run("per key limit replaces the default", func(t *testing.T) {
+		claims := &Claims{UserID: "apikey:ci", RateLimitRPS: 10}
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, http.StatusOK, send(http.MethodGet, claims, "10.0.0.4:1000").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, send(http.MethodGet, claims, "10.0.0.4:1000").Code)
+	})
+}
+
+func TestRedisRateLimiter_FallsBackWhenRedisDown(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 50 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	defer client.Close()
+
+	limiter := NewRedisRateLimiter(client)
+	ctx := context.Background()
+
+	assert.True(t, limiter.Allow(ctx, "k", 2).Allowed)
+	assert.True(t, limiter.redisDown())
+	assert.True(t, limiter.Allow(ctx, "k", 2).Allowed)
+	assert.False(t, limiter.Allow(ctx, "k", 2).Allowed)
+}
@@ -101,89 +101,3 @@ func TestRateLimit_Middleware(t *testing.T) {
 		}
 	})
 }
-
-func TestNewClientRateLimiter(t *testing.T) {
-	limiter := NewClientRateLimiter(100)
-	assert.NotNil(t, limiter)
-	assert.NotNil(t, limiter.limiters)
-	assert.Equal(t, 100, limiter.rps)
-}
-
-func TestClientRateLimiter_GetLimiter(t *testing.T) {
-	t.Run("creates new limiter for unknown client", func(t *testing.T) {
-		crl := NewClientRateLimiter(10)
-
-		limiter := crl.GetLimiter("client-1")
-		assert.NotNil(t, limiter)
-		assert.Equal(t, float64(10), limiter.maxTokens)
-	})
-
-	t.Run("returns same limiter for same client", func(t *testing.T) {
-		crl := NewClientRateLimiter(10)
-
-		limiter1 := crl.GetLimiter("client-1")
-		limiter2 := crl.GetLimiter("client-1")
-
-		assert.Same(t, limiter1, limiter2)
-	})
-
-	t.Run("returns different limiters for different clients", func(t *testing.T) {
-		crl := NewClientRateLimiter(10)
-
-		limiter1 := crl.GetLimiter("client-1")
-		limiter2 := crl.GetLimiter("client-2")
-
-		assert.NotSame(t, limiter1, limiter2)
-	})
-}
-
-func TestClientRateLimit_Middleware(t *testing.T) {
-	t.Run("allows requests within client limit", func(t *testing.T) {
-		handler := ClientRateLimit(100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-
-		req := httptest.NewRequest("GET", "/test", nil)
-		req.RemoteAddr = "192.168.1.1:12345"
-		w := httptest.NewRecorder()
-
-		handler.ServeHTTP(w, req)
-		assert.Equal(t, http.StatusOK, w.Code)
-	})
-
-	t.Run("uses X-Forwarded-For when available", func(t *testing.T) {
-		handler := ClientRateLimit(2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-
-		// Two different clients with X-Forwarded-For
-		for _, client := range []string{"10.0.0.1", "10.0.0.2"} {
-			for i := 0; i < 2; i++ {
-				req := httptest.NewRequest("GET", "/test", nil)
-				req.Header.Set("X-Forwarded-For", client)
-				w := httptest.NewRecorder()
-				handler.ServeHTTP(w, req)
-				assert.Equal(t, http.StatusOK, w.Code)
-			}
-		}
-	})
-
-	t.Run("returns 429 when client limit exceeded", func(t *testing.T) {
-		handler := ClientRateLimit(2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-
-		for i := 0; i < 3; i++ {
-			req := httptest.NewRequest("GET", "/test", nil)
-			req.RemoteAddr = "192.168.1.1:12345"
-			w := httptest.NewRecorder()
-			handler.ServeHTTP(w, req)
-
-			if i < 2 {
-				assert.Equal(t, http.StatusOK, w.Code)
-			} else {
-				assert.Equal(t, http.StatusTooManyRequests, w.Code)
-			}
-		}
-	})
-}
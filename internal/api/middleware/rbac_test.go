@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		roles   []string
+		allowed []string
+		denied  []string
+	}{
+		{[]string{RoleSubmitter}, []string{RoleSubmitter}, []string{RoleViewer, RoleOperator, RoleAdmin}},
+		{[]string{RoleViewer}, []string{RoleViewer}, []string{RoleSubmitter, RoleOperator, RoleAdmin}},
+		{[]string{RoleOperator}, []string{RoleViewer, RoleSubmitter, RoleOperator}, []string{RoleAdmin}},
+		{[]string{RoleAdmin}, []string{RoleViewer, RoleSubmitter, RoleOperator, RoleAdmin}, nil},
+		{[]string{RoleSubmitter, RoleViewer}, []string{RoleSubmitter, RoleViewer}, []string{RoleOperator}},
+		{nil, nil, []string{RoleViewer}},
+	}
+
+	for _, tt := range tests {
+		claims := &Claims{Roles: tt.roles}
+		for _, role := range tt.allowed {
+			assert.True(t, HasRole(claims, role), "%v should have %s", tt.roles, role)
+		}
+		for _, role := range tt.denied {
+			assert.False(t, HasRole(claims, role), "%v should not have %s", tt.roles, role)
+		}
+	}
+}
+
+func TestHasRole_SingleRoleClaim(t *testing.T) {
+	assert.True(t, HasRole(&Claims{Role: RoleOperator}, RoleViewer))
+	assert.True(t, HasRole(&Claims{Role: RoleViewer, Roles: []string{RoleSubmitter}}, RoleSubmitter))
+}
+
+func TestIsKnownRole(t *testing.T) {
+	for _, role := range []string{RoleSubmitter, RoleViewer, RoleOperator, RoleAdmin} {
+		assert.True(t, IsKnownRole(role))
+	}
+	assert.False(t, IsKnownRole("root"))
+	assert.False(t, IsKnownRole(""))
+}
+
+func TestRequireRole_AnyOf(t *testing.T) {
+	handler := RequireRole(RoleSubmitter, RoleViewer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for role, want := range map[string]int{
+		RoleSubmitter: http.StatusOK,
+		RoleViewer:    http.StatusOK,
+		RoleOperator:  http.StatusOK,
+		"guest":       http.StatusForbidden,
+	} {
+		ctx := context.WithValue(context.Background(), UserContextKey, &Claims{Roles: []string{role}})
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, want, w.Code, role)
+	}
+}
+
+func TestRequireRole_ForbiddenBody(t *testing.T) {
+	ctx := context.WithValue(context.Background(), UserContextKey, &Claims{UserID: "u", Roles: []string{RoleViewer}})
+	handler := RequireRole(RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/dlq", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Forbidden", body["error"])
+	assert.Equal(t, "requires role admin", body["message"])
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// peerAddrKey holds the TCP peer's IP address
+const peerAddrKey contextKey = "peer_addr"
+
+// ParseTrustedProxies parses proxy addresses and CIDRs, e.g. "10.0.0.0/8"
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", p)
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// RealIP sets r.RemoteAddr to the client address from X-Forwarded-For or
+// X-Real-IP, but only for requests from a trusted proxy; anyone else could
+// pick an address, and with it a fresh rate limit or a forged audit entry.
+// The TCP peer address stays available from PeerAddr.
+func RealIP(trusted []*net.IPNet) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer := sourceIP(r.RemoteAddr)
+			r = r.WithContext(context.WithValue(r.Context(), peerAddrKey, peer))
+
+			if isTrusted(trusted, peer) {
+				if client := forwardedClient(r, trusted); client != "" {
+					r.RemoteAddr = client
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PeerAddr returns the IP address of the TCP peer, which may be a proxy
+func PeerAddr(ctx context.Context) string {
+	peer, _ := ctx.Value(peerAddrKey).(string)
+	return peer
+}
+
+// forwardedClient returns the nearest untrusted address of X-Forwarded-For,
+// or X-Real-IP without it. Addresses further left were added by the client
+// or by proxies we do not know, so they cannot be relied on.
+func forwardedClient(r *http.Request, trusted []*net.IPNet) string {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	if len(hops) == 0 {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+			return ip.String()
+		}
+		return ""
+	}
+
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !isTrusted(trusted, client) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrusted(trusted []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/queue"
+)
+
+// TenantHeader lets a principal that is not bound to a tenant act on one
+const TenantHeader = "X-Tenant"
+
+const (
+	TenantContextKey       contextKey = "tenant"
+	tenantScopedContextKey contextKey = "tenant_scoped"
+)
+
+// Tenant returns a middleware that resolves the tenant of each request.
+//
+// Credentials carrying a tenant (API key or JWT claim) are bound to it, and an
+// X-Tenant header naming another tenant is rejected. Other principals use the
+// default tenant; admins (and anyone when authentication is disabled) may pick
+// a tenant with X-Tenant. known reports whether a tenant exists.
+func Tenant(known func(tenant string) bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUser(r.Context())
+			tenant, status, msg := resolveTenant(claims, r.Header.Get(TenantHeader))
+			if status == 0 && !known(tenant) {
+				status, msg = http.StatusForbidden, "unknown tenant"
+			}
+			if status != 0 {
+				user := ""
+				if claims != nil {
+					user = claims.UserID
+				}
+				logger.Warn().
+					Str("user", user).
+					Str("tenant", r.Header.Get(TenantHeader)).
+					Str("path", r.URL.Path).
+					Msg("tenant rejected")
+				respondAuthError(w, status, msg)
+				return
+			}
+
+			// Scoped requests see a single tenant; only unbound admins without
+			// X-Tenant (or anonymous requests when auth is off) see them all
+			scoped := r.Header.Get(TenantHeader) != "" ||
+				(claims != nil && (claims.Tenant != "" || !HasRole(claims, RoleAdmin)))
+
+			ctx := WithTenant(r.Context(), tenant)
+			ctx = context.WithValue(ctx, tenantScopedContextKey, scoped)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireNoTenant rejects credentials bound to a tenant, for operations on
+// resources all tenants share (such as workers)
+func RequireNoTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims := GetUser(r.Context()); claims != nil && claims.Tenant != "" {
+			respondAuthError(w, http.StatusForbidden, "not available to tenant-bound credentials")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// resolveTenant applies the binding rules of Tenant. A non-zero status means
+// the request is rejected with msg.
+func resolveTenant(claims *Claims, header string) (string, int, string) {
+	tenant := ""
+	if claims != nil {
+		tenant = claims.Tenant
+	}
+	if header == "" {
+		return tenant, 0, ""
+	}
+
+	requested := queue.ParseTenantName(header)
+	if requested == tenant {
+		return tenant, 0, ""
+	}
+	if claims != nil && claims.Tenant != "" {
+		return "", http.StatusForbidden, "credentials are bound to tenant " + claims.Tenant
+	}
+	if claims != nil && !HasRole(claims, RoleAdmin) {
+		return "", http.StatusForbidden, "only admins may select a tenant"
+	}
+	return requested, 0, ""
+}
+
+// WithTenant returns a context carrying the request tenant ("" = default)
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	noteAuditTenant(ctx, tenant)
+	return context.WithValue(ctx, TenantContextKey, tenant)
+}
+
+// TenantFromContext returns the tenant resolved by the Tenant middleware, or
+// the default tenant ("") if there is none
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(TenantContextKey).(string)
+	return tenant
+}
+
+// TenantScoped reports whether the request is restricted to its tenant.
+// Requests that did not pass through the Tenant middleware are scoped.
+func TenantScoped(ctx context.Context) bool {
+	scoped, ok := ctx.Value(tenantScopedContextKey).(bool)
+	return !ok || scoped
+}
+
+// TenantRateLimit returns a middleware that enforces each tenant's rate limit
+// across all replicas; rps returns a tenant's limit, 0 for none. It must run
+// after Tenant.
+func TenantRateLimit(limiter *RedisRateLimiter, rps func(tenant string) int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := TenantFromContext(r.Context())
+			limit := rps(tenant)
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res := limiter.Allow(r.Context(), "tenant:"+tenant+":submit", limit)
+			if !res.Allowed {
+				logger.Warn().
+					Str("method", r.Method).
+					Str("path", r.URL.Path).
+					Str("tenant", tenant).
+					Msg("tenant rate limit exceeded")
+				respondRateLimited(w, &res, "tenant rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tenantRequest(claims *Claims, header string) (*httptest.ResponseRecorder, string, bool) {
+	known := func(tenant string) bool { return tenant == "" || tenant == "acme" || tenant == "globex" }
+
+	var tenant string
+	var scoped bool
+	handler := Tenant(known)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = TenantFromContext(r.Context())
+		scoped = TenantScoped(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks", nil)
+	if header != "" {
+		req.Header.Set(TenantHeader, header)
+	}
+	if claims != nil {
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey, claims))
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr, tenant, scoped
+}
+
+func TestTenant_BoundCredentials(t *testing.T) {
+	claims := &Claims{UserID: "apikey:acme", Roles: []string{RoleAdmin}, Tenant: "acme"}
+
+	rr, tenant, scoped := tenantRequest(claims, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "acme", tenant)
+	assert.True(t, scoped)
+
+	rr, tenant, _ = tenantRequest(claims, "acme")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "acme", tenant)
+
+	rr, _, _ = tenantRequest(claims, "globex")
+	assert.Equal(t, http.StatusForbidden, rr.Code, "bound credentials cannot switch tenant")
+}
+
+func TestTenant_UnboundCredentials(t *testing.T) {
+	viewer := &Claims{UserID: "u1", Roles: []string{RoleViewer}}
+	admin := &Claims{UserID: "u2", Roles: []string{RoleAdmin}}
+
+	rr, tenant, scoped := tenantRequest(viewer, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", tenant)
+	assert.True(t, scoped)
+
+	rr, _, _ = tenantRequest(viewer, "acme")
+	assert.Equal(t, http.StatusForbidden, rr.Code, "only admins may select a tenant")
+
+	rr, _, _ = tenantRequest(viewer, "default")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr, tenant, scoped = tenantRequest(admin, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", tenant)
+	assert.False(t, scoped, "an unbound admin sees all tenants")
+
+	rr, tenant, scoped = tenantRequest(admin, "globex")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "globex", tenant)
+	assert.True(t, scoped)
+
+	rr, _, _ = tenantRequest(admin, "initech")
+	assert.Equal(t, http.StatusForbidden, rr.Code, "unknown tenants are rejected")
+}
+
+func TestTenant_AuthDisabled(t *testing.T) {
+	rr, tenant, scoped := tenantRequest(nil, "acme")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "acme", tenant)
+	assert.True(t, scoped)
+
+	_, tenant, scoped = tenantRequest(nil, "")
+	assert.Equal(t, "", tenant)
+	assert.False(t, scoped)
+}
+
+func TestRequireNoTenant(t *testing.T) {
+	handler := RequireNoTenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/workers/w1/pause", nil)
+	req = req.WithContext(context.WithValue(req.Context(), UserContextKey, &Claims{Tenant: "acme"}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/workers/w1/pause", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestTenantRateLimit(t *testing.T) {
+	handler := TenantRateLimit(NewRedisRateLimiter(nil), func(tenant string) int {
+		if tenant == "acme" {
+			return 2
+		}
+		return 0
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	submit := func(tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req.WithContext(WithTenant(req.Context(), tenant)))
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, submit("acme").Code)
+	assert.Equal(t, http.StatusOK, submit("acme").Code)
+	rr := submit("acme")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, "acme is limited to 2 rps")
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, submit("").Code, "tenants without a limit are not limited")
+	}
+}
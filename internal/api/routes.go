@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -11,128 +13,323 @@ import (
 	"github.com/maumercado/task-queue-go/internal/api/handlers"
 	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
 	"github.com/maumercado/task-queue-go/internal/api/websocket"
+	"github.com/maumercado/task-queue-go/internal/apikey"
+	"github.com/maumercado/task-queue-go/internal/audit"
 	"github.com/maumercado/task-queue-go/internal/config"
 	"github.com/maumercado/task-queue-go/internal/events"
 	"github.com/maumercado/task-queue-go/internal/queue"
+	"github.com/maumercado/task-queue-go/internal/redact"
 )
 
 // Server represents the HTTP server
 type Server struct {
 	router       *chi.Mux
+	adminRouter  *chi.Mux // nil when admin routes are served by router
 	queue        *queue.RedisQueue
 	dlq          *queue.DLQ
+	redrive      *queue.RedriveManager
 	config       *config.Config
 	taskHandler  *handlers.TaskHandler
 	adminHandler *handlers.AdminHandler
 	wsHub        *websocket.Hub
 	wsHandler    *websocket.Handler
 	publisher    *events.RedisPubSub
+	auth         *apiMiddleware.AuthConfig
+	adminAuth    *apiMiddleware.AuthConfig
+	audit        *audit.Log // nil when disabled
+	limiter      *apiMiddleware.RedisRateLimiter
+	proxies      []*net.IPNet // Proxies whose forwarded client addresses are trusted
+}
+
+// auditActions names audited routes by method and chi route pattern
+var auditActions = map[string]string{
+	"POST /api/v1/tasks":                     "task.create",
+	"PATCH /api/v1/tasks/{taskID}":           "task.update",
+	"DELETE /api/v1/tasks/{taskID}":          "task.cancel",
+	"POST /admin/workers/{workerID}/pause":   "worker.pause",
+	"POST /admin/workers/{workerID}/resume":  "worker.resume",
+	"POST /admin/tasks/{taskID}/retry":       "task.retry",
+	"POST /admin/tasks/{taskID}/run-now":     "task.run_now",
+	"POST /admin/tasks/run-now":              "task.run_now_bulk",
+	"POST /admin/dlq/retry":                  "dlq.retry",
+	"POST /admin/dlq/redrive":                "dlq.redrive.create",
+	"POST /admin/dlq/redrive/{jobID}/pause":  "dlq.redrive.pause",
+	"POST /admin/dlq/redrive/{jobID}/resume": "dlq.redrive.resume",
+	"POST /admin/dlq/redrive/{jobID}/cancel": "dlq.redrive.cancel",
+	"DELETE /admin/queues/{priority}":        "queue.purge",
+	"DELETE /admin/dlq":                      "dlq.clear",
+	"DELETE /admin/dlq/{taskID}":             "dlq.delete",
+	"POST /admin/api-keys":                   "apikey.create",
+	"POST /admin/api-keys/{keyID}/revoke":    "apikey.revoke",
+	"POST /admin/api-keys/{keyID}/expire":    "apikey.expire",
+	"POST /admin/api-keys/{keyID}/rotate":    "apikey.rotate",
 }
 
 // NewServer creates a new HTTP server
-func NewServer(cfg *config.Config, q *queue.RedisQueue, dlq *queue.DLQ, publisher *events.RedisPubSub) *Server {
+func NewServer(cfg *config.Config, q *queue.RedisQueue, dlq *queue.DLQ, publisher *events.RedisPubSub) (*Server, error) {
+	auth, err := apiMiddleware.NewAuthConfig(&cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth config: %w", err)
+	}
+	adminAuth := auth
+	if cfg.AdminAuth != nil {
+		if adminAuth, err = apiMiddleware.NewAuthConfig(cfg.AdminAuth); err != nil {
+			return nil, fmt.Errorf("invalid admin auth config: %w", err)
+		}
+	}
+
+	proxies, err := apiMiddleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server config: %w", err)
+	}
+
+	redactor, err := redact.New(&cfg.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction config: %w", err)
+	}
+
 	wsHub := websocket.NewHub(publisher)
 
-	// Create schedule task function
-	scheduleTask := queue.ScheduleTaskFunc(q.Client())
+	// Create schedule task function; stores the task encrypted if configured
+	scheduleTask := q.ScheduleTask
+
+	redrive := queue.NewRedriveManager(q.Client(), q, dlq, cfg.Queue.RedriveRate)
+
+	// Keys created at /admin/api-keys; looked up in Redis on every use so
+	// revocation applies to all replicas at once
+	keys := apikey.NewStore(q.Client())
+	auth.Keys = keys
+	adminAuth.Keys = keys
+
+	var auditLog *audit.Log
+	if cfg.Audit.Enabled {
+		auditLog, err = audit.NewLog(q.Client(), &cfg.Audit)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	s := &Server{
 		router:       chi.NewRouter(),
 		queue:        q,
 		dlq:          dlq,
+		redrive:      redrive,
 		config:       cfg,
 		taskHandler:  handlers.NewTaskHandler(q, dlq, scheduleTask, cfg.Queue.MaxQueueSize, cfg.Queue.RetryMaxAttempts, publisher),
-		adminHandler: handlers.NewAdminHandler(q, dlq, publisher),
+		adminHandler: handlers.NewAdminHandler(q, dlq, redrive, keys, auditLog, publisher),
 		wsHub:        wsHub,
 		wsHandler:    websocket.NewHandler(wsHub),
 		publisher:    publisher,
+		auth:         auth,
+		adminAuth:    adminAuth,
+		audit:        auditLog,
+		limiter:      apiMiddleware.NewRedisRateLimiter(q.Client()),
+		proxies:      proxies,
+	}
+
+	s.taskHandler.SetEncryptedReaders(cfg.Encryption.ReaderRoles)
+	s.adminHandler.SetEncryptedReaders(cfg.Encryption.ReaderRoles)
+	s.taskHandler.SetRedaction(redactor, cfg.Redaction.RevealRoles)
+	s.adminHandler.SetRedaction(redactor, cfg.Redaction.RevealRoles)
+	s.wsHub.SetRedactor(redactor)
+	s.wsHandler.SetRevealRoles(cfg.Redaction.RevealRoles)
+
+	// Admin routes, metrics and pprof get their own listener unless it is
+	// disabled or shares the API port
+	if cfg.Server.AdminPort != 0 && cfg.Server.AdminPort != cfg.Server.Port {
+		s.adminRouter = chi.NewRouter()
+		s.setupMiddleware(s.adminRouter)
 	}
 
-	s.setupMiddleware()
+	s.setupMiddleware(s.router)
 	s.setupRoutes()
 
-	return s
+	return s, nil
 }
 
-func (s *Server) setupMiddleware() {
+func (s *Server) setupMiddleware(router *chi.Mux) {
 	// Request ID
-	s.router.Use(middleware.RequestID)
+	router.Use(middleware.RequestID)
 
-	// Real IP
-	s.router.Use(middleware.RealIP)
+	// Real IP, from forwarding headers of trusted proxies only
+	router.Use(apiMiddleware.RealIP(s.proxies))
 
 	// Logging
-	s.router.Use(apiMiddleware.RequestLogger())
+	router.Use(apiMiddleware.RequestLogger())
 
 	// Recoverer
-	s.router.Use(middleware.Recoverer)
+	router.Use(middleware.Recoverer)
 
 	// Heartbeat endpoint for load balancers
-	s.router.Use(middleware.Heartbeat("/health"))
+	router.Use(middleware.Heartbeat("/health"))
 }
 
 func (s *Server) setupRoutes() {
 	// API v1 routes
 	s.router.Route("/api/v1", func(r chi.Router) {
+		r.Use(s.auditRequests())
 		// Content type for API routes
 		r.Use(middleware.AllowContentType("application/json"))
-
-		// Rate limiting for API routes
-		if s.config.Queue.RateLimitRPS > 0 {
-			r.Use(apiMiddleware.ClientRateLimit(s.config.Queue.RateLimitRPS))
-		}
+		r.Use(apiMiddleware.Auth(s.auth))
+		r.Use(apiMiddleware.Tenant(s.queue.HasTenant))
+		r.Use(s.clientLimit())
 
 		// Task routes
 		r.Route("/tasks", func(r chi.Router) {
-			r.Post("/", s.taskHandler.Create)
-			r.Get("/{taskID}", s.taskHandler.Get)
-			r.Delete("/{taskID}", s.taskHandler.Cancel)
-			r.Get("/", s.taskHandler.List)
+			r.Group(func(r chi.Router) {
+				r.Use(s.requireRole(apiMiddleware.RoleSubmitter, apiMiddleware.RoleViewer))
+				r.Get("/{taskID}", s.taskHandler.Get)
+				r.Get("/", s.taskHandler.List)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(s.requireRole(apiMiddleware.RoleSubmitter))
+				r.With(apiMiddleware.TenantRateLimit(s.limiter, s.tenantSubmitRPS)).Post("/", s.taskHandler.Create)
+				r.Patch("/{taskID}", s.taskHandler.Update)
+				r.Delete("/{taskID}", s.taskHandler.Cancel)
+			})
 		})
 	})
 
+	// WebSocket endpoint; browsers cannot set headers on the upgrade request,
+	// so credentials may also be passed as query parameters
+	wsAuth := *s.auth
+	wsAuth.AllowQueryToken = true
+	s.router.With(apiMiddleware.Auth(&wsAuth), apiMiddleware.Tenant(s.queue.HasTenant), s.requireRole(apiMiddleware.RoleViewer)).
+		Get("/ws", s.wsHandler.ServeWS)
+
+	admin := s.router
+	if s.adminRouter != nil {
+		admin = s.adminRouter
+
+		// Profiling, only on the admin listener
+		admin.With(apiMiddleware.Auth(s.adminAuth), s.requireRoleWith(s.adminAuth, apiMiddleware.RoleAdmin)).
+			Mount("/debug", middleware.Profiler())
+	}
+	requireRole := func(roles ...string) func(http.Handler) http.Handler {
+		return s.requireRoleWith(s.adminAuth, roles...)
+	}
+
 	// Admin routes
-	s.router.Route("/admin", func(r chi.Router) {
+	admin.Route("/admin", func(r chi.Router) {
+		r.Use(s.auditRequests())
 		r.Use(middleware.AllowContentType("application/json"))
+		r.Use(apiMiddleware.Auth(s.adminAuth))
+		r.Use(apiMiddleware.Tenant(s.queue.HasTenant))
+		r.Use(s.clientLimit())
 
-		r.Get("/health", s.adminHandler.HealthCheck)
+		// Read-only
+		r.Group(func(r chi.Router) {
+			r.Use(requireRole(apiMiddleware.RoleViewer))
 
-		// Worker management
-		r.Get("/workers", s.adminHandler.ListWorkers)
-		r.Get("/workers/{workerID}", s.adminHandler.GetWorker)
-		r.Post("/workers/{workerID}/pause", s.adminHandler.PauseWorker)
-		r.Post("/workers/{workerID}/resume", s.adminHandler.ResumeWorker)
+			r.Get("/health", s.adminHandler.HealthCheck)
+			// Workers are shared by all tenants
+			r.With(apiMiddleware.RequireNoTenant).Get("/workers", s.adminHandler.ListWorkers)
+			r.With(apiMiddleware.RequireNoTenant).Get("/workers/{workerID}", s.adminHandler.GetWorker)
+			r.Get("/queues", s.adminHandler.GetQueues)
+			r.Get("/dlq", s.adminHandler.ListDLQ)
+			r.Get("/dlq/redrive", s.adminHandler.ListRedrives)
+			r.Get("/dlq/redrive/{jobID}", s.adminHandler.GetRedrive)
+			r.Get("/dlq/redrive/{jobID}/outcomes", s.adminHandler.GetRedriveOutcomes)
+			r.Get("/dlq/{taskID}", s.adminHandler.GetDLQEntry)
+		})
 
-		// Queue management
-		r.Get("/queues", s.adminHandler.GetQueues)
-		r.Delete("/queues/{priority}", s.adminHandler.PurgeQueue)
+		// Operational actions
+		r.Group(func(r chi.Router) {
+			r.Use(requireRole(apiMiddleware.RoleOperator))
 
-		// Task management
-		r.Post("/tasks/{taskID}/retry", s.adminHandler.RetryTask)
+			// Worker management; workers are shared by all tenants
+			r.With(apiMiddleware.RequireNoTenant).Post("/workers/{workerID}/pause", s.adminHandler.PauseWorker)
+			r.With(apiMiddleware.RequireNoTenant).Post("/workers/{workerID}/resume", s.adminHandler.ResumeWorker)
 
-		// DLQ management
-		r.Get("/dlq", s.adminHandler.ListDLQ)
-		r.Post("/dlq/retry", s.adminHandler.RetryDLQ)
-		r.Delete("/dlq", s.adminHandler.ClearDLQ)
-	})
+			// Task management
+			r.Post("/tasks/{taskID}/retry", s.adminHandler.RetryTask)
+			r.Post("/tasks/{taskID}/run-now", s.adminHandler.RunTaskNow)
+			r.Post("/tasks/run-now", s.adminHandler.RunTasksNow)
+
+			// DLQ management
+			r.Post("/dlq/retry", s.adminHandler.RetryDLQ)
+			r.Post("/dlq/redrive", s.adminHandler.CreateRedrive)
+			r.Post("/dlq/redrive/{jobID}/pause", s.adminHandler.PauseRedrive)
+			r.Post("/dlq/redrive/{jobID}/resume", s.adminHandler.ResumeRedrive)
+			r.Post("/dlq/redrive/{jobID}/cancel", s.adminHandler.CancelRedrive)
+		})
 
-	// WebSocket endpoint
-	s.router.Get("/ws", s.wsHandler.ServeWS)
+		// Destructive actions
+		r.Group(func(r chi.Router) {
+			r.Use(requireRole(apiMiddleware.RoleAdmin))
+
+			r.Delete("/queues/{priority}", s.adminHandler.PurgeQueue)
+			r.Delete("/dlq", s.adminHandler.ClearDLQ)
+			r.Delete("/dlq/{taskID}", s.adminHandler.DeleteDLQEntry)
+			r.Get("/audit", s.adminHandler.ListAudit)
+
+			// API key management; keys may grant any tenant
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Use(apiMiddleware.RequireNoTenant)
+				r.Post("/", s.adminHandler.CreateAPIKey)
+				r.Get("/", s.adminHandler.ListAPIKeys)
+				r.Get("/{keyID}", s.adminHandler.GetAPIKey)
+				r.Post("/{keyID}/revoke", s.adminHandler.RevokeAPIKey)
+				r.Post("/{keyID}/expire", s.adminHandler.ExpireAPIKey)
+				r.Post("/{keyID}/rotate", s.adminHandler.RotateAPIKey)
+			})
+		})
+	})
 
 	// Metrics endpoint
 	if s.config.Metrics.Enabled {
-		s.router.Handle(s.config.Metrics.Path, promhttp.Handler())
+		admin.Handle(s.config.Metrics.Path, promhttp.Handler())
+	}
+}
+
+// requireRole enforces roles only when authentication is enabled
+func (s *Server) requireRole(roles ...string) func(http.Handler) http.Handler {
+	return s.requireRoleWith(s.auth, roles...)
+}
+
+// requireRoleWith enforces roles only when auth is enabled
+func (s *Server) requireRoleWith(auth *apiMiddleware.AuthConfig, roles ...string) func(http.Handler) http.Handler {
+	if !auth.Enabled {
+		return func(next http.Handler) http.Handler { return next }
 	}
+	return apiMiddleware.RequireRole(roles...)
 }
 
-// Start starts the WebSocket hub
+// clientLimit applies the per-client and per-route rate limits
+func (s *Server) clientLimit() func(http.Handler) http.Handler {
+	routes := make([]apiMiddleware.RouteLimit, len(s.config.RateLimit.Routes))
+	for i, rl := range s.config.RateLimit.Routes {
+		routes[i] = apiMiddleware.RouteLimit{Method: rl.Method, Pattern: rl.Pattern, RPS: rl.RPS}
+	}
+	return apiMiddleware.ClientLimit(s.limiter, s.config.Queue.RateLimitRPS, routes)
+}
+
+// auditRequests records state-changing requests when the audit log is enabled
+func (s *Server) auditRequests() func(http.Handler) http.Handler {
+	if s.audit == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return apiMiddleware.Audit(s.audit, auditActions)
+}
+
+// tenantSubmitRPS returns a tenant's task submission rate limit (0 = none)
+func (s *Server) tenantSubmitRPS(tenant string) int {
+	return s.queue.TenantConfig(tenant).SubmitRPS
+}
+
+// Start starts the WebSocket hub and resumes unfinished DLQ redrive jobs
 func (s *Server) Start(ctx context.Context) {
 	go s.wsHub.Run(ctx)
+	s.redrive.Start(ctx)
 }
 
-// Stop stops the WebSocket hub
+// Stop stops the WebSocket hub and local redrive runners and closes the audit file
 func (s *Server) Stop() {
 	s.wsHub.Stop()
+	s.redrive.Stop()
+	if s.audit != nil {
+		_ = s.audit.Close()
+	}
 }
 
 // Router returns the chi router
@@ -140,6 +337,15 @@ func (s *Server) Router() *chi.Mux {
 	return s.router
 }
 
+// AdminHandler returns the handler for the admin listener, or nil if admin
+// routes are served by the main router
+func (s *Server) AdminHandler() http.Handler {
+	if s.adminRouter == nil {
+		return nil
+	}
+	return s.adminRouter
+}
+
 // ServeHTTP implements the http.Handler interface
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
@@ -36,6 +36,8 @@ type Client struct {
 	send          chan []byte
 	subscriptions map[events.EventType]bool
 	subMu         sync.RWMutex
+	tenant        *string // Only events of this tenant are sent; nil = all tenants
+	reveal        bool    // Send sensitive payload and result fields unredacted
 }
 
 // NewClient creates a new WebSocket client
@@ -49,6 +51,26 @@ func NewClient(hub *Hub, conn *websocket.Conn) *Client {
 	}
 }
 
+// SetTenant restricts the client to the events of one tenant ("" = default)
+func (c *Client) SetTenant(tenant string) {
+	c.tenant = &tenant
+}
+
+// SetReveal lets the client see sensitive payload and result fields
+func (c *Client) SetReveal(reveal bool) {
+	c.reveal = reveal
+}
+
+// Accepts reports whether an event belongs to the client's tenant. Events
+// without a tenant belong to the default tenant.
+func (c *Client) Accepts(event *events.Event) bool {
+	if c.tenant == nil {
+		return true
+	}
+	tenant, _ := event.Data["tenant"].(string)
+	return tenant == *c.tenant
+}
+
 // Subscribe subscribes the client to an event type
 func (c *Client) Subscribe(eventType events.EventType) {
 	c.subMu.Lock()
@@ -71,6 +93,8 @@ func (c *Client) SubscribeAll() {
 	c.subscriptions[events.EventTaskCompleted] = true
 	c.subscriptions[events.EventTaskFailed] = true
 	c.subscriptions[events.EventTaskRetrying] = true
+	c.subscriptions[events.EventTaskExpired] = true
+	c.subscriptions[events.EventTaskUpdated] = true
 	c.subscriptions[events.EventWorkerJoined] = true
 	c.subscriptions[events.EventWorkerLeft] = true
 	c.subscriptions[events.EventWorkerPaused] = true
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/websocket"
 
+	apiMiddleware "github.com/maumercado/task-queue-go/internal/api/middleware"
 	"github.com/maumercado/task-queue-go/internal/logger"
 )
 
@@ -19,7 +20,8 @@ var upgrader = websocket.Upgrader{
 
 // Handler handles WebSocket connections
 type Handler struct {
-	hub *Hub
+	hub         *Hub
+	revealRoles []string
 }
 
 // NewHandler creates a new WebSocket handler
@@ -27,6 +29,12 @@ func NewHandler(hub *Hub) *Handler {
 	return &Handler{hub: hub}
 }
 
+// SetRevealRoles sends sensitive payload and result fields unredacted to
+// clients holding one of roles
+func (h *Handler) SetRevealRoles(roles []string) {
+	h.revealRoles = roles
+}
+
 // ServeWS handles WebSocket upgrade requests
 func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -39,6 +47,10 @@ func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
 
 	// Subscribe to all events by default
 	client.SubscribeAll()
+	if apiMiddleware.TenantScoped(r.Context()) {
+		client.SetTenant(apiMiddleware.TenantFromContext(r.Context()))
+	}
+	client.SetReveal(apiMiddleware.CallerHasAnyRole(r.Context(), h.revealRoles))
 
 	h.hub.Register(client)
 
@@ -7,6 +7,7 @@ import (
 	"github.com/maumercado/task-queue-go/internal/events"
 	"github.com/maumercado/task-queue-go/internal/logger"
 	"github.com/maumercado/task-queue-go/internal/metrics"
+	"github.com/maumercado/task-queue-go/internal/redact"
 )
 
 // Hub manages WebSocket clients and broadcasts messages
@@ -16,6 +17,7 @@ type Hub struct {
 	register   chan *Client
 	unregister chan *Client
 	publisher  *events.RedisPubSub
+	redactor   *redact.Redactor
 	mu         sync.RWMutex
 	stopCh     chan struct{}
 	wg         sync.WaitGroup
@@ -33,6 +35,12 @@ func NewHub(publisher *events.RedisPubSub) *Hub {
 	}
 }
 
+// SetRedactor redacts sensitive payload and result fields in events sent to
+// clients that may not see them
+func (h *Hub) SetRedactor(r *redact.Redactor) {
+	h.redactor = r
+}
+
 // Run starts the hub's main loop
 func (h *Hub) Run(ctx context.Context) {
 	// Subscribe to all events from Redis
@@ -137,17 +145,31 @@ func (h *Hub) broadcastEvent(event *events.Event) {
 		return
 	}
 
+	// Clients that may not see sensitive fields get a redacted copy
+	redactedData := data
+	if redacted := h.redactor.Event(event); redacted != event {
+		if redactedData, err = redacted.ToJSON(); err != nil {
+			logger.Error().Err(err).Msg("failed to serialize event for broadcast")
+			return
+		}
+	}
+
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
 	for client := range h.clients {
 		// Check if client is subscribed to this event type
-		if !client.IsSubscribed(event.Type) {
+		if !client.IsSubscribed(event.Type) || !client.Accepts(event) {
 			continue
 		}
 
+		msg := redactedData
+		if client.reveal {
+			msg = data
+		}
+
 		select {
-		case client.send <- data:
+		case client.send <- msg:
 			metrics.RecordWebSocketMessage(string(event.Type))
 		default:
 			// Client buffer full, mark for removal
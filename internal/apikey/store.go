@@ -0,0 +1,342 @@
+// Package apikey stores API keys in Redis. Only a SHA-256 hash of each secret
+// is kept; the secret itself is returned once, when the key is created.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keysIndexKey  = "apikeys"          // ZSET of key IDs scored by creation time (ms)
+	keyPrefix     = "apikey:"          // apikey:<id> -> key record (JSON)
+	hashPrefix    = "apikey:hash:"     // apikey:hash:<sha256> -> key ID, only while the key is usable
+	lastUsedKey   = "apikeys:lastused" // hash: key ID -> last use (ms)
+	secretPrefix  = "tq_"
+	secretBytes   = 24
+	displayLength = len(secretPrefix) + 8
+
+	// lastUsedResolution limits last-used writes to one per key per replica
+	// in this interval, so busy keys do not add a write to every request
+	lastUsedResolution = time.Minute
+)
+
+// Errors returned by the store
+var (
+	ErrKeyNotFound = errors.New("api key not found")
+	ErrInvalidKey  = errors.New("invalid API key")
+	ErrKeyRevoked  = errors.New("API key revoked")
+	ErrKeyExpired  = errors.New("API key expired")
+)
+
+// Key is a stored API key
+type Key struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"` // Start of the secret, to recognize a key
+	Hash         string     `json:"-"`      // Only stored, never returned
+	Roles        []string   `json:"roles"`
+	TaskTypes    []string   `json:"task_types,omitempty"` // Task types the key may submit; empty = any
+	ReadOnly     bool       `json:"read_only,omitempty"`
+	Tenant       string     `json:"tenant,omitempty"`
+	RateLimitRPS int        `json:"rate_limit_rps,omitempty"` // 0 = only the global limits apply
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RotatedTo    string     `json:"rotated_to,omitempty"` // ID of the key that replaced this one
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+// record is the stored form of a key, which includes its hash
+type record struct {
+	Key
+	Hash string `json:"hash"`
+}
+
+// Status returns active, expired or revoked
+func (k *Key) Status(now time.Time) string {
+	switch {
+	case k.RevokedAt != nil:
+		return "revoked"
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		return "expired"
+	default:
+		return "active"
+	}
+}
+
+// CreateRequest describes a new key
+type CreateRequest struct {
+	Name         string
+	Roles        []string
+	TaskTypes    []string
+	ReadOnly     bool
+	Tenant       string
+	RateLimitRPS int
+	ExpiresAt    *time.Time
+}
+
+// Store manages API keys in Redis. Every authentication reads Redis, so a
+// revoked or expired key is rejected by all replicas on its next use.
+type Store struct {
+	client *redis.Client
+
+	mu          sync.Mutex
+	lastTouched map[string]time.Time
+}
+
+// NewStore creates an API key store
+func NewStore(client *redis.Client) *Store {
+	return &Store{
+		client:      client,
+		lastTouched: make(map[string]time.Time),
+	}
+}
+
+// Create stores a new key and returns it together with its secret, which is
+// not stored and cannot be retrieved later
+func (s *Store) Create(ctx context.Context, req CreateRequest) (*Key, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	key := &Key{
+		ID:           uuid.New().String(),
+		Name:         req.Name,
+		Prefix:       secret[:displayLength],
+		Hash:         HashSecret(secret),
+		Roles:        req.Roles,
+		TaskTypes:    req.TaskTypes,
+		ReadOnly:     req.ReadOnly,
+		Tenant:       req.Tenant,
+		RateLimitRPS: req.RateLimitRPS,
+		CreatedAt:    now,
+		ExpiresAt:    req.ExpiresAt,
+	}
+
+	data, err := json.Marshal(record{Key: *key, Hash: key.Hash})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal api key: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, keyPrefix+key.ID, data, 0)
+	pipe.Set(ctx, hashPrefix+key.Hash, key.ID, 0)
+	pipe.ZAdd(ctx, keysIndexKey, redis.Z{Score: float64(now.UnixMilli()), Member: key.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, "", fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	return key, secret, nil
+}
+
+// Get returns a key by ID
+func (s *Store) Get(ctx context.Context, id string) (*Key, error) {
+	pipe := s.client.Pipeline()
+	raw := pipe.Get(ctx, keyPrefix+id)
+	lastUsed := pipe.HGet(ctx, lastUsedKey, id)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if raw.Err() == redis.Nil {
+		return nil, ErrKeyNotFound
+	}
+
+	var rec record
+	if err := json.Unmarshal([]byte(raw.Val()), &rec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal api key: %w", err)
+	}
+	key := rec.Key
+	key.Hash = rec.Hash
+	if ms, err := strconv.ParseInt(lastUsed.Val(), 10, 64); err == nil {
+		at := time.UnixMilli(ms).UTC()
+		key.LastUsedAt = &at
+	}
+	return &key, nil
+}
+
+// List returns all keys, newest first
+func (s *Store) List(ctx context.Context) ([]*Key, error) {
+	ids, err := s.client.ZRevRange(ctx, keysIndexKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(ids))
+	for _, id := range ids {
+		key, err := s.Get(ctx, id)
+		if errors.Is(err, ErrKeyNotFound) {
+			s.client.ZRem(ctx, keysIndexKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Revoke disables a key permanently. The key record is kept for auditing.
+func (s *Store) Revoke(ctx context.Context, id string) (*Key, error) {
+	key, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt == nil {
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+	}
+	return key, s.save(ctx, key, true)
+}
+
+// Expire sets when a key stops working; a time in the past expires it now
+func (s *Store) Expire(ctx context.Context, id string, at time.Time) (*Key, error) {
+	key, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return key, ErrKeyRevoked
+	}
+	at = at.UTC()
+	key.ExpiresAt = &at
+	return key, s.save(ctx, key, false)
+}
+
+// Rotate creates a replacement key with the same settings and expires the old
+// one after grace, so clients can switch over. The new secret is returned once.
+func (s *Store) Rotate(ctx context.Context, id string, grace time.Duration) (*Key, string, error) {
+	old, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if status := old.Status(time.Now()); status != "active" {
+		if status == "revoked" {
+			return nil, "", ErrKeyRevoked
+		}
+		return nil, "", ErrKeyExpired
+	}
+
+	key, secret, err := s.Create(ctx, CreateRequest{
+		Name:         old.Name,
+		Roles:        old.Roles,
+		TaskTypes:    old.TaskTypes,
+		ReadOnly:     old.ReadOnly,
+		Tenant:       old.Tenant,
+		RateLimitRPS: old.RateLimitRPS,
+		ExpiresAt:    old.ExpiresAt,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	expiresAt := time.Now().UTC().Add(grace)
+	if old.ExpiresAt == nil || expiresAt.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &expiresAt
+	}
+	old.RotatedTo = key.ID
+	if err := s.save(ctx, old, false); err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// Authenticate resolves a secret to its key. Revoked and expired keys are
+// rejected; on success the key's last-used time is recorded.
+func (s *Store) Authenticate(ctx context.Context, secret string) (*Key, error) {
+	if !strings.HasPrefix(secret, secretPrefix) {
+		return nil, ErrInvalidKey
+	}
+
+	id, err := s.client.Get(ctx, hashPrefix+HashSecret(secret)).Result()
+	if err == redis.Nil {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+
+	key, err := s.Get(ctx, id)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch key.Status(now) {
+	case "revoked":
+		return nil, ErrKeyRevoked
+	case "expired":
+		return nil, ErrKeyExpired
+	}
+
+	s.touch(ctx, key.ID, now)
+	return key, nil
+}
+
+// touch records a use of the key, at most once per lastUsedResolution
+func (s *Store) touch(ctx context.Context, id string, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastTouched[id]) < lastUsedResolution {
+		s.mu.Unlock()
+		return
+	}
+	s.lastTouched[id] = now
+	s.mu.Unlock()
+
+	s.client.HSet(ctx, lastUsedKey, id, now.UnixMilli())
+}
+
+// save writes a key record. Revoked keys also lose their hash lookup.
+func (s *Store) save(ctx context.Context, key *Key, revoke bool) error {
+	stored := record{Key: *key, Hash: key.Hash}
+	stored.LastUsedAt = nil // Kept in lastUsedKey
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal api key: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, keyPrefix+key.ID, data, 0)
+	if revoke {
+		pipe.Del(ctx, hashPrefix+key.Hash)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store api key: %w", err)
+	}
+	return nil
+}
+
+// HashSecret returns the hex SHA-256 of a secret. Secrets are random, so a
+// fast hash is sufficient.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,60 @@
+package apikey
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSecret(t *testing.T) {
+	a, err := newSecret()
+	require.NoError(t, err)
+	b, err := newSecret()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(a, secretPrefix))
+	assert.Len(t, a, len(secretPrefix)+32) // 24 bytes, base64 without padding
+	assert.NotEqual(t, a, b)
+	assert.Greater(t, len(a), displayLength)
+}
+
+func TestHashSecret(t *testing.T) {
+	hash := HashSecret("tq_abc")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashSecret("tq_abc"))
+	assert.NotEqual(t, hash, HashSecret("tq_abd"))
+}
+
+func TestKey_Status(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	assert.Equal(t, "active", (&Key{}).Status(now))
+	assert.Equal(t, "active", (&Key{ExpiresAt: &future}).Status(now))
+	assert.Equal(t, "expired", (&Key{ExpiresAt: &past}).Status(now))
+	assert.Equal(t, "expired", (&Key{ExpiresAt: &now}).Status(now))
+	assert.Equal(t, "revoked", (&Key{RevokedAt: &past, ExpiresAt: &past}).Status(now))
+}
+
+func TestKey_HashNotExposed(t *testing.T) {
+	key := Key{ID: "id-1", Name: "ci", Hash: "secret-hash"}
+
+	data, err := json.Marshal(key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-hash")
+
+	// The stored record keeps it
+	data, err = json.Marshal(record{Key: key, Hash: key.Hash})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"hash":"secret-hash"`)
+
+	var rec record
+	require.NoError(t, json.Unmarshal(data, &rec))
+	assert.Equal(t, "secret-hash", rec.Hash)
+	assert.Equal(t, "ci", rec.Name)
+}
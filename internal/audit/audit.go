@@ -0,0 +1,241 @@
+// Package audit records state-changing API requests in an append-only Redis
+// stream, optionally mirrored to an NDJSON file.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/logger"
+)
+
+const (
+	streamKey = "audit"
+
+	// queryScanBatch is how many entries a query reads per round trip
+	queryScanBatch = 500
+	// queryMaxScan bounds the entries one query inspects, so a filter that
+	// matches nothing cannot walk the whole log
+	queryMaxScan = 50000
+
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// Outcomes of an audited request
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied" // Rejected by authorization
+	OutcomeFailure = "failure"
+)
+
+// Entry is one audited request
+type Entry struct {
+	ID           string    `json:"id,omitempty"` // Stream ID, set when read back
+	Time         time.Time `json:"time"`
+	Actor        string    `json:"actor"`            // User ID or apikey:<name|id>; "anonymous" without auth
+	Tenant       string    `json:"tenant,omitempty"` // Tenant the request acted on
+	Action       string    `json:"action"`           // e.g. queue.purge, task.cancel
+	Target       string    `json:"target"`           // Request path of the resource
+	Method       string    `json:"method"`
+	RequestID    string    `json:"request_id,omitempty"`
+	SourceIP     string    `json:"source_ip,omitempty"`     // TCP peer, which may be a proxy
+	ForwardedFor string    `json:"forwarded_for,omitempty"` // X-Forwarded-For as sent; not verified
+	Status       int       `json:"status"`
+	Outcome      string    `json:"outcome"`
+}
+
+// OutcomeFor classifies an HTTP status
+func OutcomeFor(status int) string {
+	switch {
+	case status == 401 || status == 403:
+		return OutcomeDenied
+	case status >= 400:
+		return OutcomeFailure
+	default:
+		return OutcomeSuccess
+	}
+}
+
+// Filter selects entries in a query. Empty fields match everything.
+type Filter struct {
+	Actor   string
+	Action  string // Exact action, or a prefix ending in "." (e.g. "dlq.")
+	Outcome string
+	Tenant  *string
+	Since   time.Time
+	Until   time.Time
+	Before  string // Return entries older than this stream ID (pagination)
+	Limit   int
+}
+
+// Matches reports whether an entry passes the filter's field checks
+func (f *Filter) Matches(e *Entry) bool {
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if f.Action != "" {
+		if strings.HasSuffix(f.Action, ".") {
+			if !strings.HasPrefix(e.Action, f.Action) {
+				return false
+			}
+		} else if e.Action != f.Action {
+			return false
+		}
+	}
+	if f.Outcome != "" && e.Outcome != f.Outcome {
+		return false
+	}
+	if f.Tenant != nil && e.Tenant != *f.Tenant {
+		return false
+	}
+	return true
+}
+
+// Log appends entries to the audit stream and the optional file
+type Log struct {
+	client     *redis.Client
+	maxAge     time.Duration
+	maxEntries int64
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewLog creates an audit log. The file, if configured, is opened for append.
+func NewLog(client *redis.Client, cfg *config.AuditConfig) (*Log, error) {
+	l := &Log{
+		client:     client,
+		maxAge:     cfg.MaxAge,
+		maxEntries: cfg.MaxEntries,
+	}
+	if cfg.File != "" {
+		f, err := os.OpenFile(cfg.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit file: %w", err)
+		}
+		l.file = f
+	}
+	return l, nil
+}
+
+// Record appends an entry. Failures are logged; they never fail the request.
+func (l *Log) Record(ctx context.Context, e *Entry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to marshal audit entry")
+		return
+	}
+
+	if l.file != nil {
+		l.mu.Lock()
+		_, err := l.file.Write(append(data, '\n'))
+		l.mu.Unlock()
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to write audit file")
+		}
+	}
+
+	args := &redis.XAddArgs{
+		Stream: streamKey,
+		Values: map[string]interface{}{"data": string(data)},
+	}
+	if l.maxEntries > 0 {
+		args.MaxLen = l.maxEntries
+		args.Approx = true
+	}
+
+	pipe := l.client.Pipeline()
+	pipe.XAdd(ctx, args)
+	if l.maxAge > 0 {
+		minID := strconv.FormatInt(e.Time.Add(-l.maxAge).UnixMilli(), 10)
+		pipe.XTrimMinIDApprox(ctx, streamKey, minID, 0)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Error().Err(err).Str("action", e.Action).Msg("failed to record audit entry")
+	}
+}
+
+// Query returns matching entries, newest first, and the ID to pass as Before
+// for the next page ("" when there are no more)
+func (l *Log) Query(ctx context.Context, f Filter) ([]*Entry, string, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	limit = min(limit, MaxQueryLimit)
+
+	end := "+"
+	if f.Before != "" {
+		end = "(" + f.Before
+	} else if !f.Until.IsZero() {
+		end = strconv.FormatInt(f.Until.UnixMilli(), 10)
+	}
+	start := "-"
+	if !f.Since.IsZero() {
+		start = strconv.FormatInt(f.Since.UnixMilli(), 10)
+	}
+
+	var entries []*Entry
+	scanned := 0
+	for scanned < queryMaxScan {
+		messages, err := l.client.XRevRangeN(ctx, streamKey, end, start, queryScanBatch).Result()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read audit log: %w", err)
+		}
+		for _, msg := range messages {
+			scanned++
+			end = "(" + msg.ID
+
+			e, ok := parseEntry(msg)
+			if !ok || !f.Matches(e) {
+				continue
+			}
+			if !f.Until.IsZero() && e.Time.After(f.Until) {
+				continue // Before and Until together
+			}
+			entries = append(entries, e)
+			if len(entries) == limit {
+				return entries, msg.ID, nil
+			}
+		}
+		if len(messages) < queryScanBatch {
+			return entries, "", nil
+		}
+	}
+
+	// Scan budget used up; let the client continue from here
+	return entries, strings.TrimPrefix(end, "("), nil
+}
+
+// Close closes the audit file
+func (l *Log) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+func parseEntry(msg redis.XMessage) (*Entry, bool) {
+	data, ok := msg.Values["data"].(string)
+	if !ok {
+		return nil, false
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return nil, false
+	}
+	e.ID = msg.ID
+	return &e, true
+}
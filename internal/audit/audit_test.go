@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcomeFor(t *testing.T) {
+	assert.Equal(t, OutcomeSuccess, OutcomeFor(200))
+	assert.Equal(t, OutcomeSuccess, OutcomeFor(202))
+	assert.Equal(t, OutcomeDenied, OutcomeFor(401))
+	assert.Equal(t, OutcomeDenied, OutcomeFor(403))
+	assert.Equal(t, OutcomeFailure, OutcomeFor(404))
+	assert.Equal(t, OutcomeFailure, OutcomeFor(500))
+}
+
+func TestFilter_Matches(t *testing.T) {
+	entry := &Entry{Actor: "alice", Action: "dlq.redrive.create", Outcome: OutcomeSuccess, Tenant: "billing"}
+
+	tenant, other := "billing", ""
+	matching := []Filter{
+		{},
+		{Actor: "alice"},
+		{Action: "dlq.redrive.create"},
+		{Action: "dlq."},
+		{Action: "dlq.redrive."},
+		{Outcome: OutcomeSuccess},
+		{Tenant: &tenant},
+	}
+	for _, f := range matching {
+		assert.True(t, f.Matches(entry), "%+v", f)
+	}
+
+	nonMatching := []Filter{
+		{Actor: "bob"},
+		{Action: "dlq"}, // Prefixes must end in "."
+		{Action: "queue."},
+		{Outcome: OutcomeDenied},
+		{Tenant: &other},
+	}
+	for _, f := range nonMatching {
+		assert.False(t, f.Matches(entry), "%+v", f)
+	}
+}
@@ -7,22 +7,52 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Redis    RedisConfig
-	Worker   WorkerConfig
-	Queue    QueueConfig
-	Metrics  MetricsConfig
-	Auth     AuthConfig
-	LogLevel string
+	Server     ServerConfig
+	Redis      RedisConfig
+	Worker     WorkerConfig
+	Queue      QueueConfig
+	DLQ        DLQConfig
+	Scheduler  SchedulerConfig
+	Metrics    MetricsConfig
+	Auth       AuthConfig
+	AdminAuth  *AuthConfig // Auth for the admin listener; nil = Auth
+	Audit      AuditConfig
+	RateLimit  RateLimitConfig
+	Encryption EncryptionConfig
+	Redaction  RedactionConfig
+	LogLevel   string
 }
 
 type ServerConfig struct {
 	Host         string
 	Port         int
-	AdminPort    int
+	TLS          TLSConfig // TLS for the API and WebSocket listener
+	AdminHost    string    // Empty = Host
+	AdminPort    int       // Listener for /admin, metrics and pprof; 0 or Port = serve them on Port
+	AdminTLS     TLSConfig // TLS for the admin listener
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+
+	// Proxies (IPs or CIDRs) whose X-Forwarded-For and X-Real-IP headers are
+	// trusted for the client address; empty = use the TCP peer address
+	TrustedProxies []string
+}
+
+// TLSConfig holds a listener's certificate and client certificate policy.
+// Rotated certificate files are picked up without a restart.
+type TLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	MinVersion     string        // "1.2" or "1.3"; empty = 1.2
+	ReloadInterval time.Duration // How often the certificate files are checked for changes; 0 = never
+	ClientCAFile   string        // Verify client certificates against this CA bundle (mTLS)
+	ClientAuth     string        // "optional" or "require" a client certificate; empty = optional
+}
+
+// Enabled returns true if a certificate is configured
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
 }
 
 type RedisConfig struct {
@@ -35,6 +65,18 @@ type RedisConfig struct {
 	DialTimeout  time.Duration
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	TLS          RedisTLSConfig
+}
+
+// RedisTLSConfig controls TLS for Redis connections
+type RedisTLSConfig struct {
+	Enabled            bool
+	CAFile             string // CA bundle for the server certificate; empty = system roots
+	CertFile           string // Client certificate, for servers that require one
+	KeyFile            string
+	ServerName         string // Expected server name; empty = the host of Addr
+	MinVersion         string // "1.2" or "1.3"; empty = 1.2
+	InsecureSkipVerify bool   // Do not verify the server certificate (testing only)
 }
 
 type WorkerConfig struct {
@@ -43,6 +85,57 @@ type WorkerConfig struct {
 	HeartbeatInterval time.Duration
 	HeartbeatTimeout  time.Duration
 	ShutdownTimeout   time.Duration
+	Adaptive          AdaptiveConfig
+	Processes         []ProcessConfig
+	Endpoints         []EndpointConfig
+	Tenants           []string // Tenants to serve ("default" = untenanted tasks); empty = all
+}
+
+// AdaptiveConfig controls resource-aware concurrency for a worker pool.
+// Each signal uses a high/low watermark pair so decisions have hysteresis.
+type AdaptiveConfig struct {
+	Enabled          bool
+	MinConcurrency   int
+	MaxConcurrency   int // 0 = use WorkerConfig.Concurrency
+	SampleInterval   time.Duration
+	CPUHighPercent   float64 // Process CPU, normalized to 0-100 across all cores
+	CPULowPercent    float64
+	MemoryHighMB     int64 // Process RSS; 0 disables the memory signal
+	MemoryLowMB      int64
+	RedisLatencyHigh time.Duration // Pause dequeuing at or above this round-trip
+	RedisLatencyLow  time.Duration // Resume dequeuing at or below this round-trip
+}
+
+// ProcessConfig binds a task type to an external executable.
+// The payload is written to stdin as JSON and the result is read from stdout.
+type ProcessConfig struct {
+	TaskType           string
+	Command            string
+	Args               []string
+	Env                []string // KEY=VALUE pairs added to the environment
+	InheritEnv         bool     // Start from the worker's environment instead of an empty one
+	WorkDir            string
+	PermanentExitCodes []int // Exit codes that dead-letter the task; other non-zero codes retry
+	MaxOutputBytes     int64 // Cap on captured stdout; 0 = 1MB
+	Limits             ProcessLimits
+}
+
+// ProcessLimits are rlimits applied to the child process; 0 leaves a limit unset
+type ProcessLimits struct {
+	CPUSeconds int
+	MemoryMB   int
+	OpenFiles  int
+	FileSizeMB int
+}
+
+// EndpointConfig binds a task type to a remote HTTP endpoint that receives the task envelope
+type EndpointConfig struct {
+	TaskType       string
+	URL            string
+	Secret         string // HMAC-SHA256 signing key; empty disables signing
+	Timeout        time.Duration
+	MaxConcurrency int // In-flight deliveries per worker; 0 = unlimited
+	Headers        map[string]string
 }
 
 type QueueConfig struct {
@@ -59,6 +152,82 @@ type QueueConfig struct {
 	RetryJitterFactor   float64
 	TaskRetentionDays   int
 	RateLimitRPS        int
+	RedriveRate         float64 // Default DLQ redrive requeues per second
+	Tenants             []TenantConfig
+}
+
+// TenantConfig declares a tenant and its quotas. A tenant's keys and streams are
+// prefixed with "tenant:<name>:"; tasks submitted without a tenant keep the
+// original unprefixed keys.
+type TenantConfig struct {
+	Name           string
+	MaxQueued      int64 // Queued tasks across all priorities; 0 = unlimited
+	SubmitRPS      int   // Submissions per second, per API replica; 0 = unlimited
+	MaxConcurrency int   // Tasks running at once across all workers; 0 = unlimited
+}
+
+// DLQConfig controls dead letter queue retention.
+// Entries past MaxAge, or the oldest beyond MaxEntries, are removed by a janitor.
+type DLQConfig struct {
+	MaxAge          time.Duration // 0 keeps entries forever
+	MaxEntries      int64         // 0 = unlimited
+	JanitorInterval time.Duration
+	ArchiveDir      string // Write removed entries here as NDJSON first; empty disables archiving
+	ArchiveGzip     bool
+}
+
+// RateLimitConfig holds per-route API rate limits. The default per-client
+// limit is Queue.RateLimitRPS.
+type RateLimitConfig struct {
+	Routes []RouteLimitConfig
+}
+
+// RouteLimitConfig limits one route per client, e.g. POST /api/v1/tasks
+type RouteLimitConfig struct {
+	Method  string // Empty matches any method
+	Pattern string // chi route pattern, e.g. /admin/dlq/{taskID}
+	RPS     int
+}
+
+// AuditConfig controls the audit log of state-changing API requests
+type AuditConfig struct {
+	Enabled    bool
+	MaxAge     time.Duration // Entries older than this are trimmed; 0 keeps them
+	MaxEntries int64         // Approximate cap on stored entries; 0 = unlimited
+	File       string        // Also append entries to this file as NDJSON; empty disables
+}
+
+// EncryptionConfig controls encryption of task payloads and results at rest.
+// Every process that reads or writes tasks (API, workers) needs the keyring.
+type EncryptionConfig struct {
+	Enabled     bool
+	KeyringFile string   // JSON keyring; see docs/api.md
+	TaskTypes   []string // Task types to encrypt; empty = all
+	ReaderRoles []string // Roles that see decrypted payloads and results in API responses
+}
+
+// RedactionConfig lists sensitive payload and result fields, hidden in logs,
+// WebSocket events and API responses. A field is a name, matched at any depth
+// regardless of case, or a JSON path such as "$.card.number" or
+// "$.items[*].cvv".
+type RedactionConfig struct {
+	Fields      []string        // Redacted in every task type
+	Rules       []RedactionRule // Additional fields of particular task types
+	RevealRoles []string        // Roles that see unredacted values in the API and on /ws
+}
+
+// RedactionRule lists the sensitive fields of one task type
+type RedactionRule struct {
+	TaskType string
+	Fields   []string
+}
+
+// SchedulerConfig controls the leader-elected scheduler loop.
+// Set Embedded to false when running cmd/scheduler separately from the API.
+type SchedulerConfig struct {
+	Embedded   bool          // Run the scheduler inside the API server
+	LeaseTTL   time.Duration // A standby takes over within this after the leader dies
+	HealthPort int           // cmd/scheduler health and metrics port
 }
 
 type MetricsConfig struct {
@@ -67,9 +236,45 @@ type MetricsConfig struct {
 }
 
 type AuthConfig struct {
-	Enabled   bool
-	JWTSecret string
-	APIKeys   []string
+	Enabled     bool
+	JWTSecret   string
+	JWT         JWTConfig
+	APIKeys     []APIKeyConfig
+	ClientCerts []ClientCertConfig
+}
+
+// JWTConfig controls how JWTs are verified
+type JWTConfig struct {
+	Algorithms  []string      // Accepted signing algorithms, e.g. HS256, RS256, ES256
+	JWKSURL     string        // Keys for RS*/ES*/PS* tokens, fetched from an identity provider
+	JWKSFile    string        // Keys for RS*/ES*/PS* tokens, read from a local JWKS file
+	JWKSRefresh time.Duration // How long fetched keys are cached
+	Issuer      string        // Required iss claim; empty = not checked
+	Audience    string        // Required aud claim; empty = not checked
+	ClockSkew   time.Duration // Leeway for exp, nbf and iat
+	RolesClaim  string        // Claim holding roles (dotted path); empty = role and roles
+	TenantClaim string        // Claim holding the tenant (dotted path)
+}
+
+// APIKeyConfig is a static API key and the roles it carries
+// (submitter, viewer, operator or admin)
+type APIKeyConfig struct {
+	Name         string
+	Key          string
+	Roles        []string
+	Tenant       string // Empty = not bound to a tenant
+	RateLimitRPS int    // Replaces queue.ratelimitrps for this key; 0 = default
+}
+
+// ClientCertConfig maps a verified TLS client certificate to an identity.
+// Subject is the certificate's common name, or its full distinguished name in
+// RFC 2253 form (e.g. "CN=billing,O=Acme").
+type ClientCertConfig struct {
+	Name         string // Identity name; empty = Subject
+	Subject      string
+	Roles        []string
+	Tenant       string // Empty = not bound to a tenant
+	RateLimitRPS int    // Replaces queue.ratelimitrps for this client; 0 = default
 }
 
 func Load() (*Config, error) {
@@ -105,10 +310,24 @@ func setDefaults() {
 	// Server defaults
 	viper.SetDefault("server.host", "0.0.0.0")
 	viper.SetDefault("server.port", 8080)
+	viper.SetDefault("server.tls.certfile", "")
+	viper.SetDefault("server.tls.keyfile", "")
+	viper.SetDefault("server.tls.minversion", "1.2")
+	viper.SetDefault("server.tls.reloadinterval", time.Minute)
+	viper.SetDefault("server.tls.clientcafile", "")
+	viper.SetDefault("server.tls.clientauth", "")
+	viper.SetDefault("server.adminhost", "")
 	viper.SetDefault("server.adminport", 8081)
+	viper.SetDefault("server.admintls.certfile", "")
+	viper.SetDefault("server.admintls.keyfile", "")
+	viper.SetDefault("server.admintls.minversion", "1.2")
+	viper.SetDefault("server.admintls.reloadinterval", time.Minute)
+	viper.SetDefault("server.admintls.clientcafile", "")
+	viper.SetDefault("server.admintls.clientauth", "")
 	viper.SetDefault("server.readtimeout", 30*time.Second)
 	viper.SetDefault("server.writetimeout", 30*time.Second)
 	viper.SetDefault("server.idletimeout", 120*time.Second)
+	viper.SetDefault("server.trustedproxies", []string{})
 
 	// Redis defaults
 	viper.SetDefault("redis.addr", "localhost:6379")
@@ -120,6 +339,13 @@ func setDefaults() {
 	viper.SetDefault("redis.dialtimeout", 5*time.Second)
 	viper.SetDefault("redis.readtimeout", 3*time.Second)
 	viper.SetDefault("redis.writetimeout", 3*time.Second)
+	viper.SetDefault("redis.tls.enabled", false)
+	viper.SetDefault("redis.tls.cafile", "")
+	viper.SetDefault("redis.tls.certfile", "")
+	viper.SetDefault("redis.tls.keyfile", "")
+	viper.SetDefault("redis.tls.servername", "")
+	viper.SetDefault("redis.tls.minversion", "1.2")
+	viper.SetDefault("redis.tls.insecureskipverify", false)
 
 	// Worker defaults
 	viper.SetDefault("worker.id", "")
@@ -127,6 +353,17 @@ func setDefaults() {
 	viper.SetDefault("worker.heartbeatinterval", 5*time.Second)
 	viper.SetDefault("worker.heartbeattimeout", 15*time.Second)
 	viper.SetDefault("worker.shutdowntimeout", 30*time.Second)
+	viper.SetDefault("worker.adaptive.enabled", false)
+	viper.SetDefault("worker.adaptive.minconcurrency", 1)
+	viper.SetDefault("worker.adaptive.maxconcurrency", 0)
+	viper.SetDefault("worker.adaptive.sampleinterval", 5*time.Second)
+	viper.SetDefault("worker.adaptive.cpuhighpercent", 85.0)
+	viper.SetDefault("worker.adaptive.cpulowpercent", 60.0)
+	viper.SetDefault("worker.adaptive.memoryhighmb", 0)
+	viper.SetDefault("worker.adaptive.memorylowmb", 0)
+	viper.SetDefault("worker.adaptive.redislatencyhigh", 250*time.Millisecond)
+	viper.SetDefault("worker.adaptive.redislatencylow", 50*time.Millisecond)
+	viper.SetDefault("worker.tenants", []string{})
 
 	// Queue defaults
 	viper.SetDefault("queue.streamprefix", "tasks")
@@ -142,15 +379,59 @@ func setDefaults() {
 	viper.SetDefault("queue.retryjitterfactor", 0.1)
 	viper.SetDefault("queue.taskretentiondays", 7)
 	viper.SetDefault("queue.ratelimitrps", 1000)
+	viper.SetDefault("queue.redriverate", 10.0)
+	viper.SetDefault("queue.tenants", []TenantConfig{})
+
+	// DLQ defaults
+	viper.SetDefault("dlq.maxage", 0)
+	viper.SetDefault("dlq.maxentries", 0)
+	viper.SetDefault("dlq.janitorinterval", 1*time.Minute)
+	viper.SetDefault("dlq.archivedir", "")
+	viper.SetDefault("dlq.archivegzip", true)
+
+	// Scheduler defaults
+	viper.SetDefault("scheduler.embedded", true)
+	viper.SetDefault("scheduler.leasettl", 5*time.Second)
+	viper.SetDefault("scheduler.healthport", 8082)
 
 	// Metrics defaults
 	viper.SetDefault("metrics.enabled", true)
 	viper.SetDefault("metrics.path", "/metrics")
 
+	// Rate limit defaults
+	viper.SetDefault("ratelimit.routes", []RouteLimitConfig{})
+
+	// Audit defaults
+	viper.SetDefault("audit.enabled", true)
+	viper.SetDefault("audit.maxage", 90*24*time.Hour)
+	viper.SetDefault("audit.maxentries", 1000000)
+	viper.SetDefault("audit.file", "")
+
 	// Auth defaults
 	viper.SetDefault("auth.enabled", false)
 	viper.SetDefault("auth.jwtsecret", "")
-	viper.SetDefault("auth.apikeys", []string{})
+	viper.SetDefault("auth.jwt.algorithms", []string{"HS256"})
+	viper.SetDefault("auth.jwt.jwksurl", "")
+	viper.SetDefault("auth.jwt.jwksfile", "")
+	viper.SetDefault("auth.jwt.jwksrefresh", "5m")
+	viper.SetDefault("auth.jwt.issuer", "")
+	viper.SetDefault("auth.jwt.audience", "")
+	viper.SetDefault("auth.jwt.clockskew", "30s")
+	viper.SetDefault("auth.jwt.rolesclaim", "")
+	viper.SetDefault("auth.jwt.tenantclaim", "tenant")
+	viper.SetDefault("auth.apikeys", []APIKeyConfig{})
+	viper.SetDefault("auth.clientcerts", []ClientCertConfig{})
+
+	// Encryption defaults
+	viper.SetDefault("encryption.enabled", false)
+	viper.SetDefault("encryption.keyringfile", "")
+	viper.SetDefault("encryption.tasktypes", []string{})
+	viper.SetDefault("encryption.readerroles", []string{"submitter"})
+
+	// Redaction defaults
+	viper.SetDefault("redaction.fields", []string{"password", "card_number"})
+	viper.SetDefault("redaction.rules", []RedactionRule{})
+	viper.SetDefault("redaction.revealroles", []string{"operator"})
 
 	// Logging defaults
 	viper.SetDefault("loglevel", "info")
@@ -23,6 +23,12 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, "0.0.0.0", cfg.Server.Host)
 	assert.Equal(t, 8080, cfg.Server.Port)
 	assert.Equal(t, 8081, cfg.Server.AdminPort)
+	assert.Equal(t, "", cfg.Server.AdminHost)
+	assert.False(t, cfg.Server.TLS.Enabled())
+	assert.Equal(t, "1.2", cfg.Server.TLS.MinVersion)
+	assert.Equal(t, time.Minute, cfg.Server.TLS.ReloadInterval)
+	assert.False(t, cfg.Server.AdminTLS.Enabled())
+	assert.Nil(t, cfg.AdminAuth)
 	assert.Equal(t, 30*time.Second, cfg.Server.ReadTimeout)
 	assert.Equal(t, 30*time.Second, cfg.Server.WriteTimeout)
 	assert.Equal(t, 120*time.Second, cfg.Server.IdleTimeout)
@@ -34,6 +40,7 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, 100, cfg.Redis.PoolSize)
 	assert.Equal(t, 10, cfg.Redis.MinIdleConns)
 	assert.Equal(t, 3, cfg.Redis.MaxRetries)
+	assert.False(t, cfg.Redis.TLS.Enabled)
 
 	// Worker defaults
 	assert.Equal(t, "", cfg.Worker.ID)
@@ -41,6 +48,11 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, 5*time.Second, cfg.Worker.HeartbeatInterval)
 	assert.Equal(t, 15*time.Second, cfg.Worker.HeartbeatTimeout)
 	assert.Equal(t, 30*time.Second, cfg.Worker.ShutdownTimeout)
+	assert.False(t, cfg.Worker.Adaptive.Enabled)
+	assert.Equal(t, 1, cfg.Worker.Adaptive.MinConcurrency)
+	assert.Equal(t, 5*time.Second, cfg.Worker.Adaptive.SampleInterval)
+	assert.Equal(t, 85.0, cfg.Worker.Adaptive.CPUHighPercent)
+	assert.Equal(t, 250*time.Millisecond, cfg.Worker.Adaptive.RedisLatencyHigh)
 
 	// Queue defaults
 	assert.Equal(t, "tasks", cfg.Queue.StreamPrefix)
@@ -49,6 +61,19 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, 3, cfg.Queue.RetryMaxAttempts)
 	assert.Equal(t, 2.0, cfg.Queue.RetryBackoffFactor)
 	assert.Equal(t, 0.1, cfg.Queue.RetryJitterFactor)
+	assert.Equal(t, 10.0, cfg.Queue.RedriveRate)
+
+	// DLQ defaults
+	assert.Equal(t, time.Duration(0), cfg.DLQ.MaxAge)
+	assert.Equal(t, int64(0), cfg.DLQ.MaxEntries)
+	assert.Equal(t, time.Minute, cfg.DLQ.JanitorInterval)
+	assert.Equal(t, "", cfg.DLQ.ArchiveDir)
+	assert.True(t, cfg.DLQ.ArchiveGzip)
+
+	// Scheduler defaults
+	assert.True(t, cfg.Scheduler.Embedded)
+	assert.Equal(t, 5*time.Second, cfg.Scheduler.LeaseTTL)
+	assert.Equal(t, 8082, cfg.Scheduler.HealthPort)
 
 	// Metrics defaults
 	assert.True(t, cfg.Metrics.Enabled)
@@ -57,6 +82,16 @@ func TestLoad_Defaults(t *testing.T) {
 	// Auth defaults
 	assert.False(t, cfg.Auth.Enabled)
 
+	// Encryption defaults
+	assert.False(t, cfg.Encryption.Enabled)
+	assert.Empty(t, cfg.Encryption.TaskTypes)
+	assert.Equal(t, []string{"submitter"}, cfg.Encryption.ReaderRoles)
+
+	// Redaction defaults
+	assert.Equal(t, []string{"password", "card_number"}, cfg.Redaction.Fields)
+	assert.Empty(t, cfg.Redaction.Rules)
+	assert.Equal(t, []string{"operator"}, cfg.Redaction.RevealRoles)
+
 	// Logging defaults
 	assert.Equal(t, "info", cfg.LogLevel)
 }
@@ -85,6 +120,13 @@ redis:
 worker:
   id: "test-worker"
   concurrency: 5
+  processes:
+    - tasktype: "thumbnail"
+      command: "/usr/bin/python3"
+      args: ["thumb.py", "--fast"]
+      permanentexitcodes: [65]
+      limits:
+        memorymb: 256
 
 loglevel: "warn"
 `
@@ -106,6 +148,11 @@ loglevel: "warn"
 	assert.Equal(t, 1, cfg.Redis.DB)
 	assert.Equal(t, "test-worker", cfg.Worker.ID)
 	assert.Equal(t, 5, cfg.Worker.Concurrency)
+	require.Len(t, cfg.Worker.Processes, 1)
+	assert.Equal(t, "thumbnail", cfg.Worker.Processes[0].TaskType)
+	assert.Equal(t, []string{"thumb.py", "--fast"}, cfg.Worker.Processes[0].Args)
+	assert.Equal(t, []int{65}, cfg.Worker.Processes[0].PermanentExitCodes)
+	assert.Equal(t, 256, cfg.Worker.Processes[0].Limits.MemoryMB)
 	assert.Equal(t, "warn", cfg.LogLevel)
 }
 
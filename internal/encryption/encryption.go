@@ -0,0 +1,263 @@
+// Package encryption encrypts task payloads and results at rest with AES-GCM.
+// Each task gets a random data key; data keys are encrypted with a key from a
+// local keyring file, so keys can be rotated by adding a new primary key while
+// older keys stay available for decryption.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// keySize is the size of keyring and data keys (AES-256)
+const keySize = 32
+
+// Errors returned when opening sealed tasks
+var (
+	ErrNoKeyring  = errors.New("task is encrypted and no keyring is configured")
+	ErrUnknownKey = errors.New("task is encrypted with a key not in the keyring")
+	ErrDecrypt    = errors.New("failed to decrypt task")
+)
+
+// Keyring holds the keys that encrypt data keys. New data keys are encrypted
+// with the primary key.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// keyringFile is the on-disk keyring format
+type keyringFile struct {
+	Primary string `json:"primary"`
+	Keys    []struct {
+		ID  string `json:"id"`
+		Key string `json:"key"` // Base64, 32 bytes
+	} `json:"keys"`
+}
+
+// LoadKeyring reads a keyring file
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	return ParseKeyring(data)
+}
+
+// ParseKeyring parses a JSON keyring:
+//
+//	{"primary": "k2", "keys": [{"id": "k1", "key": "<base64>"}, {"id": "k2", "key": "<base64>"}]}
+func ParseKeyring(data []byte) (*Keyring, error) {
+	var f keyringFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid keyring: %w", err)
+	}
+
+	k := &Keyring{primary: f.Primary, keys: make(map[string]cipher.AEAD, len(f.Keys))}
+	for _, entry := range f.Keys {
+		if entry.ID == "" {
+			return nil, errors.New("keyring key has no id")
+		}
+		if _, exists := k.keys[entry.ID]; exists {
+			return nil, fmt.Errorf("keyring key %q is listed more than once", entry.ID)
+		}
+		raw, err := base64.StdEncoding.DecodeString(entry.Key)
+		if err != nil || len(raw) != keySize {
+			return nil, fmt.Errorf("keyring key %q must be %d base64-encoded bytes", entry.ID, keySize)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[entry.ID] = aead
+	}
+
+	if _, ok := k.keys[k.primary]; !ok {
+		return nil, fmt.Errorf("keyring primary key %q not found", k.primary)
+	}
+	return k, nil
+}
+
+// Primary returns the ID of the key new data keys are encrypted with
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// Sealer encrypts the payloads and results of the configured task types. A
+// nil Sealer encrypts nothing.
+type Sealer struct {
+	keyring *Keyring
+	types   map[string]bool // nil = all types
+}
+
+// NewSealer creates a sealer from the config, or returns nil if encryption is
+// disabled
+func NewSealer(cfg *config.EncryptionConfig) (*Sealer, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.KeyringFile == "" {
+		return nil, errors.New("encryption requires a keyring file")
+	}
+	keyring, err := LoadKeyring(cfg.KeyringFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewSealerWithKeyring(keyring, cfg.TaskTypes), nil
+}
+
+// NewSealerWithKeyring creates a sealer for the given task types (empty = all)
+func NewSealerWithKeyring(keyring *Keyring, taskTypes []string) *Sealer {
+	s := &Sealer{keyring: keyring}
+	if len(taskTypes) > 0 {
+		s.types = make(map[string]bool, len(taskTypes))
+		for _, t := range taskTypes {
+			s.types[t] = true
+		}
+	}
+	return s
+}
+
+// Covers reports whether tasks of a type are encrypted
+func (s *Sealer) Covers(taskType string) bool {
+	return s != nil && (s.types == nil || s.types[taskType])
+}
+
+// Seal returns a copy of t for storage with its payload and result encrypted
+// under a new data key, and marks t Encrypted. Tasks of other types, and tasks
+// still sealed because they could not be opened, are returned as they are.
+func (s *Sealer) Seal(t *task.Task) (*task.Task, error) {
+	if !s.Covers(t.Type) || t.Sealed != nil {
+		return t, nil
+	}
+
+	dataKey := make([]byte, keySize)
+	_, _ = rand.Read(dataKey)
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := s.keyring.primary
+	sealed := &task.Sealed{
+		KeyID:   primary,
+		DataKey: seal(s.keyring.keys[primary], dataKey, additionalData(t.ID, "key:"+primary)),
+	}
+	if sealed.Payload, err = sealJSON(aead, t.Payload, additionalData(t.ID, "payload")); err != nil {
+		return nil, err
+	}
+	if t.Result != nil {
+		if sealed.Result, err = sealJSON(aead, t.Result, additionalData(t.ID, "result")); err != nil {
+			return nil, err
+		}
+	}
+
+	t.Encrypted = true
+	out := *t
+	out.Payload = nil
+	out.Result = nil
+	out.Sealed = sealed
+	return &out, nil
+}
+
+// Open decrypts a sealed task in place and marks it Encrypted. On error the
+// task is left sealed.
+func (s *Sealer) Open(t *task.Task) error {
+	sealed := t.Sealed
+	if sealed == nil {
+		return nil
+	}
+	if s == nil {
+		return ErrNoKeyring
+	}
+
+	kek, ok := s.keyring.keys[sealed.KeyID]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKey, sealed.KeyID)
+	}
+	dataKey, err := open(kek, sealed.DataKey, additionalData(t.ID, "key:"+sealed.KeyID))
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	var payload, result map[string]interface{}
+	if err := openJSON(aead, sealed.Payload, additionalData(t.ID, "payload"), &payload); err != nil {
+		return err
+	}
+	if sealed.Result != nil {
+		if err := openJSON(aead, sealed.Result, additionalData(t.ID, "result"), &result); err != nil {
+			return err
+		}
+	}
+
+	t.Payload = payload
+	t.Result = result
+	t.Sealed = nil
+	t.Encrypted = true
+	return nil
+}
+
+// additionalData binds a ciphertext to its task and purpose, so ciphertexts
+// cannot be swapped between tasks or fields
+func additionalData(taskID, purpose string) []byte {
+	return []byte(taskID + "\x00" + purpose)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce, prepended to the ciphertext
+func seal(aead cipher.AEAD, plaintext, ad []byte) []byte {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	_, _ = rand.Read(nonce) // Never returns an error since Go 1.24
+	return aead.Seal(nonce, nonce, plaintext, ad)
+}
+
+func open(aead cipher.AEAD, ciphertext, ad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, body := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, body, ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func sealJSON(aead cipher.AEAD, v map[string]interface{}, ad []byte) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task data: %w", err)
+	}
+	return seal(aead, data, ad), nil
+}
+
+func openJSON(aead cipher.AEAD, ciphertext, ad []byte, v *map[string]interface{}) error {
+	data, err := open(aead, ciphertext, ad)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	return nil
+}
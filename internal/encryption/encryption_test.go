@@ -0,0 +1,164 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func testKeyring(t *testing.T, primary string, ids ...string) *Keyring {
+	t.Helper()
+	keys := ""
+	for i, id := range ids {
+		if i > 0 {
+			keys += ","
+		}
+		keys += fmt.Sprintf(`{"id": %q, "key": %q}`, id, testKey(byte(i+1)))
+	}
+	k, err := ParseKeyring([]byte(fmt.Sprintf(`{"primary": %q, "keys": [%s]}`, primary, keys)))
+	require.NoError(t, err)
+	return k
+}
+
+func testTask() *task.Task {
+	tk := task.New("email", map[string]interface{}{"to": "a@example.com", "n": float64(3)}, task.PriorityNormal)
+	tk.Result = map[string]interface{}{"sent": true}
+	return tk
+}
+
+func TestSealer_RoundTrip(t *testing.T) {
+	s := NewSealerWithKeyring(testKeyring(t, "k1", "k1"), nil)
+	tk := testTask()
+
+	sealed, err := s.Seal(tk)
+	require.NoError(t, err)
+	require.NotNil(t, sealed.Sealed)
+	assert.Nil(t, sealed.Payload)
+	assert.Nil(t, sealed.Result)
+	assert.Equal(t, "k1", sealed.Sealed.KeyID)
+	assert.NotContains(t, string(sealed.Sealed.Payload), "a@example.com")
+	assert.True(t, tk.Encrypted)
+	assert.NotNil(t, tk.Payload, "Seal must not modify the payload of its input")
+
+	require.NoError(t, s.Open(sealed))
+	assert.Nil(t, sealed.Sealed)
+	assert.True(t, sealed.Encrypted)
+	assert.Equal(t, tk.Payload, sealed.Payload)
+	assert.Equal(t, tk.Result, sealed.Result)
+}
+
+func TestSealer_Rotation(t *testing.T) {
+	old := NewSealerWithKeyring(testKeyring(t, "k1", "k1"), nil)
+	sealed, err := old.Seal(testTask())
+	require.NoError(t, err)
+
+	rotated := NewSealerWithKeyring(testKeyring(t, "k2", "k1", "k2"), nil)
+	resealed, err := rotated.Seal(testTask())
+	require.NoError(t, err)
+	assert.Equal(t, "k2", resealed.Sealed.KeyID)
+
+	// Tasks sealed with a retired primary still open
+	require.NoError(t, rotated.Open(sealed))
+	assert.Equal(t, "a@example.com", sealed.Payload["to"])
+
+	// Keys removed from the keyring no longer open their tasks
+	err = old.Open(resealed)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.NotNil(t, resealed.Sealed)
+}
+
+func TestSealer_Tampering(t *testing.T) {
+	s := NewSealerWithKeyring(testKeyring(t, "k1", "k1"), nil)
+
+	sealed, err := s.Seal(testTask())
+	require.NoError(t, err)
+	sealed.Sealed.Payload[len(sealed.Sealed.Payload)-1] ^= 1
+	assert.ErrorIs(t, s.Open(sealed), ErrDecrypt)
+	assert.NotNil(t, sealed.Sealed, "a task that fails to open stays sealed")
+
+	// Ciphertexts are bound to their task and field
+	a, err := s.Seal(testTask())
+	require.NoError(t, err)
+	b, err := s.Seal(testTask())
+	require.NoError(t, err)
+	a.Sealed = b.Sealed
+	assert.ErrorIs(t, s.Open(a), ErrDecrypt)
+
+	c, err := s.Seal(testTask())
+	require.NoError(t, err)
+	c.Sealed.Payload, c.Sealed.Result = c.Sealed.Result, c.Sealed.Payload
+	assert.ErrorIs(t, s.Open(c), ErrDecrypt)
+}
+
+func TestSealer_TaskTypes(t *testing.T) {
+	s := NewSealerWithKeyring(testKeyring(t, "k1", "k1"), []string{"payment"})
+	assert.True(t, s.Covers("payment"))
+	assert.False(t, s.Covers("email"))
+
+	tk := testTask()
+	out, err := s.Seal(tk)
+	require.NoError(t, err)
+	assert.Same(t, tk, out)
+	assert.Nil(t, out.Sealed)
+	assert.False(t, tk.Encrypted)
+}
+
+func TestSealer_Nil(t *testing.T) {
+	var s *Sealer
+	assert.False(t, s.Covers("email"))
+
+	tk := testTask()
+	out, err := s.Seal(tk)
+	require.NoError(t, err)
+	assert.Same(t, tk, out)
+
+	sealed, err := NewSealerWithKeyring(testKeyring(t, "k1", "k1"), nil).Seal(testTask())
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.Open(sealed), ErrNoKeyring)
+	assert.NoError(t, s.Open(testTask()), "unsealed tasks open without a keyring")
+}
+
+func TestParseKeyring_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"not json":        `nope`,
+		"missing primary": fmt.Sprintf(`{"primary": "k2", "keys": [{"id": "k1", "key": %q}]}`, testKey(1)),
+		"no id":           fmt.Sprintf(`{"primary": "", "keys": [{"key": %q}]}`, testKey(1)),
+		"duplicate id":    fmt.Sprintf(`{"primary": "k1", "keys": [{"id": "k1", "key": %q}, {"id": "k1", "key": %q}]}`, testKey(1), testKey(2)),
+		"short key":       `{"primary": "k1", "keys": [{"id": "k1", "key": "c2hvcnQ="}]}`,
+		"not base64":      `{"primary": "k1", "keys": [{"id": "k1", "key": "!!"}]}`,
+	} {
+		_, err := ParseKeyring([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestNewSealer(t *testing.T) {
+	s, err := NewSealer(&config.EncryptionConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, s)
+
+	_, err = NewSealer(&config.EncryptionConfig{Enabled: true})
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	keyring := fmt.Sprintf(`{"primary": "k1", "keys": [{"id": "k1", "key": %q}]}`, testKey(1))
+	require.NoError(t, os.WriteFile(path, []byte(keyring), 0o600))
+
+	s, err = NewSealer(&config.EncryptionConfig{Enabled: true, KeyringFile: path, TaskTypes: []string{"payment"}})
+	require.NoError(t, err)
+	assert.True(t, s.Covers("payment"))
+	assert.False(t, s.Covers("email"))
+}
@@ -16,6 +16,8 @@ const (
 	EventTaskCompleted EventType = "task.completed"
 	EventTaskFailed    EventType = "task.failed"
 	EventTaskRetrying  EventType = "task.retrying"
+	EventTaskExpired   EventType = "task.expired"
+	EventTaskUpdated   EventType = "task.updated"
 
 	// Worker events
 	EventWorkerJoined  EventType = "worker.joined"
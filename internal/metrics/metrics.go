@@ -23,6 +23,14 @@ var (
 		[]string{"type", "status"},
 	)
 
+	TasksExpired = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "taskqueue_tasks_expired_total",
+			Help: "Total number of tasks discarded because they expired before starting",
+		},
+		[]string{"type", "stage"}, // stage: scheduler or worker
+	)
+
 	TaskDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "taskqueue_task_duration_seconds",
@@ -82,6 +90,55 @@ var (
 		[]string{"worker_id"},
 	)
 
+	// Adaptive concurrency metrics
+	WorkerEffectiveConcurrency = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "taskqueue_worker_effective_concurrency",
+			Help: "Concurrency currently allowed by the adaptive controller",
+		},
+		[]string{"worker_id"},
+	)
+
+	WorkerConsumptionPaused = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "taskqueue_worker_consumption_paused",
+			Help: "1 when the adaptive controller has paused dequeuing, 0 otherwise",
+		},
+		[]string{"worker_id"},
+	)
+
+	WorkerCPUPercent = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "taskqueue_worker_cpu_percent",
+			Help: "Sampled worker process CPU usage (0-100 across all cores)",
+		},
+		[]string{"worker_id"},
+	)
+
+	WorkerMemoryBytes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "taskqueue_worker_memory_rss_bytes",
+			Help: "Sampled worker process resident set size",
+		},
+		[]string{"worker_id"},
+	)
+
+	WorkerRedisLatency = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "taskqueue_worker_redis_latency_seconds",
+			Help: "Sampled Redis round-trip latency seen by the worker",
+		},
+		[]string{"worker_id"},
+	)
+
+	WorkerAdaptiveDecisions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "taskqueue_worker_adaptive_decisions_total",
+			Help: "Total number of adaptive controller decisions by action",
+		},
+		[]string{"worker_id", "action"},
+	)
+
 	// DLQ metrics
 	DLQSize = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -97,6 +154,21 @@ var (
 		},
 	)
 
+	DLQRemoved = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "taskqueue_dlq_removed_total",
+			Help: "Total number of DLQ entries removed by retention",
+		},
+		[]string{"reason"}, // age, size
+	)
+
+	DLQArchived = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "taskqueue_dlq_archived_total",
+			Help: "Total number of DLQ entries written to archive files",
+		},
+	)
+
 	// HTTP metrics
 	HTTPRequestDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -216,6 +288,11 @@ func RecordTaskCompletion(taskType, status string, duration float64) {
 	TaskDuration.WithLabelValues(taskType).Observe(duration)
 }
 
+// RecordTaskExpired records a task discarded past its expiration deadline
+func RecordTaskExpired(taskType, stage string) {
+	TasksExpired.WithLabelValues(taskType, stage).Inc()
+}
+
 // RecordTaskRetry records a task retry (legacy counter kept for compatibility)
 func RecordTaskRetry(taskType string) {
 	TaskRetries.WithLabelValues(taskType).Inc()
@@ -272,6 +349,24 @@ func RecordWorkerBusyTime(workerID string, duration float64) {
 	WorkerBusyTime.WithLabelValues(workerID).Add(duration)
 }
 
+// RecordAdaptiveSample records the resource signals read by the adaptive controller.
+func RecordAdaptiveSample(workerID string, cpuPercent float64, rssBytes int64, redisLatency float64) {
+	WorkerCPUPercent.WithLabelValues(workerID).Set(cpuPercent)
+	WorkerMemoryBytes.WithLabelValues(workerID).Set(float64(rssBytes))
+	WorkerRedisLatency.WithLabelValues(workerID).Set(redisLatency)
+}
+
+// RecordAdaptiveDecision records the adaptive controller's current limit and action.
+func RecordAdaptiveDecision(workerID, action string, limit int, paused bool) {
+	WorkerEffectiveConcurrency.WithLabelValues(workerID).Set(float64(limit))
+	pausedVal := 0.0
+	if paused {
+		pausedVal = 1
+	}
+	WorkerConsumptionPaused.WithLabelValues(workerID).Set(pausedVal)
+	WorkerAdaptiveDecisions.WithLabelValues(workerID, action).Inc()
+}
+
 // SetDLQSize sets the DLQ size gauge
 func SetDLQSize(size float64) {
 	DLQSize.Set(size)
@@ -282,6 +377,12 @@ func IncrementDLQAdded() {
 	DLQAdded.Inc()
 }
 
+// RecordDLQRetention records entries removed by the DLQ janitor
+func RecordDLQRetention(reason string, removed, archived int) {
+	DLQRemoved.WithLabelValues(reason).Add(float64(removed))
+	DLQArchived.Add(float64(archived))
+}
+
 // RecordHTTPRequest records an HTTP request
 func RecordHTTPRequest(method, path, status string, duration float64) {
 	HTTPRequestDuration.WithLabelValues(method, path, status).Observe(duration)
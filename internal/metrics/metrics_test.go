@@ -16,6 +16,7 @@ func TestMetricsRegistration(t *testing.T) {
 	assert.NotNil(t, TasksCompleted)
 	assert.NotNil(t, TaskDuration)
 	assert.NotNil(t, TaskRetries)
+	assert.NotNil(t, TasksExpired)
 
 	// Queue metrics
 	assert.NotNil(t, QueueDepth)
@@ -29,6 +30,8 @@ func TestMetricsRegistration(t *testing.T) {
 	// DLQ metrics
 	assert.NotNil(t, DLQSize)
 	assert.NotNil(t, DLQAdded)
+	assert.NotNil(t, DLQRemoved)
+	assert.NotNil(t, DLQArchived)
 
 	// HTTP metrics
 	assert.NotNil(t, HTTPRequestDuration)
@@ -74,6 +77,15 @@ func TestRecordTaskRetry(t *testing.T) {
 	// Just ensure no panic
 }
 
+func TestRecordTaskExpired(t *testing.T) {
+	TasksExpired.Reset()
+
+	RecordTaskExpired("push", "worker")
+	RecordTaskExpired("push", "scheduler")
+
+	// Just ensure no panic
+}
+
 func TestUpdateQueueDepth(t *testing.T) {
 	QueueDepth.Reset()
 
@@ -110,6 +122,16 @@ func TestRecordWorkerBusyTime(t *testing.T) {
 	// Just ensure no panic
 }
 
+func TestRecordAdaptiveDecision(t *testing.T) {
+	WorkerAdaptiveDecisions.Reset()
+
+	RecordAdaptiveSample("worker-1", 42.5, 64<<20, 0.002)
+	RecordAdaptiveDecision("worker-1", "throttle", 6, false)
+	RecordAdaptiveDecision("worker-1", "pause", 6, true)
+
+	// Just ensure no panic
+}
+
 func TestSetDLQSize(t *testing.T) {
 	SetDLQSize(0)
 	SetDLQSize(10)
@@ -130,6 +152,13 @@ func TestIncrementDLQAdded(t *testing.T) {
 	// Just ensure no panic
 }
 
+func TestRecordDLQRetention(t *testing.T) {
+	RecordDLQRetention("age", 10, 10)
+	RecordDLQRetention("size", 3, 0)
+
+	// Just ensure no panic
+}
+
 func TestRecordHTTPRequest(t *testing.T) {
 	HTTPRequestDuration.Reset()
 	HTTPRequestsTotal.Reset()
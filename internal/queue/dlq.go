@@ -4,21 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
+	"github.com/maumercado/task-queue-go/internal/encryption"
 	"github.com/maumercado/task-queue-go/internal/task"
 )
 
 const (
 	dlqStreamName = "tasks:dlq"
 	dlqSetName    = "tasks:dlq:set"
+	dlqIndexName  = "tasks:dlq:index" // hash: task ID -> stream message ID
+
+	// dlqScanBatch is how many stream entries a filtered page reads per round trip
+	dlqScanBatch = 200
+	// dlqMaxScan bounds the entries examined for one page so selective filters
+	// cannot turn a request into a full stream scan; the cursor resumes from there
+	dlqMaxScan = 10000
 )
 
 // DLQ represents a Dead Letter Queue for failed tasks
 type DLQ struct {
 	client *redis.Client
+	tenant string             // Keyspace read and cleared by this view ("" = default)
+	sealer *encryption.Sealer // Encrypts task payloads and results at rest; nil = disabled
 }
 
 // NewDLQ creates a new Dead Letter Queue
@@ -26,6 +37,30 @@ func NewDLQ(client *redis.Client) *DLQ {
 	return &DLQ{client: client}
 }
 
+// ForTenant returns a view of the DLQ that reads the tenant's entries. Add and
+// Restore always write to the task's own tenant.
+func (d *DLQ) ForTenant(tenant string) *DLQ {
+	return &DLQ{client: d.client, tenant: tenant, sealer: d.sealer}
+}
+
+// SetSealer enables encryption of task payloads and results in DLQ entries.
+// Entries are decrypted by List, ListPage and Get; archives keep them sealed.
+func (d *DLQ) SetSealer(s *encryption.Sealer) {
+	d.sealer = s
+}
+
+func (d *DLQ) streamKey() string {
+	return tenantKey(d.tenant, dlqStreamName)
+}
+
+func (d *DLQ) setKey() string {
+	return tenantKey(d.tenant, dlqSetName)
+}
+
+func (d *DLQ) indexKey() string {
+	return tenantKey(d.tenant, dlqIndexName)
+}
+
 // Add moves a task to the dead letter queue
 func (d *DLQ) Add(ctx context.Context, t *task.Task, reason string) error {
 	// Update task state
@@ -36,27 +71,59 @@ func (d *DLQ) Add(ctx context.Context, t *task.Task, reason string) error {
 		t.UpdatedAt = time.Now().UTC()
 	}
 
-	// Store additional DLQ metadata
-	dlqEntry := struct {
-		Task      *task.Task `json:"task"`
-		Reason    string     `json:"reason"`
-		AddedAt   time.Time  `json:"added_at"`
-		OrigError string     `json:"original_error"`
-	}{
+	return d.addEntry(ctx, &DLQEntry{
 		Task:      t,
 		Reason:    reason,
 		AddedAt:   time.Now().UTC(),
 		OrigError: t.Error,
+	})
+}
+
+// Restore re-adds an archived entry, keeping its original reason and added-at time.
+// Entries whose task is already in the DLQ are skipped and reported as not restored.
+func (d *DLQ) Restore(ctx context.Context, entry *DLQEntry) (bool, error) {
+	if entry.Task == nil || entry.Task.ID == "" {
+		return false, task.ErrInvalidTaskData
+	}
+
+	d = d.ForTenant(entry.Task.Tenant)
+	exists, err := d.Contains(ctx, entry.Task.ID)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	entry.Task.State = task.StateDeadLetter
+	if err := d.addEntry(ctx, entry); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// addEntry appends an entry to its task's tenant stream and indexes it
+func (d *DLQ) addEntry(ctx context.Context, entry *DLQEntry) error {
+	t := entry.Task
+	d = d.ForTenant(t.Tenant)
+
+	// MessageID is assigned by the stream and never stored in the payload
+	stored := *entry
+	stored.MessageID = ""
+	sealed, err := d.sealer.Seal(t)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt DLQ entry: %w", err)
 	}
+	stored.Task = sealed
 
-	data, err := json.Marshal(dlqEntry)
+	data, err := json.Marshal(stored)
 	if err != nil {
 		return fmt.Errorf("failed to marshal DLQ entry: %w", err)
 	}
 
 	// Add to DLQ stream
-	_, err = d.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: dlqStreamName,
+	messageID, err := d.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: d.streamKey(),
 		Values: map[string]interface{}{
 			"task_id": t.ID,
 			"type":    t.Type,
@@ -68,8 +135,13 @@ func (d *DLQ) Add(ctx context.Context, t *task.Task, reason string) error {
 		return fmt.Errorf("failed to add to DLQ stream: %w", err)
 	}
 
-	// Add to set for quick lookups
-	d.client.SAdd(ctx, dlqSetName, t.ID)
+	// Add to set and index for O(1) lookups
+	pipe := d.client.TxPipeline()
+	pipe.SAdd(ctx, d.setKey(), t.ID)
+	pipe.HSet(ctx, d.indexKey(), t.ID, messageID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to index DLQ entry: %w", err)
+	}
 
 	return nil
 }
@@ -80,7 +152,7 @@ type DLQEntry struct {
 	Reason    string     `json:"reason"`
 	AddedAt   time.Time  `json:"added_at"`
 	OrigError string     `json:"original_error"`
-	MessageID string     `json:"message_id"`
+	MessageID string     `json:"message_id,omitempty"`
 }
 
 // List returns tasks in the dead letter queue
@@ -89,69 +161,231 @@ func (d *DLQ) List(ctx context.Context, count int64, offset string) ([]DLQEntry,
 		offset = "-"
 	}
 
-	messages, err := d.client.XRange(ctx, dlqStreamName, offset, "+").Result()
+	var messages []redis.XMessage
+	var err error
+	if count > 0 {
+		messages, err = d.client.XRangeN(ctx, d.streamKey(), offset, "+", count).Result()
+	} else {
+		messages, err = d.client.XRange(ctx, d.streamKey(), offset, "+").Result()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read DLQ: %w", err)
 	}
 
 	entries := make([]DLQEntry, 0, len(messages))
-	for i, msg := range messages {
-		if int64(i) >= count && count > 0 {
-			break
-		}
-
-		data, ok := msg.Values["data"].(string)
+	for _, msg := range messages {
+		entry, ok := parseDLQMessage(msg)
 		if !ok {
 			continue
 		}
+		openTask(d.sealer, entry.Task)
+		entries = append(entries, *entry)
+	}
 
-		var entry DLQEntry
-		if err := json.Unmarshal([]byte(data), &entry); err != nil {
-			continue
+	return entries, nil
+}
+
+// DLQFilter selects DLQ entries. Zero-valued fields match everything.
+type DLQFilter struct {
+	TaskType      string    `json:"type,omitempty"`
+	Reason        string    `json:"reason,omitempty"` // Case-insensitive exact match
+	ErrorContains string    `json:"error,omitempty"`  // Case-insensitive substring of the task error
+	AddedAfter    time.Time `json:"added_after,omitempty"`
+	AddedBefore   time.Time `json:"added_before,omitempty"`
+}
+
+// Matches reports whether an entry satisfies every set field of the filter
+func (f DLQFilter) Matches(e *DLQEntry) bool {
+	if e.Task == nil {
+		return false
+	}
+	if f.TaskType != "" && e.Task.Type != f.TaskType {
+		return false
+	}
+	if f.Reason != "" && !strings.EqualFold(e.Reason, f.Reason) {
+		return false
+	}
+	if f.ErrorContains != "" {
+		needle := strings.ToLower(f.ErrorContains)
+		if !strings.Contains(strings.ToLower(e.OrigError), needle) &&
+			!strings.Contains(strings.ToLower(e.Task.Error), needle) {
+			return false
 		}
-		entry.MessageID = msg.ID
+	}
+	if !f.AddedAfter.IsZero() && e.AddedAt.Before(f.AddedAfter) {
+		return false
+	}
+	if !f.AddedBefore.IsZero() && !e.AddedAt.Before(f.AddedBefore) {
+		return false
+	}
+	return true
+}
 
-		entries = append(entries, entry)
+// DLQPage is one page of filtered DLQ entries
+type DLQPage struct {
+	Entries []DLQEntry `json:"entries"`
+	// NextCursor resumes after the last examined entry; empty when the stream is exhausted
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListPage returns up to limit entries matching filter, oldest first, starting
+// after cursor (a message ID from a previous page's NextCursor).
+// Added-at bounds are checked on each entry rather than used to narrow the scan:
+// restored entries keep their original AddedAt but get a new stream ID.
+func (d *DLQ) ListPage(ctx context.Context, filter DLQFilter, cursor string, limit int64) (*DLQPage, error) {
+	if limit <= 0 {
+		limit = 100
 	}
 
-	return entries, nil
+	start := "-"
+	if cursor != "" {
+		start = "(" + cursor
+	}
+	page := &DLQPage{Entries: make([]DLQEntry, 0, limit)}
+
+	scanned := 0
+	for {
+		messages, err := d.client.XRangeN(ctx, d.streamKey(), start, "+", dlqScanBatch).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DLQ: %w", err)
+		}
+
+		for _, msg := range messages {
+			scanned++
+			if entry, ok := parseDLQMessage(msg); ok && filter.Matches(entry) {
+				openTask(d.sealer, entry.Task)
+				page.Entries = append(page.Entries, *entry)
+			}
+			if int64(len(page.Entries)) >= limit || scanned >= dlqMaxScan {
+				page.NextCursor = msg.ID
+				return page, nil
+			}
+		}
+
+		if len(messages) < dlqScanBatch {
+			return page, nil
+		}
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// Get returns the DLQ entry for a task using the task ID index
+func (d *DLQ) Get(ctx context.Context, taskID string) (*DLQEntry, error) {
+	messageID, err := d.client.HGet(ctx, d.indexKey(), taskID).Result()
+	if err == redis.Nil {
+		return nil, task.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ index: %w", err)
+	}
+
+	messages, err := d.client.XRange(ctx, d.streamKey(), messageID, messageID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ entry: %w", err)
+	}
+	if len(messages) == 0 {
+		// Stale index entry; the stream entry was trimmed or deleted
+		d.client.HDel(ctx, d.indexKey(), taskID)
+		return nil, task.ErrTaskNotFound
+	}
+
+	entry, ok := parseDLQMessage(messages[0])
+	if !ok {
+		return nil, task.ErrInvalidTaskData
+	}
+	openTask(d.sealer, entry.Task)
+	return entry, nil
+}
+
+// Reindex rebuilds the task ID index from the stream if it is missing,
+// which is the case for entries written before the index existed
+func (d *DLQ) Reindex(ctx context.Context) (int, error) {
+	indexed, err := d.client.HLen(ctx, d.indexKey()).Result()
+	if err != nil {
+		return 0, err
+	}
+	if indexed > 0 {
+		return 0, nil
+	}
+
+	count := 0
+	start := "-"
+	for {
+		messages, err := d.client.XRangeN(ctx, d.streamKey(), start, "+", dlqScanBatch).Result()
+		if err != nil {
+			return count, fmt.Errorf("failed to read DLQ: %w", err)
+		}
+		if len(messages) == 0 {
+			return count, nil
+		}
+
+		pipe := d.client.Pipeline()
+		for _, msg := range messages {
+			if taskID, ok := msg.Values["task_id"].(string); ok {
+				pipe.HSet(ctx, d.indexKey(), taskID, msg.ID)
+				count++
+			}
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return count, fmt.Errorf("failed to write DLQ index: %w", err)
+		}
+
+		if len(messages) < dlqScanBatch {
+			return count, nil
+		}
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// parseDLQMessage decodes a DLQ stream message into an entry
+func parseDLQMessage(msg redis.XMessage) (*DLQEntry, bool) {
+	data, ok := msg.Values["data"].(string)
+	if !ok {
+		return nil, false
+	}
+
+	var entry DLQEntry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil || entry.Task == nil {
+		return nil, false
+	}
+	entry.MessageID = msg.ID
+	return &entry, true
 }
 
-// Remove removes a task from the dead letter queue
+// Remove removes a task from the dead letter queue.
+// If messageID is empty it is looked up in the task ID index.
 func (d *DLQ) Remove(ctx context.Context, taskID string, messageID string) error {
+	if messageID == "" {
+		id, err := d.client.HGet(ctx, d.indexKey(), taskID).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to read DLQ index: %w", err)
+		}
+		messageID = id
+	}
+
 	// Remove from stream
 	if messageID != "" {
-		if err := d.client.XDel(ctx, dlqStreamName, messageID).Err(); err != nil {
+		if err := d.client.XDel(ctx, d.streamKey(), messageID).Err(); err != nil {
 			return fmt.Errorf("failed to remove from DLQ stream: %w", err)
 		}
 	}
 
-	// Remove from set
-	d.client.SRem(ctx, dlqSetName, taskID)
+	// Remove from set and index
+	pipe := d.client.TxPipeline()
+	pipe.SRem(ctx, d.setKey(), taskID)
+	pipe.HDel(ctx, d.indexKey(), taskID)
+	_, err := pipe.Exec(ctx)
 
-	return nil
+	return err
 }
 
 // Retry moves a task from DLQ back to the main queue
 func (d *DLQ) Retry(ctx context.Context, q *RedisQueue, taskID string, messageID string) error {
-	// Find the DLQ entry
-	entries, err := d.List(ctx, 0, "")
+	targetEntry, err := d.Get(ctx, taskID)
 	if err != nil {
 		return err
 	}
 
-	var targetEntry *DLQEntry
-	for _, entry := range entries {
-		if entry.Task.ID == taskID {
-			targetEntry = &entry
-			break
-		}
-	}
-
-	if targetEntry == nil {
-		return task.ErrTaskNotFound
-	}
-
 	// Reset task for reprocessing
 	sm := task.NewStateMachine(targetEntry.Task)
 	if err := sm.Requeue(); err != nil {
@@ -187,20 +421,20 @@ func (d *DLQ) RetryAll(ctx context.Context, q *RedisQueue) (int, error) {
 
 // Size returns the number of tasks in the DLQ
 func (d *DLQ) Size(ctx context.Context) (int64, error) {
-	return d.client.SCard(ctx, dlqSetName).Result()
+	return d.client.SCard(ctx, d.setKey()).Result()
 }
 
 // Contains checks if a task is in the DLQ
 func (d *DLQ) Contains(ctx context.Context, taskID string) (bool, error) {
-	return d.client.SIsMember(ctx, dlqSetName, taskID).Result()
+	return d.client.SIsMember(ctx, d.setKey(), taskID).Result()
 }
 
 // Clear removes all tasks from the DLQ
 func (d *DLQ) Clear(ctx context.Context) error {
-	// Delete stream and set
-	if err := d.client.Del(ctx, dlqStreamName).Err(); err != nil {
+	// Delete stream, set and index
+	if err := d.client.Del(ctx, d.streamKey()).Err(); err != nil {
 		return fmt.Errorf("failed to delete DLQ stream: %w", err)
 	}
 
-	return d.client.Del(ctx, dlqSetName).Err()
+	return d.client.Del(ctx, d.setKey(), d.indexKey()).Err()
 }
@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// dlqArchiveTimeFormat names archive files so they sort chronologically
+const dlqArchiveTimeFormat = "20060102T150405.000Z"
+
+// DLQArchiver writes DLQ entries removed by the janitor to newline-delimited
+// JSON files, one file per sweep, optionally gzip-compressed
+type DLQArchiver struct {
+	dir  string
+	gzip bool
+}
+
+// NewDLQArchiver creates an archiver writing into dir
+func NewDLQArchiver(dir string, gzip bool) *DLQArchiver {
+	return &DLQArchiver{dir: dir, gzip: gzip}
+}
+
+// fileName returns the archive file name for a sweep started at t
+func (a *DLQArchiver) fileName(t time.Time) string {
+	name := "dlq-" + t.UTC().Format(dlqArchiveTimeFormat) + ".ndjson"
+	if a.gzip {
+		name += ".gz"
+	}
+	return name
+}
+
+// open creates a new archive file; it never overwrites an existing one
+func (a *DLQArchiver) open(t time.Time) (*dlqArchiveFile, error) {
+	if err := os.MkdirAll(a.dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create DLQ archive dir: %w", err)
+	}
+
+	path := filepath.Join(a.dir, a.fileName(t))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DLQ archive file: %w", err)
+	}
+
+	af := &dlqArchiveFile{path: path, f: f}
+	var w io.Writer = f
+	if a.gzip {
+		af.gz = gzip.NewWriter(f)
+		w = af.gz
+	}
+	af.w = bufio.NewWriter(w)
+	return af, nil
+}
+
+// dlqArchiveFile is an open archive file
+type dlqArchiveFile struct {
+	path  string
+	f     *os.File
+	gz    *gzip.Writer
+	w     *bufio.Writer
+	count int
+}
+
+// write appends entries and syncs them to disk, so a batch is durable
+// before it is deleted from Redis
+func (af *dlqArchiveFile) write(entries []*DLQEntry) error {
+	enc := json.NewEncoder(af.w)
+	for _, e := range entries {
+		stored := *e
+		stored.MessageID = ""
+		if err := enc.Encode(&stored); err != nil {
+			return fmt.Errorf("failed to write DLQ archive: %w", err)
+		}
+	}
+
+	if err := af.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write DLQ archive: %w", err)
+	}
+	if af.gz != nil {
+		if err := af.gz.Flush(); err != nil {
+			return fmt.Errorf("failed to write DLQ archive: %w", err)
+		}
+	}
+	if err := af.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync DLQ archive: %w", err)
+	}
+
+	af.count += len(entries)
+	return nil
+}
+
+// Close finishes the gzip stream and closes the file
+func (af *dlqArchiveFile) Close() error {
+	if af.gz != nil {
+		if err := af.gz.Close(); err != nil {
+			af.f.Close()
+			return fmt.Errorf("failed to close DLQ archive: %w", err)
+		}
+	}
+	return af.f.Close()
+}
+
+// DLQImportResult summarizes an archive import
+type DLQImportResult struct {
+	Restored  int  `json:"restored"`
+	Skipped   int  `json:"skipped"`   // Already in the DLQ
+	Invalid   int  `json:"invalid"`   // Lines that could not be decoded
+	Truncated bool `json:"truncated"` // The archive ended mid-write
+}
+
+// ImportArchive restores entries from an NDJSON archive (plain or gzip) into the DLQ.
+// Tasks already present in the DLQ are skipped, so importing a file twice is safe.
+func ImportArchive(ctx context.Context, dlq *DLQ, r io.Reader) (DLQImportResult, error) {
+	var result DLQImportResult
+
+	truncated, invalid, err := readDLQArchive(r, func(entry *DLQEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		restored, err := dlq.Restore(ctx, entry)
+		if err != nil {
+			return err
+		}
+		if restored {
+			result.Restored++
+		} else {
+			result.Skipped++
+		}
+		return nil
+	})
+
+	result.Truncated = truncated
+	result.Invalid = invalid
+	return result, err
+}
+
+// readDLQArchive decodes archive lines and calls fn for each entry.
+// A gzip stream cut short by a crash mid-sweep is reported as truncated rather than
+// failing: every complete line before the cut is still delivered.
+func readDLQArchive(r io.Reader, fn func(*DLQEntry) error) (truncated bool, invalid int, err error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return false, 0, fmt.Errorf("failed to read DLQ archive: %w", err)
+	}
+
+	var src io.Reader = br
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return false, 0, fmt.Errorf("failed to open gzip DLQ archive: %w", err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	lines := bufio.NewReader(src)
+	for {
+		line, readErr := lines.ReadBytes('\n')
+
+		complete := len(line) > 0 && line[len(line)-1] == '\n'
+		if readErr != nil && !complete && len(line) > 0 && readErr != io.EOF {
+			// Partial trailing line of a truncated archive
+			line = nil
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var entry DLQEntry
+			if jsonErr := json.Unmarshal(line, &entry); jsonErr != nil || entry.Task == nil || entry.Task.ID == "" {
+				invalid++
+			} else if err := fn(&entry); err != nil {
+				return false, invalid, err
+			}
+		}
+
+		switch {
+		case readErr == nil:
+			continue
+		case readErr == io.EOF:
+			return false, invalid, nil
+		case errors.Is(readErr, io.ErrUnexpectedEOF):
+			return true, invalid, nil
+		default:
+			return false, invalid, fmt.Errorf("failed to read DLQ archive: %w", readErr)
+		}
+	}
+}
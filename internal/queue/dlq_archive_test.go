@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func testDLQEntries(n int) []*DLQEntry {
+	added := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	entries := make([]*DLQEntry, n)
+	for i := range entries {
+		tsk := task.New("email", map[string]interface{}{"n": i}, task.PriorityNormal)
+		tsk.State = task.StateDeadLetter
+		tsk.Error = "SMTP connection failed"
+		entries[i] = &DLQEntry{
+			Task:      tsk,
+			Reason:    "max retries exceeded",
+			AddedAt:   added.Add(time.Duration(i) * time.Minute),
+			OrigError: tsk.Error,
+			MessageID: "1705312800000-0",
+		}
+	}
+	return entries
+}
+
+func readAllArchive(t *testing.T, data []byte) ([]*DLQEntry, bool, int) {
+	t.Helper()
+	var got []*DLQEntry
+	truncated, invalid, err := readDLQArchive(bytes.NewReader(data), func(e *DLQEntry) error {
+		got = append(got, e)
+		return nil
+	})
+	require.NoError(t, err)
+	return got, truncated, invalid
+}
+
+func TestDLQArchiver_FileName(t *testing.T) {
+	at := time.Date(2024, 1, 15, 10, 30, 0, 123e6, time.UTC)
+
+	assert.Equal(t, "dlq-20240115T103000.123Z.ndjson", NewDLQArchiver("", false).fileName(at))
+	assert.Equal(t, "dlq-20240115T103000.123Z.ndjson.gz", NewDLQArchiver("", true).fileName(at))
+}
+
+func TestDLQArchive_RoundTrip(t *testing.T) {
+	for _, gzip := range []bool{false, true} {
+		t.Run(map[bool]string{false: "plain", true: "gzip"}[gzip], func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "archive")
+			archiver := NewDLQArchiver(dir, gzip)
+
+			af, err := archiver.open(time.Now())
+			require.NoError(t, err)
+			entries := testDLQEntries(5)
+			require.NoError(t, af.write(entries[:2]))
+			require.NoError(t, af.write(entries[2:]))
+			require.NoError(t, af.Close())
+			assert.Equal(t, 5, af.count)
+
+			data, err := os.ReadFile(af.path)
+			require.NoError(t, err)
+
+			got, truncated, invalid := readAllArchive(t, data)
+			assert.False(t, truncated)
+			assert.Zero(t, invalid)
+			require.Len(t, got, 5)
+			for i, e := range got {
+				assert.Equal(t, entries[i].Task.ID, e.Task.ID)
+				assert.Equal(t, entries[i].Reason, e.Reason)
+				assert.True(t, entries[i].AddedAt.Equal(e.AddedAt))
+				assert.Equal(t, entries[i].OrigError, e.OrigError)
+				assert.Empty(t, e.MessageID, "message IDs are not archived")
+			}
+		})
+	}
+}
+
+func TestDLQArchiver_NeverOverwrites(t *testing.T) {
+	archiver := NewDLQArchiver(t.TempDir(), false)
+	at := time.Now()
+
+	af, err := archiver.open(at)
+	require.NoError(t, err)
+	defer af.Close()
+
+	_, err = archiver.open(at)
+	assert.Error(t, err)
+}
+
+func TestReadDLQArchive_TruncatedGzip(t *testing.T) {
+	archiver := NewDLQArchiver(t.TempDir(), true)
+	af, err := archiver.open(time.Now())
+	require.NoError(t, err)
+	require.NoError(t, af.write(testDLQEntries(3)))
+
+	// Simulate a crash mid-sweep: the flushed data is on disk but the gzip
+	// trailer was never written, and the last block is cut short
+	data, err := os.ReadFile(af.path)
+	require.NoError(t, err)
+	require.NoError(t, af.Close())
+	data = data[:len(data)-4]
+
+	got, truncated, _ := readAllArchive(t, data)
+	assert.True(t, truncated)
+	assert.LessOrEqual(t, len(got), 3)
+	for _, e := range got {
+		assert.NotEmpty(t, e.Task.ID)
+	}
+}
+
+func TestReadDLQArchive_UnflushedGzipTail(t *testing.T) {
+	archiver := NewDLQArchiver(t.TempDir(), true)
+	af, err := archiver.open(time.Now())
+	require.NoError(t, err)
+	require.NoError(t, af.write(testDLQEntries(3)))
+
+	// Flushed but never closed: all three entries are readable
+	data, err := os.ReadFile(af.path)
+	require.NoError(t, err)
+	require.NoError(t, af.Close())
+
+	got, truncated, invalid := readAllArchive(t, data)
+	assert.True(t, truncated)
+	assert.Zero(t, invalid)
+	assert.Len(t, got, 3)
+}
+
+func TestReadDLQArchive_InvalidLines(t *testing.T) {
+	entries := testDLQEntries(2)
+	archiver := NewDLQArchiver(t.TempDir(), false)
+	f, err := archiver.open(time.Now())
+	require.NoError(t, err)
+	require.NoError(t, f.write(entries))
+	require.NoError(t, f.Close())
+	data, err := os.ReadFile(f.path)
+	require.NoError(t, err)
+
+	lines := strings.SplitAfter(string(data), "\n")
+	var buf bytes.Buffer
+	buf.WriteString(lines[0])
+	buf.WriteString("not json\n")
+	buf.WriteString("\n")
+	buf.WriteString(`{"reason":"no task"}` + "\n")
+	buf.WriteString(lines[1])
+
+	got, truncated, invalid := readAllArchive(t, buf.Bytes())
+	assert.False(t, truncated)
+	assert.Equal(t, 2, invalid)
+	assert.Len(t, got, 2)
+}
+
+func TestReadDLQArchive_Empty(t *testing.T) {
+	got, truncated, invalid := readAllArchive(t, nil)
+	assert.Empty(t, got)
+	assert.False(t, truncated)
+	assert.Zero(t, invalid)
+}
@@ -0,0 +1,290 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/metrics"
+)
+
+const (
+	dlqJanitorLockKey = "dlq:janitor:lock"
+	dlqJanitorLockTTL = 30 * time.Second
+	dlqJanitorBatch   = 200
+)
+
+// dlqDeleteScript removes stream entries and, for each, the set and index entries
+// only while the index still points at that message. A task re-added to the DLQ
+// after the janitor read its old entry keeps its new index.
+//
+// KEYS: stream, set, index. ARGV: message ID, task ID pairs.
+var dlqDeleteScript = redis.NewScript(`
+local removed = 0
+for i = 1, #ARGV, 2 do
+	removed = removed + redis.call("XDEL", KEYS[1], ARGV[i])
+	if ARGV[i+1] ~= "" and redis.call("HGET", KEYS[3], ARGV[i+1]) == ARGV[i] then
+		redis.call("HDEL", KEYS[3], ARGV[i+1])
+		redis.call("SREM", KEYS[2], ARGV[i+1])
+	end
+end
+return removed
+`)
+
+// DLQSweepResult summarizes one janitor pass
+type DLQSweepResult struct {
+	RemovedByAge  int
+	RemovedBySize int
+	Archived      int
+	ArchiveFile   string
+}
+
+// DLQJanitor enforces DLQ retention: entries older than MaxAge and the oldest
+// entries beyond MaxEntries are archived (if configured) and then deleted.
+// It runs as a scheduler leader job; its own lock also keeps sweeps exclusive
+// while replicas disagree about leadership.
+type DLQJanitor struct {
+	client   *redis.Client
+	cfg      config.DLQConfig
+	archiver *DLQArchiver
+	owner    string
+	tenants  []string // DLQs swept, "" = default
+}
+
+// NewDLQJanitor creates a new DLQ janitor
+func NewDLQJanitor(client *redis.Client, cfg *config.DLQConfig) *DLQJanitor {
+	j := &DLQJanitor{
+		client:  client,
+		cfg:     *cfg,
+		owner:   uuid.New().String(),
+		tenants: []string{""},
+	}
+	if cfg.ArchiveDir != "" {
+		j.archiver = NewDLQArchiver(cfg.ArchiveDir, cfg.ArchiveGzip)
+	}
+	return j
+}
+
+// SetTenants sets the tenants whose DLQs are swept (see RedisQueue.Tenants).
+// Only the default tenant is swept otherwise.
+func (j *DLQJanitor) SetTenants(tenants []string) {
+	j.tenants = tenants
+}
+
+// Enabled reports whether any retention limit is configured
+func (j *DLQJanitor) Enabled() bool {
+	return j.cfg.MaxAge > 0 || j.cfg.MaxEntries > 0
+}
+
+// Interval returns how often the janitor should sweep
+func (j *DLQJanitor) Interval() time.Duration {
+	if j.cfg.JanitorInterval <= 0 {
+		return time.Minute
+	}
+	return j.cfg.JanitorInterval
+}
+
+// LeaderJob returns the janitor as a job for Scheduler.RunWhileLeader
+func (j *DLQJanitor) LeaderJob() LeaderJob {
+	return LeaderJob{Name: "dlq-janitor", Interval: j.Interval(), Run: j.Sweep}
+}
+
+// Sweep runs one retention pass and logs what it removed
+func (j *DLQJanitor) Sweep(ctx context.Context) error {
+	result, err := j.RunOnce(ctx)
+	if result.RemovedByAge+result.RemovedBySize > 0 {
+		logger.Info().
+			Int("removed_by_age", result.RemovedByAge).
+			Int("removed_by_size", result.RemovedBySize).
+			Int("archived", result.Archived).
+			Str("archive_file", result.ArchiveFile).
+			Msg("DLQ retention sweep completed")
+	}
+	if err != nil {
+		return fmt.Errorf("DLQ retention sweep failed: %w", err)
+	}
+	return nil
+}
+
+// RunOnce performs a single retention sweep. It returns without doing anything
+// if another replica holds the janitor lock.
+func (j *DLQJanitor) RunOnce(ctx context.Context) (DLQSweepResult, error) {
+	var result DLQSweepResult
+	if !j.Enabled() {
+		return result, nil
+	}
+
+	acquired, err := j.client.SetNX(ctx, dlqJanitorLockKey, j.owner, dlqJanitorLockTTL).Result()
+	if err != nil {
+		return result, fmt.Errorf("failed to acquire DLQ janitor lock: %w", err)
+	}
+	if !acquired {
+		return result, nil
+	}
+	defer releaseLease(j.client, dlqJanitorLockKey, j.owner)
+
+	s := &dlqSweep{janitor: j, result: &result, started: time.Now()}
+	defer s.close()
+
+	// Limits apply to each tenant's DLQ separately; one archive file holds them all
+	for _, tenant := range j.tenants {
+		s.dlq = NewDLQ(j.client).ForTenant(tenant)
+		if j.cfg.MaxAge > 0 {
+			if err := s.sweepAge(ctx); err != nil {
+				return result, err
+			}
+		}
+		if j.cfg.MaxEntries > 0 {
+			if err := s.sweepSize(ctx); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// dlqSweep holds the state of one RunOnce pass
+type dlqSweep struct {
+	janitor *DLQJanitor
+	dlq     *DLQ // Tenant DLQ being swept
+	result  *DLQSweepResult
+	started time.Time
+	archive *dlqArchiveFile
+}
+
+// sweepAge removes entries added before now-MaxAge. Restored entries keep their
+// original AddedAt but get a new stream ID, so every entry's AddedAt is checked.
+func (s *dlqSweep) sweepAge(ctx context.Context) error {
+	cutoff := s.started.Add(-s.janitor.cfg.MaxAge)
+	start := "-"
+
+	for {
+		messages, err := s.janitor.client.XRangeN(ctx, s.dlq.streamKey(), start, "+", dlqJanitorBatch).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read DLQ stream: %w", err)
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		var expired []redis.XMessage
+		for _, msg := range messages {
+			if dlqAddedAt(msg).Before(cutoff) {
+				expired = append(expired, msg)
+			}
+		}
+		if len(expired) > 0 {
+			n, err := s.remove(ctx, expired, "age")
+			if err != nil {
+				return err
+			}
+			s.result.RemovedByAge += n
+		}
+
+		if len(messages) < dlqJanitorBatch {
+			return nil
+		}
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// dlqAddedAt returns when a stream entry was added to the DLQ, falling back to
+// its ID's timestamp for entries that cannot be parsed
+func dlqAddedAt(msg redis.XMessage) time.Time {
+	if entry, ok := parseDLQMessage(msg); ok && !entry.AddedAt.IsZero() {
+		return entry.AddedAt
+	}
+	ms, _, _ := strings.Cut(msg.ID, "-")
+	n, _ := strconv.ParseInt(ms, 10, 64)
+	return time.UnixMilli(n)
+}
+
+// sweepSize removes the oldest entries while the stream is longer than MaxEntries
+func (s *dlqSweep) sweepSize(ctx context.Context) error {
+	length, err := s.janitor.client.XLen(ctx, s.dlq.streamKey()).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read DLQ length: %w", err)
+	}
+
+	for excess := length - s.janitor.cfg.MaxEntries; excess > 0; {
+		messages, err := s.janitor.client.XRangeN(ctx, s.dlq.streamKey(), "-", "+", min(excess, dlqJanitorBatch)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read DLQ stream: %w", err)
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		n, err := s.remove(ctx, messages, "size")
+		if err != nil {
+			return err
+		}
+		s.result.RemovedBySize += n
+		excess -= int64(len(messages))
+	}
+
+	return nil
+}
+
+// remove archives a batch (if configured) and then deletes it. Nothing is deleted
+// unless the archive write for the batch was synced to disk.
+func (s *dlqSweep) remove(ctx context.Context, messages []redis.XMessage, reason string) (int, error) {
+	entries := make([]*DLQEntry, 0, len(messages))
+	args := make([]interface{}, 0, 2*len(messages))
+	for _, msg := range messages {
+		taskID, _ := msg.Values["task_id"].(string)
+		if entry, ok := parseDLQMessage(msg); ok {
+			entries = append(entries, entry)
+			taskID = entry.Task.ID
+		}
+		args = append(args, msg.ID, taskID)
+	}
+
+	archived := 0
+	if s.janitor.archiver != nil && len(entries) > 0 {
+		if s.archive == nil {
+			af, err := s.janitor.archiver.open(s.started)
+			if err != nil {
+				return 0, err
+			}
+			s.archive = af
+			s.result.ArchiveFile = af.path
+		}
+		if err := s.archive.write(entries); err != nil {
+			return 0, err
+		}
+		archived = len(entries)
+		s.result.Archived += archived
+	}
+
+	// Keep the lock for long sweeps; stop if another replica took over
+	if !renewLease(ctx, s.janitor.client, dlqJanitorLockKey, s.janitor.owner, dlqJanitorLockTTL) {
+		return 0, fmt.Errorf("lost DLQ janitor lock")
+	}
+
+	removed, err := dlqDeleteScript.Run(ctx, s.janitor.client,
+		[]string{s.dlq.streamKey(), s.dlq.setKey(), s.dlq.indexKey()}, args...).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete DLQ entries: %w", err)
+	}
+
+	metrics.RecordDLQRetention(reason, removed, archived)
+	return removed, nil
+}
+
+func (s *dlqSweep) close() {
+	if s.archive == nil {
+		return
+	}
+	if err := s.archive.Close(); err != nil {
+		logger.Error().Err(err).Str("file", s.archive.path).Msg("failed to close DLQ archive")
+	}
+}
@@ -0,0 +1,190 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func TestNewDLQJanitor(t *testing.T) {
+	j := NewDLQJanitor(nil, &config.DLQConfig{})
+	assert.False(t, j.Enabled())
+	assert.Nil(t, j.archiver)
+
+	j = NewDLQJanitor(nil, &config.DLQConfig{MaxAge: 24 * time.Hour, ArchiveDir: "/tmp/dlq", ArchiveGzip: true})
+	assert.True(t, j.Enabled())
+	assert.NotNil(t, j.archiver)
+	assert.True(t, j.archiver.gzip)
+
+	j = NewDLQJanitor(nil, &config.DLQConfig{MaxEntries: 1000})
+	assert.True(t, j.Enabled())
+}
+
+func TestDLQJanitor_DisabledIsNoop(t *testing.T) {
+	j := NewDLQJanitor(nil, &config.DLQConfig{})
+
+	// Does not touch Redis when no limit is configured
+	result, err := j.RunOnce(t.Context())
+	assert.NoError(t, err)
+	assert.Zero(t, result)
+	assert.NoError(t, j.Sweep(t.Context()))
+}
+
+func TestDLQJanitor_LeaderJob(t *testing.T) {
+	j := NewDLQJanitor(nil, &config.DLQConfig{MaxAge: time.Hour, JanitorInterval: 30 * time.Second})
+	job := j.LeaderJob()
+
+	assert.Equal(t, "dlq-janitor", job.Name)
+	assert.Equal(t, 30*time.Second, job.Interval)
+	assert.NotNil(t, job.Run)
+
+	assert.Equal(t, time.Minute, NewDLQJanitor(nil, &config.DLQConfig{}).Interval())
+}
+
+// fakeStreams answers the commands of a janitor sweep from in-memory streams,
+// as a go-redis hook, so no Redis server is needed
+type fakeStreams struct {
+	streams map[string][]redis.XMessage
+}
+
+func newFakeStreamsClient(t *testing.T, streams map[string][]redis.XMessage) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	client.AddHook(&fakeStreams{streams: streams})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func (f *fakeStreams) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (f *fakeStreams) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (f *fakeStreams) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return func(_ context.Context, cmd redis.Cmder) error {
+		args := cmd.Args()
+		switch cmd.Name() {
+		case "set": // Janitor lock
+			cmd.(*redis.BoolCmd).SetVal(true)
+		case "xlen":
+			cmd.(*redis.IntCmd).SetVal(int64(len(f.streams[args[1].(string)])))
+		case "xrange": // Always up to "+"; the start is "-" or an exclusive ID
+			messages := f.streams[args[1].(string)]
+			if start := args[2].(string); strings.HasPrefix(start, "(") {
+				for i, msg := range messages {
+					if msg.ID == start[1:] {
+						messages = messages[i+1:]
+						break
+					}
+				}
+			}
+			count := int(args[5].(int64))
+			cmd.(*redis.XMessageSliceCmd).SetVal(messages[:min(count, len(messages))])
+		case "evalsha":
+			switch args[1].(string) {
+			case dlqDeleteScript.Hash():
+				stream := args[3].(string)
+				removed := 0
+				for i := 6; i < len(args); i += 2 {
+					kept := f.streams[stream][:0]
+					for _, msg := range f.streams[stream] {
+						if msg.ID == args[i].(string) {
+							removed++
+							continue
+						}
+						kept = append(kept, msg)
+					}
+					f.streams[stream] = kept
+				}
+				cmd.(*redis.Cmd).SetVal(int64(removed))
+			case redriveScript.Hash():
+				stream, messageID := args[3].(string), args[7].(string)
+				idx := slices.IndexFunc(f.streams[stream], func(msg redis.XMessage) bool { return msg.ID == messageID })
+				if idx < 0 {
+					cmd.(*redis.Cmd).SetVal(int64(0))
+					break
+				}
+				f.streams[stream] = slices.Delete(f.streams[stream], idx, idx+1)
+				queue := args[12].(string)
+				f.streams[queue] = append(f.streams[queue], redis.XMessage{
+					ID:     fmt.Sprintf("%d-0", len(f.streams[queue])),
+					Values: map[string]interface{}{"task_id": args[8]},
+				})
+				cmd.(*redis.Cmd).SetVal(int64(1))
+			default: // Lease renewal and release
+				cmd.(*redis.Cmd).SetVal(int64(1))
+			}
+		default:
+			cmd.SetErr(fmt.Errorf("unexpected command %q", cmd.Name()))
+		}
+		return cmd.Err()
+	}
+}
+
+func fakeDLQStream(n int) []redis.XMessage {
+	messages := make([]redis.XMessage, n)
+	for i := range messages {
+		messages[i] = redis.XMessage{ID: fmt.Sprintf("%d-0", 1000+i), Values: map[string]interface{}{}}
+	}
+	return messages
+}
+
+// fakeDLQMessage returns a stream entry for a DLQ entry added at addedAt
+func fakeDLQMessage(t *testing.T, id string, addedAt time.Time) redis.XMessage {
+	t.Helper()
+	data, err := json.Marshal(DLQEntry{Task: task.New("email", nil, task.PriorityNormal), AddedAt: addedAt})
+	require.NoError(t, err)
+	return redis.XMessage{ID: id, Values: map[string]interface{}{"data": string(data)}}
+}
+
+func TestDLQJanitor_MaxEntriesPerTenant(t *testing.T) {
+	defaultStream := tenantKey("", dlqStreamName)
+	billingStream := tenantKey("billing", dlqStreamName)
+	streams := map[string][]redis.XMessage{
+		defaultStream: fakeDLQStream(3),
+		billingStream: fakeDLQStream(10),
+	}
+
+	j := NewDLQJanitor(newFakeStreamsClient(t, streams), &config.DLQConfig{MaxEntries: 5})
+	j.SetTenants([]string{"", "billing"})
+
+	result, err := j.RunOnce(t.Context())
+	require.NoError(t, err)
+
+	// Each tenant is measured by its own stream
+	assert.Equal(t, 5, result.RemovedBySize)
+	assert.Len(t, streams[defaultStream], 3)
+	require.Len(t, streams[billingStream], 5)
+	assert.Equal(t, "1005-0", streams[billingStream][0].ID, "the oldest entries are removed")
+}
+
+func TestDLQJanitor_MaxAgeUsesAddedAt(t *testing.T) {
+	now := time.Now()
+	recent := fmt.Sprintf("%d-0", now.UnixMilli())
+	stream := tenantKey("", dlqStreamName)
+	streams := map[string][]redis.XMessage{stream: {
+		fakeDLQMessage(t, fmt.Sprintf("%d-0", now.Add(-48*time.Hour).UnixMilli()), now.Add(-48*time.Hour)),
+		fakeDLQMessage(t, recent, now.Add(-time.Minute)),
+		// Restored from an archive: a new stream ID but its original added-at time
+		fakeDLQMessage(t, fmt.Sprintf("%d-1", now.UnixMilli()), now.Add(-72*time.Hour)),
+	}}
+
+	j := NewDLQJanitor(newFakeStreamsClient(t, streams), &config.DLQConfig{MaxAge: 24 * time.Hour})
+	result, err := j.RunOnce(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, result.RemovedByAge)
+	require.Len(t, streams[stream], 1)
+	assert.Equal(t, recent, streams[stream][0].ID)
+}
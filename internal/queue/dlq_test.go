@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func TestDLQConstants(t *testing.T) {
+	assert.Equal(t, "tasks:dlq", dlqStreamName)
+	assert.Equal(t, "tasks:dlq:set", dlqSetName)
+	assert.Equal(t, "tasks:dlq:index", dlqIndexName)
+}
+
+func TestDLQFilter_Matches(t *testing.T) {
+	added := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tsk := task.New("email", nil, task.PriorityNormal)
+	tsk.Error = "SMTP connection failed"
+	entry := &DLQEntry{Task: tsk, Reason: "max retries exceeded", AddedAt: added, OrigError: tsk.Error}
+
+	tests := []struct {
+		name   string
+		filter DLQFilter
+		want   bool
+	}{
+		{"empty filter", DLQFilter{}, true},
+		{"type match", DLQFilter{TaskType: "email"}, true},
+		{"type mismatch", DLQFilter{TaskType: "sms"}, false},
+		{"reason case-insensitive", DLQFilter{Reason: "Max Retries Exceeded"}, true},
+		{"reason is exact", DLQFilter{Reason: "max retries"}, false},
+		{"error substring", DLQFilter{ErrorContains: "smtp"}, true},
+		{"error mismatch", DLQFilter{ErrorContains: "timeout"}, false},
+		{"after inclusive", DLQFilter{AddedAfter: added}, true},
+		{"after excludes older", DLQFilter{AddedAfter: added.Add(time.Second)}, false},
+		{"before exclusive", DLQFilter{AddedBefore: added}, false},
+		{"before includes older", DLQFilter{AddedBefore: added.Add(time.Second)}, true},
+		{"combined", DLQFilter{TaskType: "email", ErrorContains: "connection", AddedAfter: added.Add(-time.Hour)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Matches(entry))
+		})
+	}
+
+	assert.False(t, DLQFilter{}.Matches(&DLQEntry{}), "entries without a task never match")
+}
+
+func TestDLQ_ListPageFiltersRestoredEntries(t *testing.T) {
+	added := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	stream := tenantKey("", dlqStreamName)
+	client := newFakeStreamsClient(t, map[string][]redis.XMessage{stream: {
+		fakeDLQMessage(t, "1705312800000-0", added),
+		// Restored later: a newer stream ID than its added-at time
+		fakeDLQMessage(t, "1760000000000-0", added.Add(-time.Hour)),
+		fakeDLQMessage(t, "1760000000001-0", added.Add(time.Hour)),
+	}})
+
+	page, err := NewDLQ(client).ListPage(t.Context(), DLQFilter{AddedBefore: added}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "1760000000000-0", page.Entries[0].MessageID)
+
+	page, err = NewDLQ(client).ListPage(t.Context(), DLQFilter{AddedAfter: added}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+}
@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maumercado/task-queue-go/internal/encryption"
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// SetSealer enables encryption of task payloads and results at rest. Tasks are
+// sealed when written and opened when read; views from ForTenant share it.
+func (q *RedisQueue) SetSealer(s *encryption.Sealer) {
+	q.sealer = s
+}
+
+// Sealer returns the queue's sealer (nil = encryption disabled)
+func (q *RedisQueue) Sealer() *encryption.Sealer {
+	return q.sealer
+}
+
+// ScheduleTask stores a task, encrypted if configured, and adds it to its
+// tenant's scheduled set
+func (q *RedisQueue) ScheduleTask(ctx context.Context, t *task.Task, scheduledAt time.Time) error {
+	sealed, err := q.sealer.Seal(t)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt task: %w", err)
+	}
+	return scheduleTask(ctx, q.client, sealed, scheduledAt)
+}
+
+// encodeTask marshals a task for storage, encrypting its payload and result
+// if its type is covered
+func (q *RedisQueue) encodeTask(t *task.Task) ([]byte, error) {
+	return encodeTask(q.sealer, t)
+}
+
+func encodeTask(s *encryption.Sealer, t *task.Task) ([]byte, error) {
+	sealed, err := s.Seal(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt task: %w", err)
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task: %w", err)
+	}
+	return data, nil
+}
+
+// openTask decrypts a task read from storage. A task that cannot be decrypted
+// stays sealed, without payload or result, so it can still be listed,
+// canceled or dead-lettered; workers fail it.
+func openTask(s *encryption.Sealer, t *task.Task) {
+	if t == nil || t.Sealed == nil {
+		return
+	}
+	if err := s.Open(t); err != nil {
+		event := logger.Warn()
+		if errors.Is(err, encryption.ErrNoKeyring) {
+			event = logger.Debug()
+		}
+		event.Err(err).Str("task_id", t.ID).Str("key_id", t.Sealed.KeyID).Msg("task left encrypted")
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
 	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/encryption"
 	"github.com/maumercado/task-queue-go/internal/task"
+	"github.com/maumercado/task-queue-go/internal/tlsutil"
 )
 
 // RedisQueue implements a priority queue using Redis Streams.
@@ -21,10 +24,26 @@ type RedisQueue struct {
 	blockTimeout      time.Duration // How long to block waiting for messages
 	claimMinIdle      time.Duration // Min idle time before claiming orphaned messages
 	taskRetentionDays int           // Days to retain completed tasks (0 = no expiry)
+	tenant            string        // Keyspace for ID-based operations ("" = default)
+	tenants           *tenantRegistry
+	sealer            *encryption.Sealer // Encrypts payloads and results at rest; nil = disabled
+}
+
+// allPriorities lists priorities in the order they are consumed
+var allPriorities = []task.Priority{
+	task.PriorityCritical,
+	task.PriorityHigh,
+	task.PriorityNormal,
+	task.PriorityLow,
 }
 
 // NewRedisQueue creates a new Redis-backed queue and initializes streams
 func NewRedisQueue(cfg *config.RedisConfig, queueCfg *config.QueueConfig) (*RedisQueue, error) {
+	tlsConfig, err := tlsutil.RedisConfig(&cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis tls config: %w", err)
+	}
+
 	// Create Redis client with connection pooling
 	client := redis.NewClient(&redis.Options{
 		Addr:         cfg.Addr,
@@ -36,8 +55,15 @@ func NewRedisQueue(cfg *config.RedisConfig, queueCfg *config.QueueConfig) (*Redi
 		DialTimeout:  cfg.DialTimeout,
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
+		TLSConfig:    tlsConfig,
 	})
 
+	return NewRedisQueueWithClient(client, queueCfg)
+}
+
+// NewRedisQueueWithClient creates a queue on an existing Redis client and initializes streams.
+// Used when embedding the queue in a service that already manages its own connection.
+func NewRedisQueueWithClient(client *redis.Client, queueCfg *config.QueueConfig) (*RedisQueue, error) {
 	// Verify connection before proceeding
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -46,6 +72,11 @@ func NewRedisQueue(cfg *config.RedisConfig, queueCfg *config.QueueConfig) (*Redi
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	tenants, err := newTenantRegistry(queueCfg.Tenants)
+	if err != nil {
+		return nil, err
+	}
+
 	q := &RedisQueue{
 		client:            client,
 		streamPrefix:      queueCfg.StreamPrefix,
@@ -53,6 +84,7 @@ func NewRedisQueue(cfg *config.RedisConfig, queueCfg *config.QueueConfig) (*Redi
 		blockTimeout:      queueCfg.BlockTimeout,
 		claimMinIdle:      queueCfg.ClaimMinIdle,
 		taskRetentionDays: queueCfg.TaskRetentionDays,
+		tenants:           tenants,
 	}
 
 	// Create streams and consumer groups for each priority
@@ -63,40 +95,35 @@ func NewRedisQueue(cfg *config.RedisConfig, queueCfg *config.QueueConfig) (*Redi
 	return q, nil
 }
 
-// initStreams creates streams and consumer groups for all priority levels
+// initStreams creates streams and consumer groups for all priority levels of every tenant
 func (q *RedisQueue) initStreams(ctx context.Context) error {
-	priorities := []task.Priority{
-		task.PriorityCritical,
-		task.PriorityHigh,
-		task.PriorityNormal,
-		task.PriorityLow,
-	}
-
-	for _, p := range priorities {
-		streamName := p.StreamName(q.streamPrefix)
-		// XGroupCreateMkStream creates both stream and group if they don't exist
-		err := q.client.XGroupCreateMkStream(ctx, streamName, q.consumerGroup, "0").Err()
-		if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
-			return fmt.Errorf("failed to create consumer group for %s: %w", streamName, err)
+	for _, tenant := range q.tenants.names {
+		for _, p := range allPriorities {
+			streamName := q.streamName(tenant, p)
+			// XGroupCreateMkStream creates both stream and group if they don't exist
+			err := q.client.XGroupCreateMkStream(ctx, streamName, q.consumerGroup, "0").Err()
+			if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+				return fmt.Errorf("failed to create consumer group for %s: %w", streamName, err)
+			}
 		}
 	}
 
 	return nil
 }
 
-// Enqueue adds a task to the appropriate priority stream.
+// Enqueue adds a task to the appropriate priority stream of its tenant.
 // Stores full task data separately for efficient retrieval.
 func (q *RedisQueue) Enqueue(ctx context.Context, t *task.Task) error {
-	streamName := t.Priority.StreamName(q.streamPrefix)
+	streamName := q.streamName(t.Tenant, t.Priority)
 
 	// Serialize task to JSON
-	taskData, err := json.Marshal(t)
+	taskData, err := q.encodeTask(t)
 	if err != nil {
-		return fmt.Errorf("failed to marshal task: %w", err)
+		return err
 	}
 
 	// Store full task data in a separate key (more efficient than embedding in stream)
-	taskKey := q.taskKey(t.ID)
+	taskKey := q.taskKeyFor(t)
 	if err := q.client.Set(ctx, taskKey, taskData, 0).Err(); err != nil {
 		return fmt.Errorf("failed to store task data: %w", err)
 	}
@@ -107,6 +134,7 @@ func (q *RedisQueue) Enqueue(ctx context.Context, t *task.Task) error {
 		Values: map[string]interface{}{
 			"task_id": t.ID,
 			"type":    t.Type,
+			"gen":     t.QueueGen,
 		},
 	}).Result()
 
@@ -119,77 +147,75 @@ func (q *RedisQueue) Enqueue(ctx context.Context, t *task.Task) error {
 }
 
 // Dequeue fetches the next task, checking priority queues from highest to lowest.
+// Within a priority, served tenants are tried in rotating order.
 // Non-blocking: returns nil immediately if no tasks available.
 func (q *RedisQueue) Dequeue(ctx context.Context, consumerID string) (*task.Task, string, error) {
-	// Check queues in priority order: critical -> high -> normal -> low
-	priorities := []task.Priority{
-		task.PriorityCritical,
-		task.PriorityHigh,
-		task.PriorityNormal,
-		task.PriorityLow,
-	}
+	saturated := q.saturatedTenants(ctx)
+	tenants := q.tenants.servedOrder()
 
-	for _, p := range priorities {
-		streamName := p.StreamName(q.streamPrefix)
-
-		// XReadGroup with Block=0 is non-blocking
-		streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
-			Group:    q.consumerGroup,
-			Consumer: consumerID,
-			Streams:  []string{streamName, ">"}, // ">" means only new messages
-			Count:    1,
-			Block:    0,
-		}).Result()
+	// Check queues in priority order: critical -> high -> normal -> low
+	for _, p := range allPriorities {
+		for _, tenant := range tenants {
+			if saturated[tenant] {
+				continue
+			}
+			streamName := q.streamName(tenant, p)
 
-		if err == redis.Nil {
-			continue // No messages in this priority, try next
-		}
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to read from stream %s: %w", streamName, err)
-		}
+			// XReadGroup with Block=0 is non-blocking
+			streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    q.consumerGroup,
+				Consumer: consumerID,
+				Streams:  []string{streamName, ">"}, // ">" means only new messages
+				Count:    1,
+				Block:    0,
+			}).Result()
 
-		if len(streams) == 0 || len(streams[0].Messages) == 0 {
-			continue
-		}
+			if err == redis.Nil {
+				continue // No messages in this stream, try next
+			}
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read from stream %s: %w", streamName, err)
+			}
 
-		// Extract task ID from stream message
-		msg := streams[0].Messages[0]
-		taskID, ok := msg.Values["task_id"].(string)
-		if !ok {
-			// Invalid message format, acknowledge to remove from pending
-			q.client.XAck(ctx, streamName, q.consumerGroup, msg.ID)
-			continue
-		}
+			if len(streams) == 0 || len(streams[0].Messages) == 0 {
+				continue
+			}
 
-		// Fetch full task data from storage
-		t, err := q.GetTask(ctx, taskID)
-		if err != nil {
-			q.client.XAck(ctx, streamName, q.consumerGroup, msg.ID)
-			continue
+			if t := q.resolveMessage(ctx, tenant, streamName, streams[0].Messages[0]); t != nil {
+				return t, streams[0].Messages[0].ID, nil
+			}
 		}
-
-		return t, msg.ID, nil
 	}
 
 	return nil, "", nil // No tasks available in any queue
 }
 
 // DequeueBlocking fetches the next task, blocking until one is available.
-// Listens to all priority streams simultaneously but returns highest priority first.
+// Listens to all served streams simultaneously; streams are ordered by priority
+// and, within a priority, by rotating tenant so tenants are served fairly.
 func (q *RedisQueue) DequeueBlocking(ctx context.Context, consumerID string) (*task.Task, string, error) {
-	priorities := []task.Priority{
-		task.PriorityCritical,
-		task.PriorityHigh,
-		task.PriorityNormal,
-		task.PriorityLow,
-	}
+	saturated := q.saturatedTenants(ctx)
+	tenants := q.tenants.servedOrder()
 
 	// Build streams array: [stream1, stream2, ..., ">", ">", ...]
-	streams := make([]string, 0, len(priorities)*2)
-	for _, p := range priorities {
-		streams = append(streams, p.StreamName(q.streamPrefix))
+	streams := make([]string, 0, len(allPriorities)*len(tenants)*2)
+	streamTenants := make(map[string]string, len(allPriorities)*len(tenants))
+	for _, p := range allPriorities {
+		for _, tenant := range tenants {
+			if saturated[tenant] {
+				continue
+			}
+			streamName := q.streamName(tenant, p)
+			streams = append(streams, streamName)
+			streamTenants[streamName] = tenant
+		}
 	}
-	for range priorities {
+	if len(streams) == 0 {
+		// Every served tenant is at its concurrency limit; wait as a read would
+		_ = sleepCtx(ctx, q.blockTimeout)
+		return nil, "", nil
+	}
+	for range streamTenants {
 		streams = append(streams, ">")
 	}
 
@@ -216,24 +242,47 @@ func (q *RedisQueue) DequeueBlocking(ctx context.Context, consumerID string) (*t
 	// Process first received message
 	msg := result[0].Messages[0]
 	streamName := result[0].Stream
+	t := q.resolveMessage(ctx, streamTenants[streamName], streamName, msg)
+	if t == nil {
+		return nil, "", nil
+	}
+
+	return t, msg.ID, nil
+}
+
+// resolveMessage loads the task a stream message refers to. Messages that are
+// malformed, point to a missing task or were superseded by an edit are
+// acknowledged and skipped (nil is returned).
+func (q *RedisQueue) resolveMessage(ctx context.Context, tenant, streamName string, msg redis.XMessage) *task.Task {
 	taskID, ok := msg.Values["task_id"].(string)
 	if !ok {
+		// Invalid message format, acknowledge to remove from pending
 		q.client.XAck(ctx, streamName, q.consumerGroup, msg.ID)
-		return nil, "", nil
+		return nil
 	}
 
-	t, err := q.GetTask(ctx, taskID)
-	if err != nil {
+	// Fetch full task data from storage
+	t, err := q.ForTenant(tenant).GetTask(ctx, taskID)
+	if err != nil || isStaleMessage(msg, t) {
 		q.client.XAck(ctx, streamName, q.consumerGroup, msg.ID)
-		return nil, "", nil
+		return nil
 	}
 
-	return t, msg.ID, nil
+	return t
+}
+
+// isStaleMessage reports whether a stream message was superseded by an edit that
+// moved its task to another stream or back to the scheduled set. Messages written
+// before generations existed carry none and match generation 0.
+func isStaleMessage(msg redis.XMessage, t *task.Task) bool {
+	gen, _ := msg.Values["gen"].(string)
+	n, _ := strconv.ParseInt(gen, 10, 64)
+	return n != t.QueueGen
 }
 
 // Acknowledge marks a message as successfully processed, removing from pending list
 func (q *RedisQueue) Acknowledge(ctx context.Context, t *task.Task, messageID string) error {
-	streamName := t.Priority.StreamName(q.streamPrefix)
+	streamName := q.streamName(t.Tenant, t.Priority)
 	return q.client.XAck(ctx, streamName, q.consumerGroup, messageID).Err()
 }
 
@@ -252,16 +301,17 @@ func (q *RedisQueue) GetTask(ctx context.Context, taskID string) (*task.Task, er
 	if err := json.Unmarshal(data, &t); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
 	}
+	openTask(q.sealer, &t)
 
 	return &t, nil
 }
 
 // UpdateTask updates task data in storage
 func (q *RedisQueue) UpdateTask(ctx context.Context, t *task.Task) error {
-	taskKey := q.taskKey(t.ID)
-	data, err := json.Marshal(t)
+	taskKey := q.taskKeyFor(t)
+	data, err := q.encodeTask(t)
 	if err != nil {
-		return fmt.Errorf("failed to marshal task: %w", err)
+		return err
 	}
 
 	// If task is in terminal state and retention is configured, set TTL
@@ -275,10 +325,10 @@ func (q *RedisQueue) UpdateTask(ctx context.Context, t *task.Task) error {
 
 // UpdateTaskWithTTL updates task data with a specific TTL
 func (q *RedisQueue) UpdateTaskWithTTL(ctx context.Context, t *task.Task, ttl time.Duration) error {
-	taskKey := q.taskKey(t.ID)
-	data, err := json.Marshal(t)
+	taskKey := q.taskKeyFor(t)
+	data, err := q.encodeTask(t)
 	if err != nil {
-		return fmt.Errorf("failed to marshal task: %w", err)
+		return err
 	}
 
 	return q.client.Set(ctx, taskKey, data, ttl).Err()
@@ -296,7 +346,7 @@ func (q *RedisQueue) GetRetentionTTL() time.Duration {
 // Used when canceling a scheduled or retrying task so the scheduler does not
 // reactivate it later.
 func (q *RedisQueue) RemoveScheduledTask(ctx context.Context, taskID string) error {
-	return q.client.ZRem(ctx, "tasks:scheduled", taskID).Err()
+	return q.client.ZRem(ctx, q.scheduledKey(), taskID).Err()
 }
 
 // DeleteTask removes task data from storage
@@ -330,19 +380,12 @@ type QueueStats struct {
 // GetQueueStats returns accurate queue inspection data.
 // Queued = total stream backlog; PendingUnacked = consumer PEL.
 func (q *RedisQueue) GetQueueStats(ctx context.Context) (*QueueStats, error) {
-	priorities := []task.Priority{
-		task.PriorityCritical,
-		task.PriorityHigh,
-		task.PriorityNormal,
-		task.PriorityLow,
-	}
-
 	stats := &QueueStats{
 		Queues: make(map[string]*PriorityStats),
 	}
 
-	for _, p := range priorities {
-		streamName := p.StreamName(q.streamPrefix)
+	for _, p := range allPriorities {
+		streamName := q.streamName(q.tenant, p)
 		ps := &PriorityStats{}
 
 		// Stream length = total backlog
@@ -368,7 +411,7 @@ func (q *RedisQueue) GetQueueStats(ctx context.Context) (*QueueStats, error) {
 	}
 
 	// Scheduled sorted set count (both StateScheduled and StateRetrying tasks)
-	scheduled, err := q.client.ZCard(ctx, "tasks:scheduled").Result()
+	scheduled, err := q.client.ZCard(ctx, q.scheduledKey()).Result()
 	if err == nil {
 		stats.ScheduledCount = scheduled
 	}
@@ -381,15 +424,9 @@ func (q *RedisQueue) GetQueueStats(ctx context.Context) (*QueueStats, error) {
 // backpressure check only. Use GetQueueStats for accurate inspection.
 func (q *RedisQueue) GetQueueDepth(ctx context.Context) (map[task.Priority]int64, error) {
 	depths := make(map[task.Priority]int64)
-	priorities := []task.Priority{
-		task.PriorityCritical,
-		task.PriorityHigh,
-		task.PriorityNormal,
-		task.PriorityLow,
-	}
 
-	for _, p := range priorities {
-		streamName := p.StreamName(q.streamPrefix)
+	for _, p := range allPriorities {
+		streamName := q.streamName(q.tenant, p)
 		info, err := q.client.XInfoGroups(ctx, streamName).Result()
 		if err != nil {
 			continue
@@ -411,65 +448,74 @@ func (q *RedisQueue) ClaimOrphanedTasks(ctx context.Context, consumerID string)
 	var tasks []*task.Task
 	var messageIDs []string
 
-	priorities := []task.Priority{
-		task.PriorityCritical,
-		task.PriorityHigh,
-		task.PriorityNormal,
-		task.PriorityLow,
+	for _, tenant := range q.tenants.served {
+		for _, p := range allPriorities {
+			claimed, ids := q.claimOrphaned(ctx, consumerID, tenant, q.streamName(tenant, p))
+			tasks = append(tasks, claimed...)
+			messageIDs = append(messageIDs, ids...)
+		}
 	}
 
-	for _, p := range priorities {
-		streamName := p.StreamName(q.streamPrefix)
+	return tasks, messageIDs, nil
+}
 
-		// Get all pending messages in the consumer group
-		pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
-			Stream: streamName,
-			Group:  q.consumerGroup,
-			Start:  "-",
-			End:    "+",
-			Count:  100,
-		}).Result()
+// claimOrphaned claims the idle messages of one tenant stream
+func (q *RedisQueue) claimOrphaned(ctx context.Context, consumerID, tenant, streamName string) ([]*task.Task, []string) {
+	var tasks []*task.Task
+	var messageIDs []string
 
-		if err != nil {
-			continue
-		}
+	// Get all pending messages in the consumer group
+	pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: streamName,
+		Group:  q.consumerGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  100,
+	}).Result()
 
-		for _, p := range pending {
-			// Only claim messages that have been idle too long
-			if p.Idle < q.claimMinIdle {
-				continue
-			}
+	if err != nil {
+		return nil, nil
+	}
 
-			// XCLAIM transfers ownership of the message to this consumer
-			claimed, err := q.client.XClaim(ctx, &redis.XClaimArgs{
-				Stream:   streamName,
-				Group:    q.consumerGroup,
-				Consumer: consumerID,
-				MinIdle:  q.claimMinIdle,
-				Messages: []string{p.ID},
-			}).Result()
+	for _, p := range pending {
+		// Only claim messages that have been idle too long
+		if p.Idle < q.claimMinIdle {
+			continue
+		}
 
-			if err != nil || len(claimed) == 0 {
-				continue
-			}
+		// XCLAIM transfers ownership of the message to this consumer
+		claimed, err := q.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   streamName,
+			Group:    q.consumerGroup,
+			Consumer: consumerID,
+			MinIdle:  q.claimMinIdle,
+			Messages: []string{p.ID},
+		}).Result()
 
-			msg := claimed[0]
-			taskID, ok := msg.Values["task_id"].(string)
-			if !ok {
-				continue
-			}
+		if err != nil || len(claimed) == 0 {
+			continue
+		}
 
-			t, err := q.GetTask(ctx, taskID)
-			if err != nil {
-				continue
-			}
+		msg := claimed[0]
+		taskID, ok := msg.Values["task_id"].(string)
+		if !ok {
+			continue
+		}
 
-			tasks = append(tasks, t)
-			messageIDs = append(messageIDs, msg.ID)
+		t, err := q.ForTenant(tenant).GetTask(ctx, taskID)
+		if err != nil {
+			continue
 		}
+		if isStaleMessage(msg, t) {
+			q.client.XAck(ctx, streamName, q.consumerGroup, msg.ID)
+			continue
+		}
+
+		tasks = append(tasks, t)
+		messageIDs = append(messageIDs, msg.ID)
 	}
 
-	return tasks, messageIDs, nil
+	return tasks, messageIDs
 }
 
 // Close closes the Redis connection
@@ -482,7 +528,12 @@ func (q *RedisQueue) Client() *redis.Client {
 	return q.client
 }
 
-// taskKey generates the storage key for a task
+// taskKey generates the storage key for a task in this queue's tenant
 func (q *RedisQueue) taskKey(taskID string) string {
-	return fmt.Sprintf("task:%s", taskID)
+	return tenantKey(q.tenant, fmt.Sprintf("task:%s", taskID))
+}
+
+// taskKeyFor generates the storage key for a task in its own tenant
+func (q *RedisQueue) taskKeyFor(t *task.Task) string {
+	return tenantKey(t.Tenant, fmt.Sprintf("task:%s", t.ID))
 }
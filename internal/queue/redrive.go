@@ -0,0 +1,723 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+const (
+	redriveJobsKey   = "dlq:redrive:jobs" // ZSET of job IDs scored by creation time (ms)
+	redriveJobPrefix = "dlq:redrive:job:"
+
+	redriveLeaseTTL     = 30 * time.Second
+	redrivePollInterval = 1 * time.Second
+	redriveRetention    = 7 * 24 * time.Hour
+	redrivePageSize     = 100
+	redriveMaxOutcomes  = 10000
+	redriveMinRate      = 0.1 // Keeps the gap between entries well inside the lease TTL
+	redriveMaxRate      = 1000.0
+)
+
+// RedriveState is the lifecycle state of a redrive job
+type RedriveState string
+
+const (
+	RedriveRunning   RedriveState = "running"
+	RedrivePaused    RedriveState = "paused"
+	RedriveCanceled  RedriveState = "canceled"
+	RedriveCompleted RedriveState = "completed"
+)
+
+// IsFinal reports whether the job will not process any more entries
+func (s RedriveState) IsFinal() bool {
+	return s == RedriveCanceled || s == RedriveCompleted
+}
+
+// Control signals written by the API and observed by the job runner.
+// Keeping them out of the job document avoids racing the runner's writes.
+const (
+	redriveControlPause  = "pause"
+	redriveControlCancel = "cancel"
+)
+
+// Redrive errors
+var (
+	ErrRedriveNotFound     = errors.New("redrive job not found")
+	ErrRedriveInvalidState = errors.New("redrive job is not in a valid state for this operation")
+	ErrRedriveInvalidPatch = errors.New("invalid redrive patch")
+)
+
+// RedrivePatch holds JSON merge patches (RFC 7396) applied to each task before requeue.
+// A null value removes a key; objects are merged recursively.
+type RedrivePatch struct {
+	Payload  map[string]interface{} `json:"payload,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"` // Values must be strings or null
+}
+
+// RedriveRequest describes a new redrive job
+type RedriveRequest struct {
+	Tenant        string // DLQ to redrive ("" = default)
+	Filter        DLQFilter
+	Patch         RedrivePatch
+	Priority      *task.Priority // Target queue; nil keeps each task's priority
+	RatePerSecond float64        // 0 uses the manager default
+}
+
+// RedriveJob is a server-side job that requeues matching DLQ entries at a fixed rate
+type RedriveJob struct {
+	ID            string         `json:"id"`
+	Tenant        string         `json:"tenant,omitempty"`
+	State         RedriveState   `json:"state"`
+	Filter        DLQFilter      `json:"filter"`
+	Patch         RedrivePatch   `json:"patch,omitempty"`
+	Priority      *task.Priority `json:"priority,omitempty"`
+	RatePerSecond float64        `json:"rate_per_second"`
+	// Cursor is the last DLQ message examined; a restarted runner resumes after it
+	Cursor     string     `json:"cursor,omitempty"`
+	Processed  int64      `json:"processed"`
+	Requeued   int64      `json:"requeued"`
+	Failed     int64      `json:"failed"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// RedriveOutcome records what happened to one DLQ entry
+type RedriveOutcome struct {
+	TaskID    string    `json:"task_id"`
+	MessageID string    `json:"message_id"`
+	Status    string    `json:"status"` // "requeued" or "failed"
+	Error     string    `json:"error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// redriveScript moves a DLQ entry back to its queue: it deletes the stream entry
+// and, only if it was still there, drops the task from the DLQ set and index,
+// stores the task and adds it to its priority stream.
+//
+// KEYS: DLQ stream, DLQ set, DLQ index, task key. ARGV: message ID, task ID,
+// task data, type, queue generation, stream. Returns 1 if requeued, 0 if the
+// entry was already gone.
+var redriveScript = redis.NewScript(`
+if redis.call("XDEL", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if redis.call("HGET", KEYS[3], ARGV[2]) == ARGV[1] then
+	redis.call("HDEL", KEYS[3], ARGV[2])
+	redis.call("SREM", KEYS[2], ARGV[2])
+end
+redis.call("SET", KEYS[4], ARGV[3])
+redis.call("XADD", ARGV[6], "*", "task_id", ARGV[2], "type", ARGV[4], "gen", ARGV[5])
+return 1
+`)
+
+// RedriveManager creates redrive jobs and runs them in the background.
+// Job state lives in Redis and each job is guarded by a lease, so any API
+// replica can pick up a job after a restart without processing it twice.
+type RedriveManager struct {
+	client      *redis.Client
+	queue       *RedisQueue
+	dlq         *DLQ
+	defaultRate float64
+	owner       string
+
+	mu      sync.Mutex
+	ctx     context.Context
+	running map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewRedriveManager creates a redrive manager
+func NewRedriveManager(client *redis.Client, q *RedisQueue, dlq *DLQ, defaultRate float64) *RedriveManager {
+	if defaultRate <= 0 {
+		defaultRate = 10
+	}
+	return &RedriveManager{
+		client:      client,
+		queue:       q,
+		dlq:         dlq,
+		defaultRate: defaultRate,
+		owner:       uuid.New().String(),
+		running:     make(map[string]context.CancelFunc),
+	}
+}
+
+// Start resumes unfinished jobs left by a previous process
+func (m *RedriveManager) Start(ctx context.Context) {
+	m.mu.Lock()
+	m.ctx = ctx
+	m.mu.Unlock()
+
+	jobs, err := m.List(ctx, 0)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to load redrive jobs")
+		return
+	}
+	for _, job := range jobs {
+		if !job.State.IsFinal() {
+			m.launch(job.ID)
+		}
+	}
+}
+
+// Stop stops local runners; their jobs stay resumable
+func (m *RedriveManager) Stop() {
+	m.mu.Lock()
+	for _, cancel := range m.running {
+		cancel()
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+}
+
+// Create validates a request, persists the job and starts running it
+func (m *RedriveManager) Create(ctx context.Context, req RedriveRequest) (*RedriveJob, error) {
+	if err := validateRedrivePatch(req.Patch); err != nil {
+		return nil, err
+	}
+
+	rate := req.RatePerSecond
+	if rate <= 0 {
+		rate = m.defaultRate
+	}
+	rate = math.Max(redriveMinRate, math.Min(rate, redriveMaxRate))
+
+	now := time.Now().UTC()
+
+	// Only redrive entries that exist now, so tasks that fail again and
+	// re-enter the DLQ are not picked up by the same job
+	filter := req.Filter
+	if filter.AddedBefore.IsZero() || filter.AddedBefore.After(now) {
+		filter.AddedBefore = now
+	}
+
+	job := &RedriveJob{
+		ID:            uuid.New().String(),
+		Tenant:        req.Tenant,
+		State:         RedriveRunning,
+		Filter:        filter,
+		Patch:         req.Patch,
+		Priority:      req.Priority,
+		RatePerSecond: rate,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal redrive job: %w", err)
+	}
+
+	pipe := m.client.TxPipeline()
+	pipe.Set(ctx, redriveJobKey(job.ID), data, 0)
+	pipe.ZAdd(ctx, redriveJobsKey, redis.Z{Score: float64(now.UnixMilli()), Member: job.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to store redrive job: %w", err)
+	}
+
+	logger.Info().
+		Str("job_id", job.ID).
+		Float64("rate", rate).
+		Msg("DLQ redrive job created")
+
+	m.launch(job.ID)
+	return job, nil
+}
+
+// Get returns a job by ID
+func (m *RedriveManager) Get(ctx context.Context, jobID string) (*RedriveJob, error) {
+	data, err := m.client.Get(ctx, redriveJobKey(jobID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrRedriveNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redrive job: %w", err)
+	}
+
+	var job RedriveJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal redrive job: %w", err)
+	}
+	return &job, nil
+}
+
+// List returns jobs newest first; limit 0 returns all
+func (m *RedriveManager) List(ctx context.Context, limit int64) ([]*RedriveJob, error) {
+	ids, err := m.client.ZRevRange(ctx, redriveJobsKey, 0, limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list redrive jobs: %w", err)
+	}
+
+	jobs := make([]*RedriveJob, 0, len(ids))
+	for _, id := range ids {
+		job, err := m.Get(ctx, id)
+		if err == ErrRedriveNotFound {
+			// Finished job whose retention expired
+			m.client.ZRem(ctx, redriveJobsKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Outcomes returns per-entry outcomes in processing order
+func (m *RedriveManager) Outcomes(ctx context.Context, jobID string, offset, limit int64) ([]RedriveOutcome, error) {
+	if _, err := m.Get(ctx, jobID); err != nil {
+		return nil, err
+	}
+
+	raw, err := m.client.LRange(ctx, redriveJobKey(jobID)+":outcomes", offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redrive outcomes: %w", err)
+	}
+
+	outcomes := make([]RedriveOutcome, 0, len(raw))
+	for _, item := range raw {
+		var o RedriveOutcome
+		if err := json.Unmarshal([]byte(item), &o); err == nil {
+			outcomes = append(outcomes, o)
+		}
+	}
+	return outcomes, nil
+}
+
+// Pause asks the runner to stop after the current entry
+func (m *RedriveManager) Pause(ctx context.Context, jobID string) error {
+	job, err := m.Get(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if job.State != RedriveRunning {
+		return ErrRedriveInvalidState
+	}
+	return m.client.Set(ctx, redriveJobKey(jobID)+":control", redriveControlPause, 0).Err()
+}
+
+// Resume continues a paused job from its cursor
+func (m *RedriveManager) Resume(ctx context.Context, jobID string) error {
+	job, err := m.Get(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if job.State.IsFinal() {
+		return ErrRedriveInvalidState
+	}
+	if err := m.client.Del(ctx, redriveJobKey(jobID)+":control").Err(); err != nil {
+		return err
+	}
+	m.launch(jobID)
+	return nil
+}
+
+// Cancel stops a job permanently; entries already requeued stay requeued
+func (m *RedriveManager) Cancel(ctx context.Context, jobID string) error {
+	job, err := m.Get(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if job.State.IsFinal() {
+		return ErrRedriveInvalidState
+	}
+	if err := m.client.Set(ctx, redriveJobKey(jobID)+":control", redriveControlCancel, 0).Err(); err != nil {
+		return err
+	}
+	// Make sure some runner observes the signal even if none is active
+	m.launch(jobID)
+	return nil
+}
+
+// launch starts a local runner for a job unless one is already running here
+func (m *RedriveManager) launch(jobID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ctx == nil {
+		return // Not started; Start will pick the job up
+	}
+	if _, ok := m.running[jobID]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.running[jobID] = cancel
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			delete(m.running, jobID)
+			m.mu.Unlock()
+			cancel()
+		}()
+		m.run(ctx, jobID)
+	}()
+}
+
+// run processes a job while holding its lease
+func (m *RedriveManager) run(ctx context.Context, jobID string) {
+	leaseKey := redriveJobKey(jobID) + ":lease"
+	acquired, err := m.client.SetNX(ctx, leaseKey, m.owner, redriveLeaseTTL).Result()
+	if err != nil || !acquired {
+		return // Another replica is running this job
+	}
+	defer releaseLease(m.client, leaseKey, m.owner)
+
+	log := logger.Get().With().Str("job_id", jobID).Logger()
+	pacer := newRedrivePacer(0)
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if !renewLease(ctx, m.client, leaseKey, m.owner, redriveLeaseTTL) {
+			log.Warn().Msg("lost redrive job lease")
+			return
+		}
+
+		job, err := m.Get(ctx, jobID)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to load redrive job")
+			return
+		}
+		if job.State.IsFinal() {
+			return
+		}
+		pacer.setRate(job.RatePerSecond)
+
+		if m.applyControl(ctx, job) {
+			if job.State.IsFinal() {
+				return
+			}
+			sleepCtx(ctx, redrivePollInterval)
+			continue
+		}
+
+		page, err := m.dlq.ForTenant(job.Tenant).ListPage(ctx, job.Filter, job.Cursor, redrivePageSize)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to read DLQ for redrive")
+			sleepCtx(ctx, redrivePollInterval)
+			continue
+		}
+
+		if len(page.Entries) == 0 {
+			if page.NextCursor == "" {
+				m.finish(ctx, job, RedriveCompleted)
+				log.Info().
+					Int64("requeued", job.Requeued).
+					Int64("failed", job.Failed).
+					Msg("DLQ redrive job completed")
+				return
+			}
+			job.Cursor = page.NextCursor
+			m.save(ctx, job, nil)
+			continue
+		}
+
+		for i := range page.Entries {
+			if err := pacer.wait(ctx); err != nil {
+				return
+			}
+			if !renewLease(ctx, m.client, leaseKey, m.owner, redriveLeaseTTL) {
+				log.Warn().Msg("lost redrive job lease")
+				return
+			}
+			if m.controlPending(ctx, jobID) {
+				break // Handled at the top of the loop
+			}
+
+			entry := &page.Entries[i]
+			outcome := m.redriveEntry(ctx, job, entry)
+
+			job.Processed++
+			if outcome.Status == "requeued" {
+				job.Requeued++
+			} else {
+				job.Failed++
+			}
+			job.Cursor = entry.MessageID
+			m.save(ctx, job, &outcome)
+		}
+	}
+}
+
+// applyControl handles pause/cancel signals. It returns true if the runner
+// should not process entries on this iteration.
+func (m *RedriveManager) applyControl(ctx context.Context, job *RedriveJob) bool {
+	control, err := m.client.Get(ctx, redriveJobKey(job.ID)+":control").Result()
+	if err != nil && err != redis.Nil {
+		return true
+	}
+
+	switch control {
+	case redriveControlCancel:
+		m.finish(ctx, job, RedriveCanceled)
+		logger.Info().Str("job_id", job.ID).Msg("DLQ redrive job canceled")
+		return true
+	case redriveControlPause:
+		if job.State != RedrivePaused {
+			job.State = RedrivePaused
+			m.save(ctx, job, nil)
+			logger.Info().Str("job_id", job.ID).Msg("DLQ redrive job paused")
+		}
+		return true
+	default:
+		if job.State == RedrivePaused {
+			job.State = RedriveRunning
+			m.save(ctx, job, nil)
+			logger.Info().Str("job_id", job.ID).Msg("DLQ redrive job resumed")
+		}
+		return false
+	}
+}
+
+func (m *RedriveManager) controlPending(ctx context.Context, jobID string) bool {
+	n, err := m.client.Exists(ctx, redriveJobKey(jobID)+":control").Result()
+	return err == nil && n > 0
+}
+
+// redriveEntry applies the job's edits to one entry and requeues it
+func (m *RedriveManager) redriveEntry(ctx context.Context, job *RedriveJob, entry *DLQEntry) RedriveOutcome {
+	outcome := RedriveOutcome{
+		TaskID:    entry.Task.ID,
+		MessageID: entry.MessageID,
+		At:        time.Now().UTC(),
+	}
+
+	t := entry.Task
+	if err := applyRedriveEdits(t, job); err != nil {
+		outcome.Status = "failed"
+		outcome.Error = err.Error()
+		return outcome
+	}
+
+	sm := task.NewStateMachine(t)
+	if err := sm.Requeue(); err != nil {
+		outcome.Status = "failed"
+		outcome.Error = err.Error()
+		return outcome
+	}
+
+	requeued, err := m.requeue(ctx, m.dlq.ForTenant(job.Tenant), t, entry.MessageID)
+	if err != nil {
+		outcome.Status = "failed"
+		outcome.Error = err.Error()
+		return outcome
+	}
+	if !requeued {
+		outcome.Status = "failed"
+		outcome.Error = "entry is no longer in the DLQ"
+		return outcome
+	}
+
+	outcome.Status = "requeued"
+	return outcome
+}
+
+// requeue removes a DLQ entry and enqueues its task in one step, so a runner
+// that crashes and resumes cannot requeue the entry twice. It returns false if
+// the entry was already gone from the DLQ.
+func (m *RedriveManager) requeue(ctx context.Context, dlq *DLQ, t *task.Task, messageID string) (bool, error) {
+	data, err := m.queue.encodeTask(t)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := redriveScript.Run(ctx, m.client,
+		[]string{dlq.streamKey(), dlq.setKey(), dlq.indexKey(), m.queue.taskKeyFor(t)},
+		messageID, t.ID, string(data), t.Type, t.QueueGen, m.queue.streamName(t.Tenant, t.Priority)).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue task: %w", err)
+	}
+	return n == 1, nil
+}
+
+// save persists job progress and appends an outcome in one transaction
+func (m *RedriveManager) save(ctx context.Context, job *RedriveJob, outcome *RedriveOutcome) {
+	job.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+
+	key := redriveJobKey(job.ID)
+	pipe := m.client.TxPipeline()
+	pipe.Set(ctx, key, data, 0)
+	if outcome != nil {
+		if od, err := json.Marshal(outcome); err == nil {
+			pipe.RPush(ctx, key+":outcomes", od)
+			pipe.LTrim(ctx, key+":outcomes", -redriveMaxOutcomes, -1)
+		}
+	}
+	if job.State.IsFinal() {
+		pipe.Expire(ctx, key, redriveRetention)
+		pipe.Expire(ctx, key+":outcomes", redriveRetention)
+		pipe.Del(ctx, key+":control")
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Error().Err(err).Str("job_id", job.ID).Msg("failed to save redrive job")
+	}
+}
+
+func (m *RedriveManager) finish(ctx context.Context, job *RedriveJob, state RedriveState) {
+	now := time.Now().UTC()
+	job.State = state
+	job.FinishedAt = &now
+	m.save(ctx, job, nil)
+}
+
+// applyRedriveEdits patches payload and metadata and sets the target priority
+func applyRedriveEdits(t *task.Task, job *RedriveJob) error {
+	if len(job.Patch.Payload) > 0 && t.Sealed != nil {
+		// The patch would be stored in plaintext and lost when the task is opened
+		return fmt.Errorf("%w: payload is encrypted with a key this server does not have", ErrRedriveInvalidPatch)
+	}
+	if len(job.Patch.Payload) > 0 {
+		t.Payload = mergePatch(t.Payload, job.Patch.Payload)
+	}
+	if len(job.Patch.Metadata) > 0 {
+		if t.Metadata == nil {
+			t.Metadata = make(map[string]string)
+		}
+		for k, v := range job.Patch.Metadata {
+			switch val := v.(type) {
+			case nil:
+				delete(t.Metadata, k)
+			case string:
+				t.Metadata[k] = val
+			default:
+				return fmt.Errorf("%w: metadata %q must be a string or null", ErrRedriveInvalidPatch, k)
+			}
+		}
+	}
+	if job.Priority != nil {
+		t.Priority = *job.Priority
+	}
+	return nil
+}
+
+func validateRedrivePatch(p RedrivePatch) error {
+	for k, v := range p.Metadata {
+		if _, ok := v.(string); !ok && v != nil {
+			return fmt.Errorf("%w: metadata %q must be a string or null", ErrRedriveInvalidPatch, k)
+		}
+	}
+	return nil
+}
+
+// mergePatch applies an RFC 7396 JSON merge patch, returning a new map
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(target)+len(patch))
+	for k, v := range target {
+		result[k] = v
+	}
+
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		if patchObj, ok := v.(map[string]interface{}); ok {
+			targetObj, _ := result[k].(map[string]interface{})
+			result[k] = mergePatch(targetObj, patchObj)
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+func redriveJobKey(jobID string) string {
+	return redriveJobPrefix + jobID
+}
+
+// redrivePacer spaces requeues evenly at the job's rate
+type redrivePacer struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newRedrivePacer(rate float64) *redrivePacer {
+	p := &redrivePacer{}
+	p.setRate(rate)
+	return p
+}
+
+func (p *redrivePacer) setRate(rate float64) {
+	if rate <= 0 {
+		p.interval = 0
+		return
+	}
+	p.interval = time.Duration(float64(time.Second) / rate)
+}
+
+// delay returns how long to wait before the next requeue and reserves the slot
+func (p *redrivePacer) delay(now time.Time) time.Duration {
+	if p.next.Before(now) {
+		p.next = now
+	}
+	d := p.next.Sub(now)
+	p.next = p.next.Add(p.interval)
+	return d
+}
+
+func (p *redrivePacer) wait(ctx context.Context) error {
+	if d := p.delay(time.Now()); d > 0 {
+		return sleepCtx(ctx, d)
+	}
+	return ctx.Err()
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// renewLeaseScript extends a lease only if it is still held by owner
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes a lease only if it is still held by owner
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func renewLease(ctx context.Context, client *redis.Client, key, owner string, ttl time.Duration) bool {
+	n, err := renewLeaseScript.Run(ctx, client, []string{key}, owner, ttl.Milliseconds()).Int()
+	return err == nil && n == 1
+}
+
+func releaseLease(client *redis.Client, key, owner string) {
+	// Use a fresh context: the runner's context is usually canceled by now
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	releaseLeaseScript.Run(ctx, client, []string{key}, owner)
+}
@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{
+		"to":   "old@example.com",
+		"cc":   "boss@example.com",
+		"opts": map[string]interface{}{"html": true, "track": true},
+	}
+	patch := map[string]interface{}{
+		"to":   "new@example.com",
+		"cc":   nil,
+		"opts": map[string]interface{}{"track": nil, "retries": float64(2)},
+		"new":  map[string]interface{}{"a": nil, "b": "x"},
+	}
+
+	result := mergePatch(target, patch)
+
+	assert.Equal(t, "new@example.com", result["to"])
+	assert.NotContains(t, result, "cc")
+	assert.Equal(t, map[string]interface{}{"html": true, "retries": float64(2)}, result["opts"])
+	assert.Equal(t, map[string]interface{}{"b": "x"}, result["new"], "nulls are stripped from new objects")
+
+	// Target is not modified
+	assert.Equal(t, "old@example.com", target["to"])
+	assert.Contains(t, target, "cc")
+}
+
+func TestApplyRedriveEdits(t *testing.T) {
+	high := task.PriorityHigh
+	job := &RedriveJob{
+		Patch: RedrivePatch{
+			Payload:  map[string]interface{}{"endpoint": "v2"},
+			Metadata: map[string]interface{}{"redriven": "true", "stale": nil},
+		},
+		Priority: &high,
+	}
+
+	tsk := task.New("webhook", map[string]interface{}{"endpoint": "v1", "id": "42"}, task.PriorityLow)
+	tsk.Metadata["stale"] = "yes"
+
+	require.NoError(t, applyRedriveEdits(tsk, job))
+	assert.Equal(t, "v2", tsk.Payload["endpoint"])
+	assert.Equal(t, "42", tsk.Payload["id"])
+	assert.Equal(t, "true", tsk.Metadata["redriven"])
+	assert.NotContains(t, tsk.Metadata, "stale")
+	assert.Equal(t, task.PriorityHigh, tsk.Priority)
+}
+
+func TestApplyRedriveEdits_Sealed(t *testing.T) {
+	sealed := task.New("webhook", nil, task.PriorityLow)
+	sealed.Sealed = &task.Sealed{KeyID: "k-retired"}
+
+	err := applyRedriveEdits(sealed, &RedriveJob{Patch: RedrivePatch{Payload: map[string]interface{}{"endpoint": "v2"}}})
+	assert.ErrorIs(t, err, ErrRedriveInvalidPatch)
+	assert.Nil(t, sealed.Payload, "no plaintext is stored next to the ciphertext")
+
+	// Metadata and priority edits do not touch the payload
+	require.NoError(t, applyRedriveEdits(sealed, &RedriveJob{Patch: RedrivePatch{Metadata: map[string]interface{}{"a": "b"}}}))
+	assert.Equal(t, "b", sealed.Metadata["a"])
+}
+
+func TestRedriveEntry_RequeuesOnce(t *testing.T) {
+	dlqStream := tenantKey("", dlqStreamName)
+	message := fakeDLQMessage(t, "1705312800000-0", time.Now())
+	streams := map[string][]redis.XMessage{dlqStream: {message}}
+	client := newFakeStreamsClient(t, streams)
+
+	q := &RedisQueue{client: client, streamPrefix: "tasks"}
+	m := NewRedriveManager(client, q, NewDLQ(client), 0)
+	job := &RedriveJob{ID: "job-1"}
+
+	entry, ok := parseDLQMessage(message)
+	require.True(t, ok)
+	entry.Task.State = task.StateDeadLetter
+	outcome := m.redriveEntry(t.Context(), job, entry)
+	assert.Equal(t, "requeued", outcome.Status, outcome.Error)
+	assert.Empty(t, streams[dlqStream])
+
+	// A runner resuming from a cursor saved before the first attempt
+	entry, _ = parseDLQMessage(message)
+	entry.Task.State = task.StateDeadLetter
+	outcome = m.redriveEntry(t.Context(), job, entry)
+	assert.Equal(t, "failed", outcome.Status)
+	assert.Len(t, streams[q.streamName("", entry.Task.Priority)], 1, "the task is queued once")
+}
+
+func TestValidateRedrivePatch(t *testing.T) {
+	assert.NoError(t, validateRedrivePatch(RedrivePatch{Metadata: map[string]interface{}{"a": "b", "c": nil}}))
+
+	err := validateRedrivePatch(RedrivePatch{Metadata: map[string]interface{}{"a": float64(1)}})
+	assert.ErrorIs(t, err, ErrRedriveInvalidPatch)
+}
+
+func TestRedriveState_IsFinal(t *testing.T) {
+	assert.False(t, RedriveRunning.IsFinal())
+	assert.False(t, RedrivePaused.IsFinal())
+	assert.True(t, RedriveCanceled.IsFinal())
+	assert.True(t, RedriveCompleted.IsFinal())
+}
+
+func TestRedrivePacer(t *testing.T) {
+	p := newRedrivePacer(4) // one every 250ms
+	now := time.Now()
+
+	assert.Equal(t, time.Duration(0), p.delay(now), "first requeue is immediate")
+	assert.Equal(t, 250*time.Millisecond, p.delay(now))
+	assert.Equal(t, 500*time.Millisecond, p.delay(now))
+
+	// After an idle gap the pacer does not accumulate a burst
+	later := now.Add(10 * time.Second)
+	assert.Equal(t, time.Duration(0), p.delay(later))
+	assert.Equal(t, 250*time.Millisecond, p.delay(later))
+}
+
+func TestRedriveJobKey(t *testing.T) {
+	assert.Equal(t, "dlq:redrive:job:abc", redriveJobKey("abc"))
+}
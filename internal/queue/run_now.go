@@ -0,0 +1,197 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// runNowScanCount is the ZSCAN page size used by RunNowMatching
+const runNowScanCount = 200
+
+// ErrTaskNotDeferred is returned when run-now targets a task that is not waiting
+// in the scheduled set
+var ErrTaskNotDeferred = errors.New("task is not scheduled or retrying")
+
+// runNowScript queues a deferred task immediately: it writes the task only if its
+// stored data is unchanged since it was read, removes it from the scheduled set
+// and adds it to its priority stream.
+//
+// KEYS: task key, scheduled set. ARGV: data read, new data, task ID, type,
+// queue generation, stream. Returns 1 on success, 0 if the task changed.
+var runNowScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2])
+redis.call("ZREM", KEYS[2], ARGV[3])
+redis.call("XADD", ARGV[6], "*", "task_id", ARGV[3], "type", ARGV[4], "gen", ARGV[5])
+return 1
+`)
+
+// RunNowFilter selects deferred tasks for RunNowMatching
+type RunNowFilter struct {
+	TaskType string
+	State    *task.State // StateScheduled or StateRetrying; nil matches both
+	Limit    int         // 0 = no limit
+}
+
+// Matches reports whether a task satisfies every set field of the filter
+func (f RunNowFilter) Matches(t *task.Task) bool {
+	if t.State != task.StateScheduled && t.State != task.StateRetrying {
+		return false
+	}
+	if f.State != nil && t.State != *f.State {
+		return false
+	}
+	if f.TaskType != "" && t.Type != f.TaskType {
+		return false
+	}
+	return true
+}
+
+// RunNowResult summarizes a bulk run-now
+type RunNowResult struct {
+	Matched   int      `json:"matched"`
+	Activated int      `json:"activated"`
+	Skipped   int      `json:"skipped"` // Changed concurrently (e.g. activated by the scheduler)
+	TaskIDs   []string `json:"task_ids"`
+}
+
+// RunNow moves a scheduled or retrying task to its priority stream without
+// waiting for its due time, optionally overriding its priority
+func (q *RedisQueue) RunNow(ctx context.Context, taskID string, priority *task.Priority) (*task.Task, error) {
+	for attempt := 0; attempt < taskEditAttempts; attempt++ {
+		raw, err := q.client.Get(ctx, q.taskKey(taskID)).Result()
+		if err == redis.Nil {
+			return nil, task.ErrTaskNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+
+		var t task.Task
+		if err := json.Unmarshal([]byte(raw), &t); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+		}
+		if t.State != task.StateScheduled && t.State != task.StateRetrying {
+			return &t, ErrTaskNotDeferred
+		}
+
+		ok, err := q.runNow(ctx, raw, &t, priority)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			openTask(q.sealer, &t)
+			return &t, nil
+		}
+	}
+
+	return nil, ErrTaskEditConflict
+}
+
+// RunNowMatching runs every deferred task matching the filter immediately.
+// Tasks that change while being processed are skipped, not retried.
+func (q *RedisQueue) RunNowMatching(ctx context.Context, filter RunNowFilter, priority *task.Priority) (RunNowResult, error) {
+	result := RunNowResult{TaskIDs: []string{}}
+	var cursor uint64
+
+	for {
+		// ZSCAN tolerates entries being removed while it iterates; a task it
+		// returns twice is no longer deferred the second time and is skipped
+		members, next, err := q.client.ZScan(ctx, q.scheduledKey(), cursor, "", runNowScanCount).Result()
+		if err != nil {
+			return result, fmt.Errorf("failed to scan scheduled tasks: %w", err)
+		}
+
+		// Members and scores are interleaved
+		keys := make([]string, 0, len(members)/2)
+		for i := 0; i < len(members); i += 2 {
+			keys = append(keys, q.taskKey(members[i]))
+		}
+
+		if len(keys) > 0 {
+			values, err := q.client.MGet(ctx, keys...).Result()
+			if err != nil {
+				return result, fmt.Errorf("failed to get task data: %w", err)
+			}
+
+			for _, v := range values {
+				raw, ok := v.(string)
+				if !ok {
+					continue
+				}
+				var t task.Task
+				if err := json.Unmarshal([]byte(raw), &t); err != nil || !filter.Matches(&t) {
+					continue
+				}
+
+				result.Matched++
+				ok, err := q.runNow(ctx, raw, &t, priority)
+				if err != nil {
+					return result, err
+				}
+				if !ok {
+					result.Skipped++
+				} else {
+					result.Activated++
+					result.TaskIDs = append(result.TaskIDs, t.ID)
+				}
+
+				if filter.Limit > 0 && result.Matched >= filter.Limit {
+					return result, nil
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return result, nil
+		}
+	}
+}
+
+// runNow transitions a deferred task read as raw to pending and queues it.
+// It returns false if the stored task changed in the meantime.
+func (q *RedisQueue) runNow(ctx context.Context, raw string, t *task.Task, priority *task.Priority) (bool, error) {
+	if err := prepareRunNow(t, priority, time.Now().UTC()); err != nil {
+		return false, err
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	ok, err := runNowScript.Run(ctx, q.client, []string{q.taskKey(t.ID), q.scheduledKey()},
+		raw, string(data), t.ID, t.Type, t.QueueGen, q.streamName(t.Tenant, t.Priority)).Bool()
+	if err != nil {
+		return false, fmt.Errorf("failed to queue task: %w", err)
+	}
+	if ok {
+		logger.Info().Str("task_id", t.ID).Str("priority", t.Priority.String()).Msg("deferred task queued to run now")
+	}
+	return ok, nil
+}
+
+// prepareRunNow moves a deferred task to pending through the state machine and
+// applies the priority override
+func prepareRunNow(t *task.Task, priority *task.Priority, now time.Time) error {
+	if err := task.NewStateMachine(t).Transition(task.StatePending); err != nil {
+		return fmt.Errorf("%w: %s", ErrTaskNotDeferred, t.State)
+	}
+	if priority != nil {
+		t.Priority = *priority
+	}
+	t.Version++
+	t.UpdatedAt = now
+	return nil
+}
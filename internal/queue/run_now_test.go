@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func TestRunNowFilter_Matches(t *testing.T) {
+	retrying := task.StateRetrying
+
+	newTask := func(taskType string, state task.State) *task.Task {
+		tsk := task.New(taskType, nil, task.PriorityNormal)
+		tsk.State = state
+		return tsk
+	}
+
+	all := RunNowFilter{}
+	assert.True(t, all.Matches(newTask("email", task.StateScheduled)))
+	assert.True(t, all.Matches(newTask("email", task.StateRetrying)))
+	assert.False(t, all.Matches(newTask("email", task.StatePending)), "only deferred tasks match")
+	assert.False(t, all.Matches(newTask("email", task.StateCanceled)))
+
+	f := RunNowFilter{TaskType: "email", State: &retrying}
+	assert.True(t, f.Matches(newTask("email", task.StateRetrying)))
+	assert.False(t, f.Matches(newTask("email", task.StateScheduled)))
+	assert.False(t, f.Matches(newTask("sms", task.StateRetrying)))
+}
+
+func TestPrepareRunNow(t *testing.T) {
+	now := time.Now().UTC()
+	critical := task.PriorityCritical
+
+	tsk := task.New("email", nil, task.PriorityLow)
+	tsk.State = task.StateRetrying
+	require.NoError(t, prepareRunNow(tsk, &critical, now))
+	assert.Equal(t, task.StatePending, tsk.State)
+	assert.Equal(t, task.PriorityCritical, tsk.Priority)
+	assert.Equal(t, int64(2), tsk.Version)
+	assert.Zero(t, tsk.QueueGen, "deferred tasks have no stream message to invalidate")
+
+	tsk = task.New("email", nil, task.PriorityLow)
+	tsk.State = task.StateScheduled
+	require.NoError(t, prepareRunNow(tsk, nil, now))
+	assert.Equal(t, task.StatePending, tsk.State)
+	assert.Equal(t, task.PriorityLow, tsk.Priority)
+
+	tsk = task.New("email", nil, task.PriorityLow)
+	tsk.State = task.StateCompleted
+	assert.ErrorIs(t, prepareRunNow(tsk, nil, now), ErrTaskNotDeferred)
+}
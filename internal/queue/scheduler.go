@@ -3,63 +3,249 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/events"
 	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/metrics"
 	"github.com/maumercado/task-queue-go/internal/task"
 )
 
 const (
-	scheduledSetKey       = "tasks:scheduled"
-	schedulerLockKey      = "scheduler:lock"
+	scheduledSetKey      = "tasks:scheduled"
+	schedulerLockKey     = "scheduler:lock"
+	schedulerFenceKey    = "scheduler:fence" // incremented on every leadership change
+	schedulerWakeChannel = "scheduler:wake"
+
+	// schedulerPollInterval is the longest the scheduler sleeps between polls.
+	// It normally wakes earlier: when the next task is due or an earlier one is added.
+	// It is kept well below the lock TTL so the leader renews its lease on every poll.
 	schedulerPollInterval = 1 * time.Second
 	schedulerLockTTL      = 5 * time.Second
+
+	// schedulerBatchSize is how many due tasks one activation script moves
+	schedulerBatchSize = 100
+	// schedulerMaxPerPoll caps the tasks activated while holding the lock, so a
+	// large backlog is drained over several polls instead of outliving the lock
+	schedulerMaxPerPoll = 2000
+
+	// legacyScoreLimit separates scores written in Unix seconds by older
+	// releases (about 1.7e9) from millisecond scores (about 1.7e12)
+	legacyScoreLimit = 1e11
 )
 
-// Scheduler polls the scheduled tasks set and moves due tasks to priority queues
+// errSchedulerFenced is returned when an activation is rejected because this
+// instance no longer holds the scheduler lease
+var errSchedulerFenced = errors.New("scheduler lease lost")
+
+// acquireLeaseScript takes the scheduler lock and returns a new fencing token,
+// or 0 if another instance holds it.
+//
+// KEYS: lock, fence. ARGV: owner, TTL (ms).
+var acquireLeaseScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// scheduleScript stores task data and adds it to the scheduled set in one step.
+// If the task became the earliest entry, schedulers are woken to re-arm their timers.
+//
+// KEYS: task key, scheduled set. ARGV: task data, score (ms), task ID, wake channel.
+var scheduleScript = redis.NewScript(`
+redis.call("SET", KEYS[1], ARGV[1])
+redis.call("ZADD", KEYS[2], ARGV[2], ARGV[3])
+local head = redis.call("ZRANGE", KEYS[2], 0, 0)
+if head[1] == ARGV[3] then
+	redis.call("PUBLISH", ARGV[4], ARGV[2])
+end
+return 1
+`)
+
+// activateScript moves a batch of due tasks to their priority streams. A task is
+// only activated while it is still in the scheduled set and its stored data is
+// unchanged since it was read, so one canceled or edited in the meantime is left
+// for the next poll. Tasks with an empty stream have expired: their data is
+// written with the retention TTL (seconds, 0 = none) and they are not queued.
+// Nothing is written unless the caller still holds the lock with the current
+// fencing token; a paused former leader gets {-1}.
+//
+// KEYS: scheduled set, lock, fence. ARGV: task key prefix, owner, fencing token,
+// then task ID, data read, new data, stream, type, TTL, queue generation for each
+// task. Returns the number activated followed by the IDs of the expired tasks written.
+var activateScript = redis.NewScript(`
+if redis.call("GET", KEYS[2]) ~= ARGV[2] or redis.call("GET", KEYS[3]) ~= ARGV[3] then
+	return {-1}
+end
+local result = {0}
+for i = 4, #ARGV, 7 do
+	local id = ARGV[i]
+	if redis.call("ZSCORE", KEYS[1], id) and redis.call("GET", ARGV[1] .. id) == ARGV[i+1] then
+		if tonumber(ARGV[i+5]) > 0 then
+			redis.call("SET", ARGV[1] .. id, ARGV[i+2], "EX", ARGV[i+5])
+		else
+			redis.call("SET", ARGV[1] .. id, ARGV[i+2])
+		end
+		if ARGV[i+3] ~= "" then
+			redis.call("XADD", ARGV[i+3], "*", "task_id", id, "type", ARGV[i+4], "gen", ARGV[i+6])
+			result[1] = result[1] + 1
+		else
+			table.insert(result, id)
+		end
+		redis.call("ZREM", KEYS[1], id)
+	end
+end
+return result
+`)
+
+// migrateScoresScript rescores entries written in Unix seconds to milliseconds
+//
+// KEYS: scheduled set. ARGV: legacy score limit, batch size.
+var migrateScoresScript = redis.NewScript(`
+local entries = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", "(" .. ARGV[1], "WITHSCORES", "LIMIT", 0, ARGV[2])
+for i = 1, #entries, 2 do
+	redis.call("ZADD", KEYS[1], tonumber(entries[i+1]) * 1000, entries[i])
+end
+return #entries / 2
+`)
+
+var instanceID = sync.OnceValue(func() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8])
+})
+
+// InstanceID identifies this process as a scheduler lease owner
+func InstanceID() string {
+	return instanceID()
+}
+
+// SchedulerStatus reports this instance's scheduler leadership
+type SchedulerStatus struct {
+	Instance    string     `json:"instance"`
+	Leader      bool       `json:"leader"`
+	Fence       int64      `json:"fence,omitempty"`
+	LeaderSince *time.Time `json:"leader_since,omitempty"`
+}
+
+// SchedulerLeader describes the current lease holder as seen in Redis
+type SchedulerLeader struct {
+	Instance         string `json:"instance"`
+	Fence            int64  `json:"fence"`
+	LeaseRemainingMs int64  `json:"lease_remaining_ms"`
+}
+
+// LeaderJob is periodic work that must run on a single instance at a time.
+// Jobs registered with Scheduler.RunWhileLeader run only on the elected leader.
+type LeaderJob struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler polls the scheduled tasks set and moves due tasks to priority queues.
+// Replicas elect a leader through a renewable lease on schedulerLockKey; only the
+// leader activates tasks and runs leader jobs, and its writes carry a fencing token.
+// A standby takes over within the lease TTL plus one poll interval.
 type Scheduler struct {
 	client       *redis.Client
 	queue        *RedisQueue
 	pollInterval time.Duration
+	lockTTL      time.Duration
+	owner        string
+	jobs         []LeaderJob
+	publisher    events.Publisher
 	stopCh       chan struct{}
 	wg           sync.WaitGroup
+
+	mu          sync.RWMutex
+	fence       int64 // 0 when not the leader
+	leaderSince time.Time
 }
 
-// NewScheduler creates a new scheduler
+// NewScheduler creates a new scheduler with the default lease TTL
 func NewScheduler(client *redis.Client, queue *RedisQueue) *Scheduler {
+	return NewSchedulerWithConfig(client, queue, &config.SchedulerConfig{LeaseTTL: schedulerLockTTL})
+}
+
+// NewSchedulerWithConfig creates a new scheduler. cfg.LeaseTTL bounds how long a
+// standby waits to take over from a leader that died without releasing the lease.
+func NewSchedulerWithConfig(client *redis.Client, queue *RedisQueue, cfg *config.SchedulerConfig) *Scheduler {
+	lockTTL := cfg.LeaseTTL
+	if lockTTL <= 0 {
+		lockTTL = schedulerLockTTL
+	}
+
 	return &Scheduler{
 		client:       client,
 		queue:        queue,
-		pollInterval: schedulerPollInterval,
+		pollInterval: min(schedulerPollInterval, lockTTL/3),
+		lockTTL:      lockTTL,
+		owner:        InstanceID(),
 		stopCh:       make(chan struct{}),
 	}
 }
 
-// Start begins the scheduler loop
+// SetPublisher sets the publisher for task events emitted by the scheduler
+func (s *Scheduler) SetPublisher(p events.Publisher) {
+	s.publisher = p
+}
+
+// RunWhileLeader registers a job that runs every interval while this instance
+// is the leader. It must be called before Start.
+func (s *Scheduler) RunWhileLeader(job LeaderJob) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start begins the scheduler loop and leader jobs
 func (s *Scheduler) Start(ctx context.Context) {
 	s.wg.Add(1)
 	go s.schedulerLoop(ctx)
 
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.jobLoop(ctx, job)
+	}
+
 	logger.Info().
 		Dur("poll_interval", s.pollInterval).
+		Dur("lease_ttl", s.lockTTL).
+		Int("leader_jobs", len(s.jobs)).
 		Msg("scheduler started")
 }
 
-// Stop stops the scheduler
+// Stop stops the scheduler and hands over the lease so a standby can take over
+// without waiting for it to expire
 func (s *Scheduler) Stop() {
 	close(s.stopCh)
 	s.wg.Wait()
+	if s.currentFence() != 0 {
+		releaseLease(s.client, schedulerLockKey, s.owner)
+		s.setFence(0)
+	}
 	logger.Info().Msg("scheduler stopped")
 }
 
-func (s *Scheduler) schedulerLoop(ctx context.Context) {
+func (s *Scheduler) jobLoop(ctx context.Context, job LeaderJob) {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(s.pollInterval)
+	interval := job.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -69,162 +255,401 @@ func (s *Scheduler) schedulerLoop(ctx context.Context) {
 		case <-s.stopCh:
 			return
 		case <-ticker.C:
-			s.processDueTasks(ctx)
+			if s.currentFence() == 0 {
+				continue
+			}
+			if err := job.Run(ctx); err != nil {
+				logger.Error().Err(err).Str("job", job.Name).Msg("leader job failed")
+			}
 		}
 	}
 }
 
-func (s *Scheduler) processDueTasks(ctx context.Context) {
-	// Try to acquire distributed lock to prevent multiple schedulers from processing
-	locked, err := s.client.SetNX(ctx, schedulerLockKey, "1", schedulerLockTTL).Result()
-	if err != nil || !locked {
-		return // Another scheduler instance is processing
+// Status reports whether this instance currently holds the scheduler lease
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := SchedulerStatus{Instance: s.owner, Leader: s.fence != 0, Fence: s.fence}
+	if status.Leader {
+		since := s.leaderSince
+		status.LeaderSince = &since
 	}
-	defer s.client.Del(ctx, schedulerLockKey)
+	return status
+}
 
-	now := time.Now().UTC().Unix()
+func (s *Scheduler) currentFence() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fence
+}
 
-	// Get all tasks scheduled to run at or before now
-	// ZRANGEBYSCORE tasks:scheduled -inf <now>
-	taskIDs, err := s.client.ZRangeByScore(ctx, scheduledSetKey, &redis.ZRangeBy{
-		Min: "-inf",
-		Max: fmt.Sprintf("%d", now),
-	}).Result()
+func (s *Scheduler) setFence(fence int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fence = fence
+	if fence != 0 {
+		s.leaderSince = time.Now().UTC()
+	}
+}
 
-	if err != nil {
-		logger.Error().Err(err).Msg("failed to get due tasks")
-		return
+// ensureLeadership renews the lease if this instance holds it, or tries to take it
+func (s *Scheduler) ensureLeadership(ctx context.Context) bool {
+	if s.currentFence() != 0 {
+		if renewLease(ctx, s.client, schedulerLockKey, s.owner, s.lockTTL) {
+			return true
+		}
+		s.loseLeadership()
 	}
 
-	if len(taskIDs) == 0 {
-		return
+	fence, err := acquireLeaseScript.Run(ctx, s.client, []string{schedulerLockKey, schedulerFenceKey},
+		s.owner, s.lockTTL.Milliseconds()).Int64()
+	if err != nil || fence == 0 {
+		return false // Another scheduler instance is the leader
 	}
 
-	logger.Debug().Int("count", len(taskIDs)).Msg("processing due scheduled tasks")
+	s.setFence(fence)
+	logger.Info().Str("instance", s.owner).Int64("fence", fence).Msg("scheduler acquired leadership")
+	return true
+}
 
-	for _, taskID := range taskIDs {
-		if err := s.activateTask(ctx, taskID); err != nil {
-			logger.Error().Err(err).Str("task_id", taskID).Msg("failed to activate scheduled task")
-			continue
+func (s *Scheduler) loseLeadership() {
+	s.setFence(0)
+	logger.Warn().Str("instance", s.owner).Msg("scheduler lost leadership")
+}
+
+func (s *Scheduler) schedulerLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	// Wake-ups from ScheduleTask when a task is added ahead of the current head
+	pubsub := s.client.Subscribe(ctx, schedulerWakeChannel)
+	defer pubsub.Close()
+	wakeCh := pubsub.Channel()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	nextPoll := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-timer.C:
+			wait := s.poll(ctx)
+			nextPoll = time.Now().Add(wait)
+			timer.Reset(wait)
+		case msg, ok := <-wakeCh:
+			if !ok {
+				wakeCh = nil
+				continue
+			}
+			ms, err := strconv.ParseInt(msg.Payload, 10, 64)
+			if err != nil {
+				continue
+			}
+			if dueAt := time.UnixMilli(ms); dueAt.Before(nextPoll) {
+				nextPoll = dueAt
+				timer.Reset(time.Until(dueAt))
+			}
 		}
 	}
 }
 
-func (s *Scheduler) activateTask(ctx context.Context, taskID string) error {
-	// Get task data
-	t, err := s.queue.GetTask(ctx, taskID)
-	if err != nil {
-		// Task was deleted, remove from scheduled set
-		s.client.ZRem(ctx, scheduledSetKey, taskID)
-		return nil
+// poll activates due tasks of every tenant if this instance is the leader, and
+// returns how long to wait before the next poll
+func (s *Scheduler) poll(ctx context.Context) time.Duration {
+	if !s.ensureLeadership(ctx) {
+		return s.pollInterval
 	}
 
-	// Accept both scheduled (first-run) and retrying (backoff delay) states.
-	// Any other state (including canceled) means the task was already handled;
-	// just clean up the sorted set entry.
-	if t.State != task.StateScheduled && t.State != task.StateRetrying {
-		s.client.ZRem(ctx, scheduledSetKey, taskID)
-		return nil
+	s.migrateLegacyScores(ctx)
+
+	more := false
+	for _, tenant := range s.queue.Tenants() {
+		if s.currentFence() == 0 {
+			return s.pollInterval
+		}
+		if s.processDueTasks(ctx, s.queue.ForTenant(tenant)) {
+			more = true
+		}
 	}
+	if more {
+		return 0
+	}
+
+	// Sleep until the earliest head across all tenants
+	wait := s.pollInterval
+	for _, tenant := range s.queue.Tenants() {
+		head, err := s.client.ZRangeWithScores(ctx, s.queue.ForTenant(tenant).scheduledKey(), 0, 0).Result()
+		if err != nil || len(head) == 0 {
+			continue
+		}
+		wait = min(wait, schedulerWait(time.UnixMilli(int64(head[0].Score)), time.Now(), s.pollInterval))
+	}
+	return wait
+}
 
-	sm := task.NewStateMachine(t)
-	if err := sm.Transition(task.StatePending); err != nil {
-		return fmt.Errorf("failed to transition task: %w", err)
+// schedulerWait returns the time until next is due, bounded by [0, max]
+func schedulerWait(next, now time.Time, max time.Duration) time.Duration {
+	d := next.Sub(now)
+	if d < 0 {
+		return 0
 	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// processDueTasks activates the due tasks of tq's tenant in batches. It returns
+// true if it stopped because of the per-poll cap while more tasks are due.
+func (s *Scheduler) processDueTasks(ctx context.Context, tq *RedisQueue) bool {
+	started := time.Now()
+	budget := s.lockTTL / 2
+
+	for total := 0; total < schedulerMaxPerPoll; {
+		// ZRANGEBYSCORE tasks:scheduled -inf <now ms> LIMIT 0 <batch>
+		taskIDs, err := s.client.ZRangeByScore(ctx, tq.scheduledKey(), &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+			Count: schedulerBatchSize,
+		}).Result()
+
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to get due tasks")
+			return false
+		}
+
+		if len(taskIDs) == 0 {
+			return false
+		}
 
-	// Update task in storage
-	if err := s.queue.UpdateTask(ctx, t); err != nil {
-		return fmt.Errorf("failed to update task: %w", err)
+		progressed, err := s.activateBatch(ctx, tq, taskIDs)
+		if errors.Is(err, errSchedulerFenced) {
+			s.loseLeadership()
+			return false
+		}
+		if err != nil {
+			logger.Error().Err(err).Int("count", len(taskIDs)).Msg("failed to activate scheduled tasks")
+			return false
+		}
+		if progressed == 0 || len(taskIDs) < schedulerBatchSize {
+			return false
+		}
+
+		total += len(taskIDs)
+		if time.Since(started) > budget {
+			return true
+		}
+
+		// Keep the lease while draining a backlog
+		if !renewLease(ctx, s.client, schedulerLockKey, s.owner, s.lockTTL) {
+			s.loseLeadership()
+			return false
+		}
 	}
 
-	// Add to appropriate priority stream
-	streamName := t.Priority.StreamName(s.queue.streamPrefix)
-	_, err = s.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: streamName,
-		Values: map[string]interface{}{
-			"task_id": t.ID,
-			"type":    t.Type,
-		},
-	}).Result()
+	return true
+}
+
+// activateBatch moves the given due tasks to their priority streams and drops
+// entries whose task is gone or already handled. It returns how many entries
+// left the scheduled set.
+func (s *Scheduler) activateBatch(ctx context.Context, tq *RedisQueue, taskIDs []string) (int, error) {
+	keys := make([]string, len(taskIDs))
+	for i, id := range taskIDs {
+		keys[i] = tq.taskKey(id)
+	}
 
+	values, err := s.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		return fmt.Errorf("failed to add task to stream: %w", err)
+		return 0, fmt.Errorf("failed to get task data: %w", err)
 	}
 
-	// Remove from scheduled set
-	s.client.ZRem(ctx, scheduledSetKey, taskID)
+	args := []interface{}{tq.taskKey(""), s.owner, s.currentFence()}
+	var stale []interface{}
+	expiring := make(map[string]*task.Task)
+	now := time.Now()
+
+	for i, id := range taskIDs {
+		raw, ok := values[i].(string)
+		if !ok {
+			// Task was deleted, remove from scheduled set
+			stale = append(stale, id)
+			continue
+		}
+
+		var t task.Task
+		if err := json.Unmarshal([]byte(raw), &t); err != nil {
+			logger.Error().Err(err).Str("task_id", id).Msg("failed to unmarshal scheduled task")
+			stale = append(stale, id)
+			continue
+		}
+
+		// Accept both scheduled (first-run) and retrying (backoff delay) states.
+		// Any other state (including canceled) means the task was already handled;
+		// just clean up the sorted set entry.
+		if t.State != task.StateScheduled && t.State != task.StateRetrying {
+			stale = append(stale, id)
+			continue
+		}
+
+		// A task past its deadline is expired instead of queued
+		sm := task.NewStateMachine(&t)
+		stream, ttl := tq.streamName(tq.Tenant(), t.Priority), int64(0)
+		if t.IsExpired(now) {
+			if err := sm.Expire(); err != nil {
+				logger.Error().Err(err).Str("task_id", id).Msg("failed to expire scheduled task")
+				continue
+			}
+			stream, ttl = "", int64(s.queue.GetRetentionTTL().Seconds())
+			expiring[id] = &t
+		} else if err := sm.Transition(task.StatePending); err != nil {
+			logger.Error().Err(err).Str("task_id", id).Msg("failed to transition scheduled task")
+			continue
+		}
+
+		data, err := json.Marshal(&t)
+		if err != nil {
+			logger.Error().Err(err).Str("task_id", id).Msg("failed to marshal scheduled task")
+			continue
+		}
+
+		args = append(args, id, raw, string(data), stream, t.Type, ttl, t.QueueGen)
+	}
+
+	removed := 0
+	if len(stale) > 0 {
+		n, err := s.client.ZRem(ctx, tq.scheduledKey(), stale...).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to remove stale scheduled tasks: %w", err)
+		}
+		removed = int(n)
+	}
+
+	activated, expired := 0, 0
+	if len(args) > 3 {
+		res, err := activateScript.Run(ctx, s.client,
+			[]string{tq.scheduledKey(), schedulerLockKey, schedulerFenceKey}, args...).Slice()
+		if err != nil {
+			return removed, fmt.Errorf("failed to activate scheduled tasks: %w", err)
+		}
+		n, _ := res[0].(int64)
+		if n < 0 {
+			return removed, errSchedulerFenced
+		}
+		activated = int(n)
+
+		for _, v := range res[1:] {
+			if t, ok := expiring[fmt.Sprint(v)]; ok {
+				s.taskExpired(ctx, t)
+				expired++
+			}
+		}
+	}
+
+	if activated > 0 {
+		logger.Debug().Int("count", activated).Msg("scheduled tasks activated")
+	}
+
+	return removed + activated + expired, nil
+}
+
+// taskExpired reports a scheduled task that passed its deadline before activation
+func (s *Scheduler) taskExpired(ctx context.Context, t *task.Task) {
+	metrics.RecordTaskExpired(t.Type, "scheduler")
 
 	logger.Info().
-		Str("task_id", taskID).
+		Str("task_id", t.ID).
 		Str("type", t.Type).
-		Str("priority", t.Priority.String()).
-		Msg("scheduled task activated")
+		Time("expires_at", *t.ExpiresAt).
+		Msg("scheduled task expired before it could run")
 
-	return nil
+	if s.publisher == nil {
+		return
+	}
+	data := events.TaskEventData(t.ID, t.Type, t.Priority.String(), map[string]interface{}{
+		"state":      t.State.String(),
+		"attempts":   t.Attempts,
+		"expires_at": t.ExpiresAt,
+	})
+	if t.Tenant != "" {
+		data["tenant"] = t.Tenant
+	}
+	if err := s.publisher.Publish(ctx, events.NewEvent(events.EventTaskExpired, data)); err != nil {
+		logger.Warn().Err(err).Str("task_id", t.ID).Msg("failed to publish task event")
+	}
 }
 
-// ScheduleTask adds a task to the scheduled set
-func (s *Scheduler) ScheduleTask(ctx context.Context, t *task.Task, scheduledAt time.Time) error {
-	// Store task data
-	taskData, err := json.Marshal(t)
+// migrateLegacyScores converts a batch of second-resolution scores, written by
+// releases before millisecond scheduling, so they are not activated early.
+// Only the default tenant predates millisecond scores.
+func (s *Scheduler) migrateLegacyScores(ctx context.Context) {
+	n, err := migrateScoresScript.Run(ctx, s.client, []string{scheduledSetKey},
+		strconv.FormatFloat(legacyScoreLimit, 'f', 0, 64), schedulerMaxPerPoll).Int()
 	if err != nil {
-		return fmt.Errorf("failed to marshal task: %w", err)
+		logger.Error().Err(err).Msg("failed to migrate scheduled task scores")
+		return
 	}
-
-	taskKey := fmt.Sprintf("task:%s", t.ID)
-	if err := s.client.Set(ctx, taskKey, taskData, 0).Err(); err != nil {
-		return fmt.Errorf("failed to store task data: %w", err)
+	if n > 0 {
+		logger.Info().Int("count", n).Msg("migrated scheduled task scores to milliseconds")
 	}
+}
 
-	// Add to scheduled sorted set with score = scheduled time
-	err = s.client.ZAdd(ctx, scheduledSetKey, redis.Z{
-		Score:  float64(scheduledAt.Unix()),
-		Member: t.ID,
-	}).Err()
+// scheduleTask stores task data and adds it to its tenant's scheduled set with a
+// millisecond score = scheduled time
+func scheduleTask(ctx context.Context, client *redis.Client, t *task.Task, scheduledAt time.Time) error {
+	taskData, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
 
+	taskKey := tenantKey(t.Tenant, fmt.Sprintf("task:%s", t.ID))
+	err = scheduleScript.Run(ctx, client, []string{taskKey, tenantKey(t.Tenant, scheduledSetKey)},
+		string(taskData), scheduledScore(scheduledAt), t.ID, schedulerWakeChannel).Err()
 	if err != nil {
-		s.client.Del(ctx, taskKey) // Cleanup on failure
 		return fmt.Errorf("failed to add task to scheduled set: %w", err)
 	}
 
 	return nil
 }
 
-// ScheduleTaskFunc returns a function that can schedule tasks (for use in handlers)
-func ScheduleTaskFunc(client *redis.Client) func(ctx context.Context, t *task.Task, scheduledAt time.Time) error {
-	return func(ctx context.Context, t *task.Task, scheduledAt time.Time) error {
-		// Store task data
-		taskData, err := json.Marshal(t)
-		if err != nil {
-			return fmt.Errorf("failed to marshal task: %w", err)
-		}
-
-		taskKey := fmt.Sprintf("task:%s", t.ID)
-		if err := client.Set(ctx, taskKey, taskData, 0).Err(); err != nil {
-			return fmt.Errorf("failed to store task data: %w", err)
-		}
+// scheduledScore returns the sorted set score for a due time
+func scheduledScore(at time.Time) int64 {
+	return at.UnixMilli()
+}
 
-		// Add to scheduled sorted set with score = scheduled time
-		err = client.ZAdd(ctx, scheduledSetKey, redis.Z{
-			Score:  float64(scheduledAt.Unix()),
-			Member: t.ID,
-		}).Err()
+// GetSchedulerLeader returns the instance currently holding the scheduler lease,
+// or nil if no instance does
+func GetSchedulerLeader(ctx context.Context, client *redis.Client) (*SchedulerLeader, error) {
+	pipe := client.Pipeline()
+	owner := pipe.Get(ctx, schedulerLockKey)
+	fence := pipe.Get(ctx, schedulerFenceKey)
+	ttl := pipe.PTTL(ctx, schedulerLockKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read scheduler lease: %w", err)
+	}
 
-		if err != nil {
-			client.Del(ctx, taskKey) // Cleanup on failure
-			return fmt.Errorf("failed to add task to scheduled set: %w", err)
-		}
+	if owner.Err() == redis.Nil {
+		return nil, nil
+	}
 
-		return nil
+	leader := &SchedulerLeader{Instance: owner.Val()}
+	leader.Fence, _ = fence.Int64()
+	if d := ttl.Val(); d > 0 {
+		leader.LeaseRemainingMs = d.Milliseconds()
 	}
+	return leader, nil
 }
 
-// GetScheduledCount returns the number of scheduled tasks
+// GetScheduledCount returns the number of scheduled tasks of the default tenant
 func GetScheduledCount(ctx context.Context, client *redis.Client) (int64, error) {
 	return client.ZCard(ctx, scheduledSetKey).Result()
 }
 
-// RemoveScheduledTask removes a task from the scheduled set
+// RemoveScheduledTask removes a task from the default tenant's scheduled set
 func RemoveScheduledTask(ctx context.Context, client *redis.Client, taskID string) error {
 	return client.ZRem(ctx, scheduledSetKey, taskID).Err()
 }
@@ -1,16 +1,24 @@
 package queue
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/maumercado/task-queue-go/internal/config"
 	"github.com/maumercado/task-queue-go/internal/task"
 )
 
 func TestSchedulerConstants(t *testing.T) {
 	assert.Equal(t, "tasks:scheduled", scheduledSetKey)
 	assert.Equal(t, "scheduler:lock", schedulerLockKey)
+	assert.Equal(t, "scheduler:fence", schedulerFenceKey)
 }
 
 func TestNewScheduler(t *testing.T) {
@@ -76,3 +84,213 @@ func TestActivateTask_RetryingTransitionToPending(t *testing.T) {
 	assert.NoError(t, err, "retrying -> pending transition must be valid")
 	assert.Equal(t, task.StatePending, tsk.State)
 }
+
+func TestScheduledScore_Milliseconds(t *testing.T) {
+	at := time.Date(2024, 1, 15, 10, 30, 0, 250*int(time.Millisecond), time.UTC)
+
+	assert.Equal(t, at.UnixMilli(), scheduledScore(at))
+	assert.Equal(t, scheduledScore(at)+1, scheduledScore(at.Add(time.Millisecond)), "sub-second times must not be rounded")
+}
+
+func TestLegacyScoreLimit(t *testing.T) {
+	now := time.Now()
+
+	// Second scores for any realistic date fall below the limit, millisecond scores above it
+	assert.Less(t, float64(now.Add(100*365*24*time.Hour).Unix()), legacyScoreLimit)
+	assert.Greater(t, float64(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()), legacyScoreLimit)
+}
+
+func TestSchedulerWait(t *testing.T) {
+	now := time.Now()
+	max := time.Second
+
+	assert.Equal(t, time.Duration(0), schedulerWait(now.Add(-time.Minute), now, max), "overdue tasks are processed immediately")
+	assert.Equal(t, 150*time.Millisecond, schedulerWait(now.Add(150*time.Millisecond), now, max))
+	assert.Equal(t, max, schedulerWait(now.Add(time.Hour), now, max), "the wait is capped")
+}
+
+func TestSchedulerBatchLimits(t *testing.T) {
+	assert.Greater(t, schedulerMaxPerPoll, schedulerBatchSize)
+	assert.Zero(t, schedulerMaxPerPoll%schedulerBatchSize)
+}
+
+func TestInstanceID_Stable(t *testing.T) {
+	id := InstanceID()
+
+	assert.NotEmpty(t, id)
+	assert.Equal(t, id, InstanceID())
+	assert.Equal(t, id, NewScheduler(nil, nil).owner)
+}
+
+func TestScheduler_Status(t *testing.T) {
+	s := NewScheduler(nil, nil)
+
+	status := s.Status()
+	assert.False(t, status.Leader)
+	assert.Zero(t, status.Fence)
+	assert.Nil(t, status.LeaderSince)
+	assert.Equal(t, InstanceID(), status.Instance)
+
+	s.setFence(42)
+	status = s.Status()
+	assert.True(t, status.Leader)
+	assert.Equal(t, int64(42), status.Fence)
+	assert.NotNil(t, status.LeaderSince)
+
+	s.loseLeadership()
+	assert.False(t, s.Status().Leader)
+}
+
+func TestNewSchedulerWithConfig(t *testing.T) {
+	s := NewSchedulerWithConfig(nil, nil, &config.SchedulerConfig{LeaseTTL: 15 * time.Second})
+	assert.Equal(t, 15*time.Second, s.lockTTL)
+	assert.Equal(t, schedulerPollInterval, s.pollInterval)
+
+	// Short leases poll often enough to renew several times per TTL
+	s = NewSchedulerWithConfig(nil, nil, &config.SchedulerConfig{LeaseTTL: 900 * time.Millisecond})
+	assert.Equal(t, 300*time.Millisecond, s.pollInterval)
+
+	s = NewSchedulerWithConfig(nil, nil, &config.SchedulerConfig{})
+	assert.Equal(t, schedulerLockTTL, s.lockTTL)
+}
+
+func TestScheduler_RunWhileLeader(t *testing.T) {
+	s := NewScheduler(nil, nil)
+	ran := make(chan struct{}, 1)
+	s.RunWhileLeader(LeaderJob{Name: "test", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}})
+	require.Len(t, s.jobs, 1)
+
+	s.wg.Add(1)
+	go s.jobLoop(t.Context(), s.jobs[0])
+
+	// Followers skip leader jobs
+	select {
+	case <-ran:
+		t.Fatal("job ran without leadership")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	s.setFence(1)
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job did not run on the leader")
+	}
+
+	close(s.stopCh)
+	s.wg.Wait()
+}
+
+// fakeActivation answers the commands of Scheduler.activateBatch from memory.
+// afterMGet runs between reading the batch and the activation script.
+type fakeActivation struct {
+	data      map[string]string   // Task keys
+	scheduled map[string]bool     // Scheduled set members
+	queued    map[string][]string // Stream -> task IDs
+	afterMGet func()
+}
+
+func (f *fakeActivation) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (f *fakeActivation) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (f *fakeActivation) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return func(_ context.Context, cmd redis.Cmder) error {
+		args := cmd.Args()
+		switch {
+		case cmd.Name() == "mget":
+			values := make([]interface{}, 0, len(args)-1)
+			for _, key := range args[1:] {
+				if v, ok := f.data[key.(string)]; ok {
+					values = append(values, v)
+				} else {
+					values = append(values, nil)
+				}
+			}
+			cmd.(*redis.SliceCmd).SetVal(values)
+			if f.afterMGet != nil {
+				f.afterMGet()
+			}
+		case cmd.Name() == "evalsha" && args[1] == activateScript.Hash():
+			// ARGV starts after the sha, key count and three keys
+			argv := args[6:]
+			prefix := argv[0].(string)
+			activated := int64(0)
+			for i := 3; i < len(argv); i += 7 {
+				id := argv[i].(string)
+				if !f.scheduled[id] || f.data[prefix+id] != argv[i+1].(string) {
+					continue
+				}
+				f.data[prefix+id] = argv[i+2].(string)
+				stream := argv[i+3].(string)
+				f.queued[stream] = append(f.queued[stream], id)
+				activated++
+				delete(f.scheduled, id)
+			}
+			cmd.(*redis.Cmd).SetVal([]interface{}{activated})
+		default:
+			cmd.SetErr(fmt.Errorf("unexpected command %q", cmd.Name()))
+		}
+		return cmd.Err()
+	}
+}
+
+func TestActivateBatch_EditDuringActivation(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	tsk := task.New("email", map[string]interface{}{"to": "old@example.com"}, task.PriorityNormal)
+	tsk.State = task.StateScheduled
+	tsk.ScheduledAt = &past
+	raw, err := json.Marshal(tsk)
+	require.NoError(t, err)
+
+	for _, edited := range []bool{false, true} {
+		fake := &fakeActivation{
+			data:      map[string]string{"task:" + tsk.ID: string(raw)},
+			scheduled: map[string]bool{tsk.ID: true},
+			queued:    map[string][]string{},
+		}
+		var editedData string
+		if edited {
+			// An EditTask lands after the scheduler read the task: it moves it an
+			// hour ahead and keeps it in the scheduled set
+			fake.afterMGet = func() {
+				later := time.Now().Add(time.Hour)
+				edit := *tsk
+				edit.ScheduledAt = &later
+				edit.Payload = map[string]interface{}{"to": "new@example.com"}
+				edit.Version++
+				data, err := json.Marshal(&edit)
+				require.NoError(t, err)
+				editedData = string(data)
+				fake.data["task:"+tsk.ID] = editedData
+			}
+		}
+
+		client := redis.NewClient(&redis.Options{Addr: "fake:6379"})
+		client.AddHook(fake)
+		q := &RedisQueue{client: client, streamPrefix: "tasks"}
+		s := NewScheduler(client, q)
+
+		n, err := s.activateBatch(t.Context(), q, []string{tsk.ID})
+		require.NoError(t, err)
+		_ = client.Close()
+
+		if !edited {
+			assert.Equal(t, 1, n)
+			assert.Len(t, fake.queued[q.streamName("", task.PriorityNormal)], 1)
+			continue
+		}
+		assert.Zero(t, n, "the edited task is left for the next poll")
+		assert.Empty(t, fake.queued)
+		assert.True(t, fake.scheduled[tsk.ID])
+		assert.Equal(t, editedData, fake.data["task:"+tsk.ID], "the edit is not overwritten")
+	}
+}
@@ -0,0 +1,208 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// taskEditAttempts bounds retries when a task changes between read and write
+const taskEditAttempts = 3
+
+// Task edit errors
+var (
+	ErrTaskNotEditable     = errors.New("task can no longer be edited")
+	ErrTaskVersionMismatch = errors.New("task version does not match")
+	ErrTaskEditConflict    = errors.New("task was modified concurrently")
+	ErrInvalidTaskEdit     = errors.New("invalid task edit")
+)
+
+// editTaskScript writes an edited task only if its stored data is unchanged since
+// it was read, then moves it: XADD to its new priority stream and/or re-score it
+// in the scheduled set (waking schedulers if it became the earliest entry).
+//
+// KEYS: task key, scheduled set. ARGV: data read, new data, task ID, type,
+// queue generation, stream ("" = none), score in ms ("" = none), wake channel.
+// Returns 1 on success, 0 if the task changed.
+var editTaskScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2])
+if ARGV[6] ~= "" then
+	redis.call("XADD", ARGV[6], "*", "task_id", ARGV[3], "type", ARGV[4], "gen", ARGV[5])
+end
+if ARGV[7] ~= "" then
+	redis.call("ZADD", KEYS[2], ARGV[7], ARGV[3])
+	local head = redis.call("ZRANGE", KEYS[2], 0, 0)
+	if head[1] == ARGV[3] then
+		redis.call("PUBLISH", ARGV[8], ARGV[7])
+	end
+end
+return 1
+`)
+
+// TaskEdit describes changes to a task that has not started yet
+type TaskEdit struct {
+	Priority    *task.Priority
+	ScheduledAt *time.Time
+	Payload     map[string]interface{} // JSON merge patch (RFC 7396)
+	Metadata    map[string]interface{} // JSON merge patch; values must be strings or null
+	IfVersion   *int64                 // Reject the edit unless the task is at this version
+}
+
+// IsEmpty returns true if the edit changes nothing
+func (e *TaskEdit) IsEmpty() bool {
+	return e.Priority == nil && e.ScheduledAt == nil && e.Payload == nil && e.Metadata == nil
+}
+
+// Validate checks the edit independently of the task it applies to
+func (e *TaskEdit) Validate() error {
+	if e.IsEmpty() {
+		return fmt.Errorf("%w: nothing to change", ErrInvalidTaskEdit)
+	}
+	for k, v := range e.Metadata {
+		if _, ok := v.(string); !ok && v != nil {
+			return fmt.Errorf("%w: metadata %q must be a string or null", ErrInvalidTaskEdit, k)
+		}
+	}
+	return nil
+}
+
+// isEditable returns true for tasks no worker has picked up yet
+func isEditable(s task.State) bool {
+	return s == task.StatePending || s == task.StateScheduled || s == task.StateRetrying
+}
+
+// EditTask applies an edit to a pending, scheduled or retrying task. A priority
+// change moves a pending task to its new stream; a new scheduled_at re-scores a
+// deferred task, or defers a pending one if it lies in the future. The write is
+// atomic with respect to workers: once one has picked the task up, the edit
+// fails with ErrTaskNotEditable.
+func (q *RedisQueue) EditTask(ctx context.Context, taskID string, edit TaskEdit) (*task.Task, error) {
+	if err := edit.Validate(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < taskEditAttempts; attempt++ {
+		raw, err := q.client.Get(ctx, q.taskKey(taskID)).Result()
+		if err == redis.Nil {
+			return nil, task.ErrTaskNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+
+		var t task.Task
+		if err := json.Unmarshal([]byte(raw), &t); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+		}
+		openTask(q.sealer, &t)
+
+		if edit.IfVersion != nil && *edit.IfVersion != t.Version {
+			return &t, ErrTaskVersionMismatch
+		}
+		if !isEditable(t.State) {
+			return &t, ErrTaskNotEditable
+		}
+		if edit.Payload != nil && t.Sealed != nil {
+			return nil, fmt.Errorf("%w: payload is encrypted with a key this server does not have", ErrInvalidTaskEdit)
+		}
+
+		move, err := applyTaskEdit(&t, &edit, time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := q.encodeTask(&t)
+		if err != nil {
+			return nil, err
+		}
+
+		stream, score := "", ""
+		if move.requeue {
+			stream = q.streamName(t.Tenant, t.Priority)
+		}
+		if move.reschedule {
+			score = fmt.Sprint(scheduledScore(*t.ScheduledAt))
+		}
+
+		ok, err := editTaskScript.Run(ctx, q.client, []string{q.taskKey(taskID), q.scheduledKey()},
+			raw, string(data), t.ID, t.Type, t.QueueGen, stream, score, schedulerWakeChannel).Bool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to update task: %w", err)
+		}
+		if ok {
+			return &t, nil
+		}
+	}
+
+	return nil, ErrTaskEditConflict
+}
+
+// taskMove says where an edited task has to be placed
+type taskMove struct {
+	requeue    bool // Add a message to the task's priority stream
+	reschedule bool // Re-score the task in the scheduled set
+}
+
+// applyTaskEdit changes t in place and bumps its version. A pending task that
+// leaves its stream gets a new queue generation, so workers skip the message
+// already queued for it.
+func applyTaskEdit(t *task.Task, edit *TaskEdit, now time.Time) (taskMove, error) {
+	var move taskMove
+	wasPending := t.State == task.StatePending
+
+	if edit.Payload != nil {
+		t.Payload = mergePatch(t.Payload, edit.Payload)
+	}
+	if edit.Metadata != nil {
+		if t.Metadata == nil {
+			t.Metadata = make(map[string]string)
+		}
+		for k, v := range edit.Metadata {
+			if v == nil {
+				delete(t.Metadata, k)
+			} else {
+				t.Metadata[k] = v.(string)
+			}
+		}
+	}
+
+	if edit.ScheduledAt != nil {
+		at := edit.ScheduledAt.UTC()
+		if t.ExpiresAt != nil && !t.ExpiresAt.After(at) {
+			return move, fmt.Errorf("%w: scheduled_at must be before expires_at", ErrInvalidTaskEdit)
+		}
+		t.ScheduledAt = &at
+
+		switch {
+		case t.State == task.StateScheduled || t.State == task.StateRetrying:
+			move.reschedule = true
+		case at.After(now):
+			if err := task.NewStateMachine(t).Transition(task.StateScheduled); err != nil {
+				return move, err
+			}
+			move.reschedule = true
+		}
+	}
+
+	if edit.Priority != nil && *edit.Priority != t.Priority {
+		t.Priority = *edit.Priority
+		move.requeue = t.State == task.StatePending
+	}
+
+	if wasPending && (move.requeue || move.reschedule) {
+		t.QueueGen++
+	}
+
+	t.Version++
+	t.UpdatedAt = now
+	return move, nil
+}
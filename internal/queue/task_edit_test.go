@@ -0,0 +1,133 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func TestTaskEdit_Validate(t *testing.T) {
+	high := task.PriorityHigh
+
+	assert.ErrorIs(t, (&TaskEdit{}).Validate(), ErrInvalidTaskEdit)
+	assert.NoError(t, (&TaskEdit{Priority: &high}).Validate())
+	assert.NoError(t, (&TaskEdit{Metadata: map[string]interface{}{"a": "b", "c": nil}}).Validate())
+	assert.ErrorIs(t, (&TaskEdit{Metadata: map[string]interface{}{"a": 1.0}}).Validate(), ErrInvalidTaskEdit)
+}
+
+func TestApplyTaskEdit_PendingPriority(t *testing.T) {
+	now := time.Now().UTC()
+	high := task.PriorityHigh
+	tsk := task.New("email", nil, task.PriorityLow)
+
+	move, err := applyTaskEdit(tsk, &TaskEdit{Priority: &high}, now)
+	require.NoError(t, err)
+
+	assert.True(t, move.requeue)
+	assert.False(t, move.reschedule)
+	assert.Equal(t, task.PriorityHigh, tsk.Priority)
+	assert.Equal(t, int64(1), tsk.QueueGen, "old stream message must become stale")
+	assert.Equal(t, int64(2), tsk.Version)
+}
+
+func TestApplyTaskEdit_PendingDeferred(t *testing.T) {
+	now := time.Now().UTC()
+	at := now.Add(time.Hour)
+	tsk := task.New("email", nil, task.PriorityNormal)
+
+	move, err := applyTaskEdit(tsk, &TaskEdit{ScheduledAt: &at}, now)
+	require.NoError(t, err)
+
+	assert.False(t, move.requeue)
+	assert.True(t, move.reschedule)
+	assert.Equal(t, task.StateScheduled, tsk.State)
+	assert.Equal(t, int64(1), tsk.QueueGen)
+}
+
+func TestApplyTaskEdit_PendingPastScheduleStaysQueued(t *testing.T) {
+	now := time.Now().UTC()
+	at := now.Add(-time.Minute)
+	tsk := task.New("email", nil, task.PriorityNormal)
+
+	move, err := applyTaskEdit(tsk, &TaskEdit{ScheduledAt: &at}, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, taskMove{}, move)
+	assert.Equal(t, task.StatePending, tsk.State)
+	assert.Zero(t, tsk.QueueGen)
+}
+
+func TestApplyTaskEdit_Deferred(t *testing.T) {
+	now := time.Now().UTC()
+	at := now.Add(time.Minute)
+	critical := task.PriorityCritical
+
+	for _, state := range []task.State{task.StateScheduled, task.StateRetrying} {
+		tsk := task.New("email", nil, task.PriorityNormal)
+		tsk.State = state
+
+		move, err := applyTaskEdit(tsk, &TaskEdit{ScheduledAt: &at, Priority: &critical}, now)
+		require.NoError(t, err)
+
+		assert.False(t, move.requeue, "deferred tasks are queued by the scheduler")
+		assert.True(t, move.reschedule)
+		assert.Equal(t, state, tsk.State)
+		assert.Equal(t, task.PriorityCritical, tsk.Priority)
+		assert.Zero(t, tsk.QueueGen)
+	}
+}
+
+func TestApplyTaskEdit_Content(t *testing.T) {
+	tsk := task.New("email", map[string]interface{}{"to": "a@example.com", "cc": "b@example.com"}, task.PriorityNormal)
+	tsk.Metadata["stale"] = "yes"
+
+	move, err := applyTaskEdit(tsk, &TaskEdit{
+		Payload:  map[string]interface{}{"cc": nil, "subject": "hi"},
+		Metadata: map[string]interface{}{"stale": nil, "owner": "ops"},
+	}, time.Now().UTC())
+	require.NoError(t, err)
+
+	assert.Equal(t, taskMove{}, move)
+	assert.Equal(t, map[string]interface{}{"to": "a@example.com", "subject": "hi"}, tsk.Payload)
+	assert.Equal(t, map[string]string{"owner": "ops"}, tsk.Metadata)
+	assert.Equal(t, int64(2), tsk.Version)
+}
+
+func TestApplyTaskEdit_ScheduleAfterExpiry(t *testing.T) {
+	now := time.Now().UTC()
+	expires := now.Add(time.Minute)
+	at := now.Add(time.Hour)
+	tsk := task.New("email", nil, task.PriorityNormal)
+	tsk.ExpiresAt = &expires
+
+	_, err := applyTaskEdit(tsk, &TaskEdit{ScheduledAt: &at}, now)
+	assert.ErrorIs(t, err, ErrInvalidTaskEdit)
+}
+
+func TestIsEditable(t *testing.T) {
+	assert.True(t, isEditable(task.StatePending))
+	assert.True(t, isEditable(task.StateScheduled))
+	assert.True(t, isEditable(task.StateRetrying))
+	assert.False(t, isEditable(task.StateRunning))
+	assert.False(t, isEditable(task.StateCompleted))
+	assert.False(t, isEditable(task.StateCanceled))
+}
+
+func TestIsStaleMessage(t *testing.T) {
+	tsk := task.New("email", nil, task.PriorityNormal)
+
+	legacy := redis.XMessage{Values: map[string]interface{}{"task_id": tsk.ID}}
+	current := redis.XMessage{Values: map[string]interface{}{"task_id": tsk.ID, "gen": "0"}}
+	assert.False(t, isStaleMessage(legacy, tsk))
+	assert.False(t, isStaleMessage(current, tsk))
+
+	tsk.QueueGen = 1
+	assert.True(t, isStaleMessage(legacy, tsk))
+	assert.True(t, isStaleMessage(current, tsk))
+	assert.False(t, isStaleMessage(redis.XMessage{Values: map[string]interface{}{"gen": "1"}}, tsk))
+}
@@ -0,0 +1,262 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// DefaultTenantName names the untenanted keyspace in configuration (e.g. the
+// tenants a worker serves). In code the default tenant is the empty string.
+const DefaultTenantName = "default"
+
+// tenantSlotGrace is added to a task's timeout when it takes a concurrency slot,
+// so slots held by crashed workers expire on their own
+const tenantSlotGrace = time.Minute
+
+// ErrUnknownTenant is returned for tenants that are not configured
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// acquireSlotScript takes a concurrency slot for a task unless the tenant is at
+// its limit. Slots are sorted set members scored by their expiry (ms); expired
+// slots are dropped first.
+//
+// KEYS: running set. ARGV: now (ms), limit, task ID, expiry (ms).
+// Returns 1 if the slot was taken (or already held), 0 if the tenant is full.
+var acquireSlotScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+if redis.call("ZSCORE", KEYS[1], ARGV[3]) then
+	redis.call("ZADD", KEYS[1], ARGV[4], ARGV[3])
+	return 1
+end
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[4], ARGV[3])
+return 1
+`)
+
+// ValidTenantName reports whether name can be used as a tenant
+func ValidTenantName(name string) bool {
+	return name != DefaultTenantName && tenantNamePattern.MatchString(name)
+}
+
+// ParseTenantName maps a configured tenant name to its code form ("default" -> "")
+func ParseTenantName(name string) string {
+	if name == DefaultTenantName {
+		return ""
+	}
+	return name
+}
+
+// tenantKey returns the Redis key for name in a tenant's keyspace. The default
+// tenant keeps the original key names so existing data stays where it is.
+func tenantKey(tenant, name string) string {
+	if tenant == "" {
+		return name
+	}
+	return "tenant:" + tenant + ":" + name
+}
+
+// tenantRegistry is shared by a queue and all its tenant views
+type tenantRegistry struct {
+	configs map[string]config.TenantConfig
+	names   []string      // Default tenant first, then configured order
+	served  []string      // Tenants Dequeue reads from
+	next    atomic.Uint64 // Round-robin offset
+}
+
+func newTenantRegistry(tenants []config.TenantConfig) (*tenantRegistry, error) {
+	reg := &tenantRegistry{
+		configs: make(map[string]config.TenantConfig, len(tenants)),
+		names:   []string{""},
+	}
+	for _, tc := range tenants {
+		if !ValidTenantName(tc.Name) {
+			return nil, fmt.Errorf("invalid tenant name %q", tc.Name)
+		}
+		if _, exists := reg.configs[tc.Name]; exists {
+			return nil, fmt.Errorf("tenant %q is configured more than once", tc.Name)
+		}
+		reg.configs[tc.Name] = tc
+		reg.names = append(reg.names, tc.Name)
+	}
+	reg.served = reg.names
+	return reg, nil
+}
+
+// servedOrder returns the served tenants rotated by one on every call, so that
+// within a priority no tenant is always read first
+func (r *tenantRegistry) servedOrder() []string {
+	n := len(r.served)
+	if n <= 1 {
+		return r.served
+	}
+	start := int((r.next.Add(1) - 1) % uint64(n))
+	return append(append([]string{}, r.served[start:]...), r.served[:start]...)
+}
+
+// ForTenant returns a view of the queue whose ID-based operations (GetTask,
+// EditTask, stats, ...) use the tenant's keyspace. Operations that take a task
+// always use the task's own tenant.
+func (q *RedisQueue) ForTenant(tenant string) *RedisQueue {
+	view := *q
+	view.tenant = tenant
+	return &view
+}
+
+// Tenant returns the tenant of this queue view ("" = default)
+func (q *RedisQueue) Tenant() string {
+	return q.tenant
+}
+
+// Tenants returns every known tenant, the default tenant first
+func (q *RedisQueue) Tenants() []string {
+	return q.tenants.names
+}
+
+// HasTenant reports whether tenant is the default or a configured tenant
+func (q *RedisQueue) HasTenant(tenant string) bool {
+	if tenant == "" {
+		return true
+	}
+	_, ok := q.tenants.configs[tenant]
+	return ok
+}
+
+// TenantConfig returns a tenant's quotas; the default tenant has none
+func (q *RedisQueue) TenantConfig(tenant string) config.TenantConfig {
+	return q.tenants.configs[tenant]
+}
+
+// ServeTenants restricts Dequeue and orphan recovery to the given tenants,
+// named as in configuration ("default" = untenanted). Empty serves all.
+func (q *RedisQueue) ServeTenants(names []string) error {
+	if len(names) == 0 {
+		q.tenants.served = q.tenants.names
+		return nil
+	}
+
+	served := make([]string, 0, len(names))
+	for _, name := range names {
+		tenant := ParseTenantName(name)
+		if !q.HasTenant(tenant) {
+			return fmt.Errorf("%w: %s", ErrUnknownTenant, name)
+		}
+		served = append(served, tenant)
+	}
+	q.tenants.served = served
+	return nil
+}
+
+// streamName returns a tenant's stream for a priority
+func (q *RedisQueue) streamName(tenant string, p task.Priority) string {
+	return tenantKey(tenant, p.StreamName(q.streamPrefix))
+}
+
+// QueueStream returns this queue's tenant stream for a priority
+func (q *RedisQueue) QueueStream(p task.Priority) string {
+	return q.streamName(q.tenant, p)
+}
+
+// scheduledKey returns the scheduled set of this queue's tenant
+func (q *RedisQueue) scheduledKey() string {
+	return tenantKey(q.tenant, scheduledSetKey)
+}
+
+func tenantRunningKey(tenant string) string {
+	return tenantKey(tenant, "tasks:running")
+}
+
+// AcquireTenantSlot takes one of the task's tenant's concurrency slots. It
+// returns false if the tenant already runs MaxConcurrency tasks.
+func (q *RedisQueue) AcquireTenantSlot(ctx context.Context, t *task.Task) (bool, error) {
+	limit := q.TenantConfig(t.Tenant).MaxConcurrency
+	if limit <= 0 {
+		return true, nil
+	}
+
+	now := time.Now()
+	expiry := now.Add(t.Timeout + tenantSlotGrace).UnixMilli()
+	ok, err := acquireSlotScript.Run(ctx, q.client, []string{tenantRunningKey(t.Tenant)},
+		now.UnixMilli(), limit, t.ID, expiry).Bool()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire tenant slot: %w", err)
+	}
+	return ok, nil
+}
+
+// ReleaseTenantSlot frees the slot taken by AcquireTenantSlot
+func (q *RedisQueue) ReleaseTenantSlot(ctx context.Context, t *task.Task) error {
+	if q.TenantConfig(t.Tenant).MaxConcurrency <= 0 {
+		return nil
+	}
+	return q.client.ZRem(ctx, tenantRunningKey(t.Tenant), t.ID).Err()
+}
+
+// saturatedTenants returns the served tenants that have no free concurrency
+// slot, so Dequeue can skip their streams
+func (q *RedisQueue) saturatedTenants(ctx context.Context) map[string]bool {
+	var limited []string
+	for _, tenant := range q.tenants.served {
+		if q.TenantConfig(tenant).MaxConcurrency > 0 {
+			limited = append(limited, tenant)
+		}
+	}
+	if len(limited) == 0 {
+		return nil
+	}
+
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	pipe := q.client.Pipeline()
+	counts := make([]*redis.IntCmd, len(limited))
+	for i, tenant := range limited {
+		counts[i] = pipe.ZCount(ctx, tenantRunningKey(tenant), "("+now, "+inf")
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil // Fail open; AcquireTenantSlot still enforces the limit
+	}
+
+	saturated := make(map[string]bool)
+	for i, tenant := range limited {
+		if counts[i].Val() >= int64(q.TenantConfig(tenant).MaxConcurrency) {
+			saturated[tenant] = true
+		}
+	}
+	return saturated
+}
+
+// Backlog returns the tasks of this queue's tenant that are waiting to run:
+// undelivered and in-flight stream messages plus scheduled and retrying tasks
+func (q *RedisQueue) Backlog(ctx context.Context) (int64, error) {
+	var total int64
+	for _, p := range allPriorities {
+		groups, err := q.client.XInfoGroups(ctx, q.streamName(q.tenant, p)).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read stream info: %w", err)
+		}
+		for _, g := range groups {
+			if g.Name == q.consumerGroup {
+				total += g.Pending + max(g.Lag, 0)
+				break
+			}
+		}
+	}
+
+	scheduled, err := q.client.ZCard(ctx, q.scheduledKey()).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count scheduled tasks: %w", err)
+	}
+	return total + scheduled, nil
+}
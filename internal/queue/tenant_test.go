@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func TestValidTenantName(t *testing.T) {
+	for _, name := range []string{"acme", "acme-eu", "a", "tenant_2", "0x"} {
+		assert.True(t, ValidTenantName(name), name)
+	}
+	for _, name := range []string{"", "default", "Acme", "-acme", "acme:eu", "acme eu"} {
+		assert.False(t, ValidTenantName(name), name)
+	}
+}
+
+func TestParseTenantName(t *testing.T) {
+	assert.Equal(t, "", ParseTenantName(DefaultTenantName))
+	assert.Equal(t, "acme", ParseTenantName("acme"))
+}
+
+func TestTenantKey(t *testing.T) {
+	assert.Equal(t, "task:123", tenantKey("", "task:123"), "default tenant keeps legacy keys")
+	assert.Equal(t, "tenant:acme:task:123", tenantKey("acme", "task:123"))
+	assert.Equal(t, "tenant:acme:tasks:scheduled", tenantKey("acme", scheduledSetKey))
+}
+
+func newTenantQueue(t *testing.T, tenants ...config.TenantConfig) *RedisQueue {
+	reg, err := newTenantRegistry(tenants)
+	require.NoError(t, err)
+	return &RedisQueue{streamPrefix: "tasks", tenants: reg}
+}
+
+func TestNewTenantRegistry(t *testing.T) {
+	reg, err := newTenantRegistry([]config.TenantConfig{{Name: "acme"}, {Name: "globex"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "acme", "globex"}, reg.names)
+	assert.Equal(t, reg.names, reg.served)
+
+	_, err = newTenantRegistry([]config.TenantConfig{{Name: "default"}})
+	assert.Error(t, err)
+	_, err = newTenantRegistry([]config.TenantConfig{{Name: "Bad Name"}})
+	assert.Error(t, err)
+	_, err = newTenantRegistry([]config.TenantConfig{{Name: "acme"}, {Name: "acme"}})
+	assert.Error(t, err)
+}
+
+func TestTenantRegistry_ServedOrderRotates(t *testing.T) {
+	q := newTenantQueue(t, config.TenantConfig{Name: "acme"}, config.TenantConfig{Name: "globex"})
+
+	seen := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		order := q.tenants.servedOrder()
+		require.Len(t, order, 3)
+		assert.ElementsMatch(t, []string{"", "acme", "globex"}, order)
+		seen[order[0]]++
+	}
+	assert.Len(t, seen, 3, "every tenant is read first once per rotation")
+}
+
+func TestRedisQueue_ServeTenants(t *testing.T) {
+	q := newTenantQueue(t, config.TenantConfig{Name: "acme"}, config.TenantConfig{Name: "globex"})
+
+	require.NoError(t, q.ServeTenants([]string{"default", "acme"}))
+	assert.Equal(t, []string{"", "acme"}, q.tenants.served)
+
+	require.NoError(t, q.ServeTenants(nil))
+	assert.Equal(t, []string{"", "acme", "globex"}, q.tenants.served)
+
+	err := q.ServeTenants([]string{"initech"})
+	assert.ErrorIs(t, err, ErrUnknownTenant)
+}
+
+func TestRedisQueue_ForTenant(t *testing.T) {
+	q := newTenantQueue(t, config.TenantConfig{Name: "acme", MaxQueued: 10})
+	acme := q.ForTenant("acme")
+
+	assert.Equal(t, "", q.Tenant())
+	assert.Equal(t, "acme", acme.Tenant())
+	assert.Equal(t, "task:1", q.taskKey("1"))
+	assert.Equal(t, "tenant:acme:task:1", acme.taskKey("1"))
+	assert.Equal(t, "tenant:acme:tasks:scheduled", acme.scheduledKey())
+	assert.Equal(t, "tenant:acme:tasks:high", acme.QueueStream(task.PriorityHigh))
+	assert.Equal(t, "tasks:high", q.QueueStream(task.PriorityHigh))
+
+	tsk := task.New("email", nil, task.PriorityLow)
+	tsk.Tenant = "acme"
+	assert.Equal(t, "tenant:acme:task:"+tsk.ID, q.taskKeyFor(tsk), "task operations follow the task's tenant")
+
+	assert.True(t, q.HasTenant(""))
+	assert.True(t, q.HasTenant("acme"))
+	assert.False(t, q.HasTenant("globex"))
+	assert.Equal(t, int64(10), q.TenantConfig("acme").MaxQueued)
+	assert.Zero(t, q.TenantConfig("").MaxQueued)
+}
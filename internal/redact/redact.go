@@ -0,0 +1,283 @@
+// Package redact hides sensitive task payload and result fields wherever they
+// leave the task itself: logs, WebSocket events, DLQ listings and API
+// responses. Handlers always receive the real values.
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/events"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// Value replaces redacted values
+const Value = "[REDACTED]"
+
+// Redactor applies the redaction rules of each task type. A nil Redactor
+// redacts nothing.
+type Redactor struct {
+	all    *ruleSet            // Rules of every task type
+	byType map[string]*ruleSet // Rules of every task type plus the type's own
+}
+
+// ruleSet is a compiled list of fields
+type ruleSet struct {
+	names map[string]bool // Lowercased names, matched at any depth
+	paths [][]string      // JSON path segments; "*" matches any key or element
+}
+
+// New compiles the configured rules
+func New(cfg *config.RedactionConfig) (*Redactor, error) {
+	all, err := compile(cfg.Fields, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Redactor{all: all, byType: make(map[string]*ruleSet, len(cfg.Rules))}
+	for _, rule := range cfg.Rules {
+		if rule.TaskType == "" {
+			return nil, errors.New("redaction rule has no task type")
+		}
+		base := r.byType[rule.TaskType]
+		if base == nil {
+			base = all
+		}
+		set, err := compile(rule.Fields, base)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule for %q: %w", rule.TaskType, err)
+		}
+		r.byType[rule.TaskType] = set
+	}
+	return r, nil
+}
+
+// compile adds fields to a copy of base
+func compile(fields []string, base *ruleSet) (*ruleSet, error) {
+	set := &ruleSet{names: make(map[string]bool)}
+	if base != nil {
+		for name := range base.names {
+			set.names[name] = true
+		}
+		set.paths = append(set.paths, base.paths...)
+	}
+
+	for _, field := range fields {
+		if !strings.HasPrefix(field, "$") {
+			if field == "" {
+				return nil, errors.New("empty redaction field")
+			}
+			set.names[strings.ToLower(field)] = true
+			continue
+		}
+		path, err := parsePath(field)
+		if err != nil {
+			return nil, err
+		}
+		set.paths = append(set.paths, path)
+	}
+	return set, nil
+}
+
+// parsePath splits "$.a.b[*].c" into ["a", "b", "*", "c"]
+func parsePath(path string) ([]string, error) {
+	rest, ok := strings.CutPrefix(path, "$.")
+	if !ok || rest == "" {
+		return nil, fmt.Errorf("invalid redaction path %q: must start with $.", path)
+	}
+
+	var segments []string
+	for _, part := range strings.Split(rest, ".") {
+		key, wildcards, _ := strings.Cut(part, "[")
+		if key == "" && wildcards == "" {
+			return nil, fmt.Errorf("invalid redaction path %q: empty segment", path)
+		}
+		if key != "" {
+			segments = append(segments, key)
+		}
+		if wildcards == "" {
+			continue
+		}
+		// Only array wildcards are supported, e.g. items[*] or matrix[*][*]
+		for _, w := range strings.SplitAfter("["+wildcards, "]") {
+			if w == "" {
+				continue
+			}
+			if w != "[*]" {
+				return nil, fmt.Errorf("invalid redaction path %q: only [*] is supported", path)
+			}
+			segments = append(segments, "*")
+		}
+	}
+	return segments, nil
+}
+
+// Covers reports whether any field of a task type is redacted
+func (r *Redactor) Covers(taskType string) bool {
+	return !r.rules(taskType).empty()
+}
+
+// Data returns a copy of a task's payload or result with the sensitive fields
+// of its type replaced by Value. The original is not modified.
+func (r *Redactor) Data(taskType string, data map[string]interface{}) map[string]interface{} {
+	set := r.rules(taskType)
+	if data == nil || set.empty() {
+		return data
+	}
+	out, _ := set.redact(data, set.paths).(map[string]interface{})
+	return out
+}
+
+// Task returns a copy of t with its payload and result redacted, or t itself
+// if its type has no sensitive fields
+func (r *Redactor) Task(t *task.Task) *task.Task {
+	if t == nil || !r.Covers(t.Type) {
+		return t
+	}
+	out := *t
+	out.Payload = r.Data(t.Type, t.Payload)
+	out.Result = r.Data(t.Type, t.Result)
+	return &out
+}
+
+// Response redacts the payload and result of a task response in place
+func (r *Redactor) Response(resp *task.TaskResponse) {
+	resp.Payload = r.Data(resp.Type, resp.Payload)
+	resp.Result = r.Data(resp.Type, resp.Result)
+}
+
+// Event returns a copy of a task event with its "payload" and "result" data
+// redacted by the event's task "type", or the event itself if there is nothing
+// to redact
+func (r *Redactor) Event(e *events.Event) *events.Event {
+	taskType, _ := e.Data["type"].(string)
+	if !r.Covers(taskType) {
+		return e
+	}
+
+	var data map[string]interface{}
+	for _, key := range []string{"payload", "result"} {
+		value, ok := e.Data[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if data == nil {
+			data = make(map[string]interface{}, len(e.Data))
+			for k, v := range e.Data {
+				data[k] = v
+			}
+		}
+		data[key] = r.Data(taskType, value)
+	}
+	if data == nil {
+		return e
+	}
+
+	out := *e
+	out.Data = data
+	return &out
+}
+
+func (r *Redactor) rules(taskType string) *ruleSet {
+	if r == nil {
+		return nil
+	}
+	if set, ok := r.byType[taskType]; ok {
+		return set
+	}
+	return r.all
+}
+
+func (s *ruleSet) empty() bool {
+	return s == nil || (len(s.names) == 0 && len(s.paths) == 0)
+}
+
+// redact copies v, replacing named fields and the ends of paths. paths are the
+// remaining segments of the paths that matched up to v.
+func (s *ruleSet) redact(v interface{}, paths [][]string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			if s.names[strings.ToLower(k)] {
+				out[k] = Value
+				continue
+			}
+			next, matched := advance(paths, k)
+			if matched {
+				out[k] = Value
+				continue
+			}
+			out[k] = s.redact(inner, next)
+		}
+		return out
+	case []interface{}:
+		next, matched := advance(paths, "*")
+		out := make([]interface{}, len(val))
+		for i, inner := range val {
+			if matched {
+				out[i] = Value
+				continue
+			}
+			out[i] = s.redact(inner, next)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// advance returns the rest of the paths whose next segment matches key, and
+// whether a path ends at key
+func advance(paths [][]string, key string) ([][]string, bool) {
+	var next [][]string
+	for _, path := range paths {
+		if path[0] != key && path[0] != "*" {
+			continue
+		}
+		if len(path) == 1 {
+			return nil, true
+		}
+		next = append(next, path[1:])
+	}
+	return next, false
+}
+
+// Fields returns a copy of data with the given fields (names or JSON paths)
+// redacted, for one-off rules such as worker.LogPayload. Fields that are not
+// valid paths are matched as names.
+func Fields(data map[string]interface{}, fields []string) map[string]interface{} {
+	set := &ruleSet{names: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		if path, err := parsePath(field); err == nil {
+			set.paths = append(set.paths, path)
+		} else if field != "" {
+			set.names[strings.ToLower(field)] = true
+		}
+	}
+	if data == nil || set.empty() {
+		return data
+	}
+	out, _ := set.redact(data, set.paths).(map[string]interface{})
+	return out
+}
+
+var defaultRedactor atomic.Pointer[Redactor]
+
+// SetDefault sets the redactor used for log output
+func SetDefault(r *Redactor) {
+	defaultRedactor.Store(r)
+}
+
+// Default returns the redactor used for log output (nil = none)
+func Default() *Redactor {
+	return defaultRedactor.Load()
+}
+
+// Data redacts a payload or result with the default redactor, for logging
+func Data(taskType string, data map[string]interface{}) map[string]interface{} {
+	return Default().Data(taskType, data)
+}
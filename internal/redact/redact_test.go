@@ -0,0 +1,149 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/events"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+func testRedactor(t *testing.T) *Redactor {
+	t.Helper()
+	r, err := New(&config.RedactionConfig{
+		Fields: []string{"password"},
+		Rules: []config.RedactionRule{
+			{TaskType: "payment", Fields: []string{"$.card.number", "$.items[*].cvv"}},
+			{TaskType: "payment", Fields: []string{"iban"}},
+			{TaskType: "matrix", Fields: []string{"$.rows[*][*]"}},
+		},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestRedactor_Data(t *testing.T) {
+	r := testRedactor(t)
+	payload := map[string]interface{}{
+		"Password": "hunter2",
+		"iban":     "DE89370400440532013000",
+		"card":     map[string]interface{}{"number": "4111111111111111", "brand": "visa"},
+		"items": []interface{}{
+			map[string]interface{}{"cvv": "123", "sku": "a"},
+			map[string]interface{}{"cvv": "456", "sku": "b"},
+		},
+		"number": "not a card",
+	}
+
+	out := r.Data("payment", payload)
+	assert.Equal(t, Value, out["Password"])
+	assert.Equal(t, Value, out["iban"])
+	assert.Equal(t, Value, out["card"].(map[string]interface{})["number"])
+	assert.Equal(t, "visa", out["card"].(map[string]interface{})["brand"])
+	for _, item := range out["items"].([]interface{}) {
+		assert.Equal(t, Value, item.(map[string]interface{})["cvv"])
+		assert.NotEqual(t, Value, item.(map[string]interface{})["sku"])
+	}
+	assert.Equal(t, "not a card", out["number"], "paths only match their location")
+
+	// The original is not modified
+	assert.Equal(t, "hunter2", payload["Password"])
+	assert.Equal(t, "4111111111111111", payload["card"].(map[string]interface{})["number"])
+
+	// Other types only get the global fields
+	out = r.Data("email", payload)
+	assert.Equal(t, Value, out["Password"])
+	assert.Equal(t, "DE89370400440532013000", out["iban"])
+	assert.Equal(t, "4111111111111111", out["card"].(map[string]interface{})["number"])
+
+	out = r.Data("matrix", map[string]interface{}{"rows": []interface{}{[]interface{}{1.0, 2.0}}, "n": 1.0})
+	assert.Equal(t, []interface{}{[]interface{}{Value, Value}}, out["rows"])
+	assert.Equal(t, 1.0, out["n"])
+}
+
+func TestRedactor_Nil(t *testing.T) {
+	var r *Redactor
+	payload := map[string]interface{}{"password": "hunter2"}
+	assert.False(t, r.Covers("email"))
+	assert.Equal(t, payload, r.Data("email", payload))
+
+	tk := task.New("email", payload, task.PriorityNormal)
+	assert.Same(t, tk, r.Task(tk))
+
+	empty, err := New(&config.RedactionConfig{})
+	require.NoError(t, err)
+	assert.False(t, empty.Covers("email"))
+}
+
+func TestRedactor_Task(t *testing.T) {
+	r := testRedactor(t)
+	tk := task.New("login", map[string]interface{}{"password": "hunter2"}, task.PriorityNormal)
+	tk.Result = map[string]interface{}{"echoed": map[string]interface{}{"password": "hunter2"}}
+
+	out := r.Task(tk)
+	assert.NotSame(t, tk, out)
+	assert.Equal(t, Value, out.Payload["password"])
+	assert.Equal(t, Value, out.Result["echoed"].(map[string]interface{})["password"])
+	assert.Equal(t, "hunter2", tk.Payload["password"])
+
+	resp := tk.ToResponse()
+	r.Response(resp)
+	assert.Equal(t, Value, resp.Payload["password"])
+	assert.Equal(t, "hunter2", tk.Payload["password"])
+}
+
+func TestRedactor_Event(t *testing.T) {
+	r := testRedactor(t)
+
+	plain := events.NewEvent(events.EventTaskCompleted, map[string]interface{}{"task_id": "1", "type": "login"})
+	assert.Same(t, plain, r.Event(plain))
+
+	e := events.NewEvent(events.EventTaskCompleted, map[string]interface{}{
+		"task_id": "1",
+		"type":    "login",
+		"payload": map[string]interface{}{"password": "hunter2", "user": "bob"},
+	})
+	out := r.Event(e)
+	assert.NotSame(t, e, out)
+	assert.Equal(t, Value, out.Data["payload"].(map[string]interface{})["password"])
+	assert.Equal(t, "bob", out.Data["payload"].(map[string]interface{})["user"])
+	assert.Equal(t, "1", out.Data["task_id"])
+	assert.Equal(t, "hunter2", e.Data["payload"].(map[string]interface{})["password"])
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for name, cfg := range map[string]config.RedactionConfig{
+		"empty field":   {Fields: []string{""}},
+		"bare root":     {Fields: []string{"$"}},
+		"empty segment": {Fields: []string{"$.card..number"}},
+		"index":         {Fields: []string{"$.items[0].cvv"}},
+		"no task type":  {Rules: []config.RedactionRule{{Fields: []string{"password"}}}},
+		"bad rule path": {Rules: []config.RedactionRule{{TaskType: "payment", Fields: []string{"$."}}}},
+	} {
+		_, err := New(&cfg)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestFields(t *testing.T) {
+	payload := map[string]interface{}{
+		"password": "hunter2",
+		"card":     map[string]interface{}{"number": "4111111111111111"},
+	}
+	out := Fields(payload, []string{"PASSWORD", "$.card.number"})
+	assert.Equal(t, Value, out["password"])
+	assert.Equal(t, Value, out["card"].(map[string]interface{})["number"])
+	assert.Equal(t, payload, Fields(payload, nil))
+}
+
+func TestDefault(t *testing.T) {
+	payload := map[string]interface{}{"password": "hunter2"}
+	assert.Equal(t, "hunter2", Data("email", payload)["password"])
+
+	SetDefault(testRedactor(t))
+	defer SetDefault(nil)
+	assert.Equal(t, Value, Data("email", payload)["password"])
+}
@@ -17,6 +17,7 @@ const (
 	StateRetrying
 	StateCanceled
 	StateDeadLetter
+	StateExpired // Not started before its expiration deadline
 )
 
 func (s State) String() string {
@@ -37,6 +38,8 @@ func (s State) String() string {
 		return "canceled"
 	case StateDeadLetter:
 		return "dead_letter"
+	case StateExpired:
+		return "expired"
 	default:
 		return "unknown"
 	}
@@ -60,6 +63,8 @@ func ParseState(s string) State {
 		return StateCanceled
 	case "dead_letter":
 		return StateDeadLetter
+	case "expired":
+		return StateExpired
 	default:
 		return StatePending
 	}
@@ -67,7 +72,7 @@ func ParseState(s string) State {
 
 // IsFinal returns true if the state is a terminal state
 func (s State) IsFinal() bool {
-	return s == StateCompleted || s == StateFailed || s == StateCanceled || s == StateDeadLetter
+	return s == StateCompleted || s == StateFailed || s == StateCanceled || s == StateDeadLetter || s == StateExpired
 }
 
 // IsActive returns true if the task is actively being processed
@@ -85,14 +90,15 @@ var (
 
 // ValidTransitions defines the allowed state transitions
 var ValidTransitions = map[State][]State{
-	StatePending:    {StateScheduled, StateRunning, StateCanceled},
-	StateScheduled:  {StatePending, StateRunning, StateCanceled},
+	StatePending:    {StateScheduled, StateRunning, StateCanceled, StateExpired},
+	StateScheduled:  {StatePending, StateRunning, StateCanceled, StateExpired},
 	StateRunning:    {StateCompleted, StateFailed, StateRetrying, StateCanceled},
-	StateRetrying:   {StateRunning, StateFailed, StateDeadLetter, StateCanceled, StatePending}, // StatePending: scheduler reactivates after backoff
-	StateFailed:     {StateRetrying, StateDeadLetter, StatePending},                            // Can retry or move to DLQ
-	StateCompleted:  {},                                                                        // Terminal state
-	StateCanceled:   {},                                                                        // Terminal state
-	StateDeadLetter: {StatePending},                                                            // Can be re-queued
+	StateRetrying:   {StateRunning, StateFailed, StateDeadLetter, StateCanceled, StatePending, StateExpired}, // StatePending: scheduler reactivates after backoff
+	StateFailed:     {StateRetrying, StateDeadLetter, StatePending},                                          // Can retry or move to DLQ
+	StateCompleted:  {},                                                                                      // Terminal state
+	StateCanceled:   {},                                                                                      // Terminal state
+	StateDeadLetter: {StatePending},                                                                          // Can be re-queued
+	StateExpired:    {},                                                                                      // Terminal state
 }
 
 // CanTransitionTo checks if a transition from current state to target state is valid
@@ -133,7 +139,7 @@ func (sm *StateMachine) Transition(target State) error {
 	switch target {
 	case StateRunning:
 		sm.task.StartedAt = &now
-	case StateCompleted, StateFailed, StateCanceled, StateDeadLetter:
+	case StateCompleted, StateFailed, StateCanceled, StateDeadLetter, StateExpired:
 		sm.task.CompletedAt = &now
 	}
 
@@ -183,6 +189,15 @@ func (sm *StateMachine) Cancel() error {
 	return sm.Transition(StateCanceled)
 }
 
+// Expire transitions a task that was not started before its deadline to expired state
+func (sm *StateMachine) Expire() error {
+	if err := sm.Transition(StateExpired); err != nil {
+		return err
+	}
+	sm.task.Error = "expired before it could run"
+	return nil
+}
+
 // MoveToDLQ transitions the task to dead letter queue
 func (sm *StateMachine) MoveToDLQ() error {
 	return sm.Transition(StateDeadLetter)
@@ -21,6 +21,7 @@ func TestState_String(t *testing.T) {
 		{StateRetrying, "retrying"},
 		{StateCanceled, "canceled"},
 		{StateDeadLetter, "dead_letter"},
+		{StateExpired, "expired"},
 		{State(99), "unknown"},
 	}
 
@@ -44,6 +45,7 @@ func TestParseState(t *testing.T) {
 		{"retrying", StateRetrying},
 		{"canceled", StateCanceled},
 		{"dead_letter", StateDeadLetter},
+		{"expired", StateExpired},
 		{"invalid", StatePending}, // Default
 		{"", StatePending},        // Default
 	}
@@ -56,7 +58,7 @@ func TestParseState(t *testing.T) {
 }
 
 func TestState_IsFinal(t *testing.T) {
-	finalStates := []State{StateCompleted, StateFailed, StateCanceled, StateDeadLetter}
+	finalStates := []State{StateCompleted, StateFailed, StateCanceled, StateDeadLetter, StateExpired}
 	nonFinalStates := []State{StatePending, StateScheduled, StateRunning, StateRetrying}
 
 	for _, state := range finalStates {
@@ -117,6 +119,13 @@ func TestState_CanTransitionTo(t *testing.T) {
 		// From DeadLetter
 		{StateDeadLetter, StatePending, true},
 		{StateDeadLetter, StateRunning, false},
+
+		// Expiration applies only to tasks that have not started
+		{StatePending, StateExpired, true},
+		{StateScheduled, StateExpired, true},
+		{StateRetrying, StateExpired, true},
+		{StateRunning, StateExpired, false},
+		{StateExpired, StatePending, false},
 	}
 
 	for _, tt := range tests {
@@ -242,6 +251,22 @@ func TestStateMachine_Cancel(t *testing.T) {
 	assert.Equal(t, StateCanceled, task.State)
 }
 
+func TestStateMachine_Expire(t *testing.T) {
+	task := New("test", nil, PriorityNormal)
+	sm := NewStateMachine(task)
+
+	err := sm.Expire()
+	require.NoError(t, err)
+	assert.Equal(t, StateExpired, task.State)
+	assert.NotNil(t, task.CompletedAt)
+	assert.NotEmpty(t, task.Error)
+
+	// A running task is past the point of expiring
+	running := New("test", nil, PriorityNormal)
+	_ = NewStateMachine(running).Start("worker")
+	assert.ErrorIs(t, NewStateMachine(running).Expire(), ErrInvalidTransition)
+}
+
 func TestStateMachine_MoveToDLQ(t *testing.T) {
 	task := New("test", nil, PriorityNormal)
 	sm := NewStateMachine(task)
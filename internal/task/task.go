@@ -62,6 +62,7 @@ func PriorityFromInt(i int) Priority {
 // Task represents a unit of work in the queue
 type Task struct {
 	ID          string                 `json:"id"`
+	Tenant      string                 `json:"tenant,omitempty"` // Empty = default tenant
 	Type        string                 `json:"type"`
 	Payload     map[string]interface{} `json:"payload"`
 	Priority    Priority               `json:"priority"`
@@ -76,8 +77,23 @@ type Task struct {
 	StartedAt   *time.Time             `json:"started_at,omitempty"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	ScheduledAt *time.Time             `json:"scheduled_at,omitempty"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"` // Discard if not started by then
 	Timeout     time.Duration          `json:"timeout"`
 	Metadata    map[string]string      `json:"metadata,omitempty"`
+	Version     int64                  `json:"version"`             // Incremented on every edit, exposed as the ETag
+	QueueGen    int64                  `json:"queue_gen,omitempty"` // Stream messages from older generations are skipped
+	Sealed      *Sealed                `json:"sealed,omitempty"`    // Encrypted payload and result; nil once decrypted
+	Encrypted   bool                   `json:"-"`                   // Stored encrypted (set when read)
+}
+
+// Sealed holds a task's payload and result encrypted at rest. Each task has
+// its own data key, stored encrypted with a keyring key so keys can be rotated
+// without re-encrypting payloads.
+type Sealed struct {
+	KeyID   string `json:"key_id"`   // Keyring key that encrypts DataKey
+	DataKey []byte `json:"data_key"` // AES-256 key for Payload and Result
+	Payload []byte `json:"payload,omitempty"`
+	Result  []byte `json:"result,omitempty"`
 }
 
 // CreateTaskRequest represents the API request for creating a task
@@ -88,12 +104,15 @@ type CreateTaskRequest struct {
 	MaxRetries  int                    `json:"max_retries,omitempty"`
 	Timeout     int                    `json:"timeout,omitempty"` // in seconds
 	ScheduledAt *time.Time             `json:"scheduled_at,omitempty"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+	TTL         int                    `json:"ttl,omitempty"` // in seconds, from scheduled_at (or submission)
 	Metadata    map[string]string      `json:"metadata,omitempty"`
 }
 
 // TaskResponse represents the API response for a task
 type TaskResponse struct {
 	ID          string                 `json:"id"`
+	Tenant      string                 `json:"tenant,omitempty"`
 	Type        string                 `json:"type"`
 	Payload     map[string]interface{} `json:"payload"`
 	Priority    string                 `json:"priority"`
@@ -109,7 +128,10 @@ type TaskResponse struct {
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	ScheduledAt *time.Time             `json:"scheduled_at,omitempty"`
 	NextRetryAt *time.Time             `json:"next_retry_at,omitempty"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
 	Metadata    map[string]string      `json:"metadata,omitempty"`
+	Version     int64                  `json:"version"`
+	Encrypted   bool                   `json:"encrypted,omitempty"` // Payload and result are stored encrypted
 }
 
 // New creates a new Task with default values
@@ -127,6 +149,7 @@ func New(taskType string, payload map[string]interface{}, priority Priority) *Ta
 		UpdatedAt:  now,
 		Timeout:    5 * time.Minute, // Default timeout
 		Metadata:   make(map[string]string),
+		Version:    1,
 	}
 }
 
@@ -143,6 +166,18 @@ func FromRequest(req *CreateTaskRequest) *Task {
 	if req.ScheduledAt != nil {
 		task.ScheduledAt = req.ScheduledAt
 	}
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		task.ExpiresAt = &expiresAt
+	} else if req.TTL > 0 {
+		// TTL counts from when the task becomes due, so it cannot expire while scheduled
+		from := task.CreatedAt
+		if req.ScheduledAt != nil && req.ScheduledAt.After(from) {
+			from = req.ScheduledAt.UTC()
+		}
+		expiresAt := from.Add(time.Duration(req.TTL) * time.Second)
+		task.ExpiresAt = &expiresAt
+	}
 	if req.Metadata != nil {
 		task.Metadata = req.Metadata
 	}
@@ -154,6 +189,7 @@ func FromRequest(req *CreateTaskRequest) *Task {
 func (t *Task) ToResponse() *TaskResponse {
 	resp := &TaskResponse{
 		ID:          t.ID,
+		Tenant:      t.Tenant,
 		Type:        t.Type,
 		Payload:     t.Payload,
 		Priority:    t.Priority.String(),
@@ -168,7 +204,10 @@ func (t *Task) ToResponse() *TaskResponse {
 		StartedAt:   t.StartedAt,
 		CompletedAt: t.CompletedAt,
 		ScheduledAt: t.ScheduledAt,
+		ExpiresAt:   t.ExpiresAt,
 		Metadata:    t.Metadata,
+		Version:     t.Version,
+		Encrypted:   t.Encrypted || t.Sealed != nil,
 	}
 	// next_retry_at is only meaningful while waiting for backoff delay.
 	if t.State == StateRetrying && t.ScheduledAt != nil {
@@ -208,6 +247,11 @@ func FromMap(m map[string]interface{}) (*Task, error) {
 	return FromJSON([]byte(data))
 }
 
+// IsExpired returns true if the task has a deadline that passed before now
+func (t *Task) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
 // CanRetry returns true if the task can be retried
 func (t *Task) CanRetry() bool {
 	return t.Attempts < t.MaxRetries
@@ -100,6 +100,7 @@ func TestNew(t *testing.T) {
 	assert.Equal(t, 0, task.Attempts)
 	assert.Equal(t, 3, task.MaxRetries)
 	assert.Equal(t, 5*time.Minute, task.Timeout)
+	assert.Equal(t, int64(1), task.Version)
 	assert.False(t, task.CreatedAt.IsZero())
 	assert.False(t, task.UpdatedAt.IsZero())
 	assert.NotNil(t, task.Metadata)
@@ -208,6 +209,40 @@ func TestFromMap_Invalid(t *testing.T) {
 	assert.Equal(t, ErrInvalidTaskData, err)
 }
 
+func TestFromRequest_Expiration(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	tsk := FromRequest(&CreateTaskRequest{Type: "push", ExpiresAt: &expiresAt})
+	require.NotNil(t, tsk.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*tsk.ExpiresAt))
+
+	// TTL counts from submission for immediate tasks
+	tsk = FromRequest(&CreateTaskRequest{Type: "push", TTL: 60})
+	require.NotNil(t, tsk.ExpiresAt)
+	assert.Equal(t, tsk.CreatedAt.Add(time.Minute), *tsk.ExpiresAt)
+
+	// ...and from the scheduled time for scheduled ones
+	scheduledAt := time.Now().Add(10 * time.Minute).UTC()
+	tsk = FromRequest(&CreateTaskRequest{Type: "push", TTL: 60, ScheduledAt: &scheduledAt})
+	require.NotNil(t, tsk.ExpiresAt)
+	assert.Equal(t, scheduledAt.Add(time.Minute), *tsk.ExpiresAt)
+
+	tsk = FromRequest(&CreateTaskRequest{Type: "push"})
+	assert.Nil(t, tsk.ExpiresAt)
+	assert.Nil(t, tsk.ToResponse().ExpiresAt)
+}
+
+func TestTask_IsExpired(t *testing.T) {
+	now := time.Now()
+	tsk := New("push", nil, PriorityNormal)
+	assert.False(t, tsk.IsExpired(now), "tasks without a deadline never expire")
+
+	deadline := now.Add(time.Second)
+	tsk.ExpiresAt = &deadline
+	assert.False(t, tsk.IsExpired(now))
+	assert.True(t, tsk.IsExpired(deadline))
+	assert.True(t, tsk.IsExpired(deadline.Add(time.Millisecond)))
+}
+
 func TestTask_CanRetry(t *testing.T) {
 	task := New("test", nil, PriorityNormal)
 	task.MaxRetries = 3
@@ -0,0 +1,217 @@
+// Package tlsutil builds TLS configurations for the API listeners and Redis
+// connections. Certificates are read from disk and reloaded when the files
+// are rotated, so renewals do not need a restart.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/logger"
+)
+
+// Client certificate policies of a listener
+const (
+	ClientAuthOptional = "optional" // Verify a client certificate if one is sent
+	ClientAuthRequire  = "require"  // Reject connections without a valid client certificate
+)
+
+// ParseVersion converts "1.2" or "1.3" to a TLS version; "" is TLS 1.2
+func ParseVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q (use 1.2 or 1.3)", v)
+	}
+}
+
+// ServerConfig builds a listener's TLS configuration. The certificate is
+// reloaded from disk when it changes; client certificates are verified
+// against ClientCAFile if one is configured.
+func ServerConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("certfile and keyfile are both required")
+	}
+	minVersion, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	reloader, err := NewCertReloader(cfg.CertFile, cfg.KeyFile, cfg.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	switch {
+	case cfg.ClientCAFile == "" && cfg.ClientAuth != "":
+		return nil, errors.New("clientauth requires clientcafile")
+	case cfg.ClientCAFile == "":
+		return out, nil
+	case cfg.ClientAuth == "" || cfg.ClientAuth == ClientAuthOptional:
+		out.ClientAuth = tls.VerifyClientCertIfGiven
+	case cfg.ClientAuth == ClientAuthRequire:
+		out.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("clientauth must be %s or %s", ClientAuthOptional, ClientAuthRequire)
+	}
+
+	if out.ClientCAs, err = loadCertPool(cfg.ClientCAFile); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RedisConfig builds the TLS configuration for Redis connections, or returns
+// nil if TLS is disabled. The client certificate, if any, is reloaded from
+// disk when it changes.
+func RedisConfig(cfg *config.RedisTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	minVersion, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // Opt-in, for testing
+	}
+
+	if cfg.CAFile != "" {
+		if out.RootCAs, err = loadCertPool(cfg.CAFile); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case cfg.CertFile != "" && cfg.KeyFile != "":
+		reloader, err := NewCertReloader(cfg.CertFile, cfg.KeyFile, time.Minute)
+		if err != nil {
+			return nil, err
+		}
+		out.GetClientCertificate = reloader.GetClientCertificate
+	case cfg.CertFile != "" || cfg.KeyFile != "":
+		return nil, errors.New("certfile and keyfile must be set together")
+	}
+
+	return out, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// CertReloader serves a certificate and key from disk. At most once per
+// interval it checks the files' modification times and reloads them if they
+// changed; a failed reload keeps the previous certificate.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration // 0 = never reload
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time // Newest modification time of the loaded files
+	checkedAt time.Time
+}
+
+// NewCertReloader loads the certificate and key
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return r, nil
+}
+
+// Certificate returns the current certificate, reloading it first if the
+// files changed
+func (r *CertReloader) Certificate() *tls.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.interval > 0 && time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+		r.reload()
+	}
+	return r.cert
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// reload loads the files if they changed; r.mu must be held
+func (r *CertReloader) reload() {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		logger.Warn().Err(err).Str("cert", r.certFile).Msg("keeping current certificate")
+		return
+	}
+	if modTime.Equal(r.modTime) {
+		return
+	}
+
+	// A rotation may replace the certificate and key one at a time; until the
+	// pair matches, keep serving the old one and try again next interval
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		logger.Warn().Err(err).Str("cert", r.certFile).Msg("keeping current certificate")
+		return
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	logger.Info().Str("cert", r.certFile).Msg("reloaded certificate")
+}
+
+func (r *CertReloader) filesModTime() (time.Time, error) {
+	var newest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat certificate: %w", err)
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
@@ -0,0 +1,319 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+)
+
+// testCA issues certificates for tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes a certificate and key for cn to dir and returns their paths
+func (ca *testCA) issue(t *testing.T, dir, cn string, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Acme"}},
+		DNSNames:     []string{cn},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, cn+".crt")
+	keyFile := filepath.Join(dir, cn+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func (ca *testCA) writePEM(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(path, ca.pem, 0o600))
+	return path
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// serveTLS starts a server that answers with the client certificate's common
+// name and returns its URL
+func serveTLS(t *testing.T, cfg *tls.Config) string {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	require.NoError(t, err)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.VerifiedChains) > 0 {
+				_, _ = io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+			}
+		}),
+		ErrorLog: log.New(io.Discard, "", 0), // Rejected handshakes are expected
+	}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return "https://" + ln.Addr().String()
+}
+
+func get(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), v)
+
+	v, err = ParseVersion("1.3")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), v)
+
+	_, err = ParseVersion("1.0")
+	assert.Error(t, err)
+}
+
+func TestServerConfig_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, dir, "localhost", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, dir, "billing", x509.ExtKeyUsageClientAuth)
+
+	cfg, err := ServerConfig(&config.TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: ca.writePEM(t, dir),
+		ClientAuth:   ClientAuthRequire,
+	})
+	require.NoError(t, err)
+	url := serveTLS(t, cfg)
+
+	// Without a client certificate the handshake fails
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.pool()}}}
+	_, err = get(client, url)
+	assert.Error(t, err)
+
+	pair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      ca.pool(),
+		Certificates: []tls.Certificate{pair},
+	}}}
+	body, err := get(client, url)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", body)
+
+	// A certificate from another CA is rejected
+	otherCert, otherKey := newTestCA(t).issue(t, t.TempDir(), "billing", x509.ExtKeyUsageClientAuth)
+	pair, err = tls.LoadX509KeyPair(otherCert, otherKey)
+	require.NoError(t, err)
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      ca.pool(),
+		Certificates: []tls.Certificate{pair},
+	}}}
+	_, err = get(client, url)
+	assert.Error(t, err)
+}
+
+func TestServerConfig_OptionalClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, dir, "localhost", x509.ExtKeyUsageServerAuth)
+
+	cfg, err := ServerConfig(&config.TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "1.3",
+		ClientCAFile: ca.writePEM(t, dir),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, cfg.ClientAuth)
+	url := serveTLS(t, cfg)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.pool()}}}
+	body, err := get(client, url)
+	require.NoError(t, err)
+	assert.Empty(t, body)
+
+	// The minimum version is enforced
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:    ca.pool(),
+		MaxVersion: tls.VersionTLS12,
+	}}}
+	_, err = get(client, url)
+	assert.Error(t, err)
+}
+
+func TestServerConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, dir, "localhost", x509.ExtKeyUsageServerAuth)
+	caFile := ca.writePEM(t, dir)
+
+	bad := []config.TLSConfig{
+		{CertFile: certFile},
+		{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.1"},
+		{CertFile: certFile, KeyFile: caFile},
+		{CertFile: certFile, KeyFile: keyFile, ClientAuth: ClientAuthRequire},
+		{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ClientAuth: "always"},
+		{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile},
+	}
+	for _, cfg := range bad {
+		_, err := ServerConfig(&cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestCertReloader_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, dir, "localhost", x509.ExtKeyUsageServerAuth)
+
+	r, err := NewCertReloader(certFile, keyFile, time.Minute)
+	require.NoError(t, err)
+	first := r.Certificate()
+
+	// Rotate: issue a new certificate over the old files
+	rotated := t.TempDir()
+	newCert, newKey := ca.issue(t, rotated, "localhost", x509.ExtKeyUsageServerAuth)
+	for src, dst := range map[string]string{newCert: certFile, newKey: keyFile} {
+		data, err := os.ReadFile(src)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(dst, data, 0o600))
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(dst, later, later))
+	}
+
+	// Not checked again until the interval passes
+	assert.Same(t, first, r.Certificate())
+
+	r.checkedAt = time.Now().Add(-time.Minute)
+	second := r.Certificate()
+	assert.NotSame(t, first, second)
+	assert.NotEqual(t, first.Certificate[0], second.Certificate[0])
+
+	// A half-written rotation keeps the current certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	later := time.Now().Add(2 * time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	r.checkedAt = time.Now().Add(-time.Minute)
+	assert.Same(t, second, r.Certificate())
+}
+
+func TestRedisConfig(t *testing.T) {
+	cfg, err := RedisConfig(&config.RedisTLSConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, cfg, "disabled")
+
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, dir, "redis.internal", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, dir, "worker", x509.ExtKeyUsageClientAuth)
+
+	cfg, err = RedisConfig(&config.RedisTLSConfig{
+		Enabled:    true,
+		CAFile:     ca.writePEM(t, dir),
+		CertFile:   clientCert,
+		KeyFile:    clientKey,
+		ServerName: "redis.internal",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+
+	// Handshake with a server that requires client certificates, as Redis
+	// does with tls-auth-clients yes
+	pair, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool(),
+	})
+	require.NoError(t, err)
+	defer ln.Close()
+
+	peer := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			peer <- ""
+			return
+		}
+		defer conn.Close()
+		tlsConn := conn.(*tls.Conn)
+		if err := tlsConn.Handshake(); err != nil {
+			peer <- ""
+			return
+		}
+		peer <- tlsConn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), cfg)
+	require.NoError(t, err)
+	require.NoError(t, conn.Handshake())
+	defer conn.Close()
+	assert.Equal(t, "worker", <-peer)
+
+	_, err = RedisConfig(&config.RedisTLSConfig{Enabled: true, CertFile: clientCert})
+	assert.Error(t, err)
+	_, err = RedisConfig(&config.RedisTLSConfig{Enabled: true, CAFile: clientKey})
+	assert.Error(t, err)
+}
@@ -0,0 +1,274 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+)
+
+// clockTicksPerSecond is USER_HZ, the unit of utime/stime in /proc/<pid>/stat.
+// It is 100 on every mainstream Linux architecture.
+const clockTicksPerSecond = 100
+
+// Adaptive controller actions, used as the metric label and in WorkerInfo.
+const (
+	AdaptiveActionHold     = "hold"
+	AdaptiveActionThrottle = "throttle"
+	AdaptiveActionExpand   = "expand"
+	AdaptiveActionPause    = "pause"
+	AdaptiveActionResume   = "resume"
+)
+
+// ResourceSample is one reading of the signals the adaptive controller uses
+type ResourceSample struct {
+	CPUPercent   float64       // Process CPU, 0-100 across all cores
+	RSSBytes     int64         // Process resident set size
+	RedisLatency time.Duration // Round-trip time of a PING
+	RedisErr     error         // Non-nil if the PING failed
+}
+
+// AdaptiveDecision is the outcome of evaluating one ResourceSample
+type AdaptiveDecision struct {
+	Limit  int    // Number of worker slots allowed to dequeue
+	Paused bool   // True if dequeuing is paused entirely
+	Action string // One of the AdaptiveAction* constants
+	Reason string // Human-readable cause, empty on hold
+}
+
+// AdaptiveController adjusts a pool's effective concurrency between a min and
+// max based on resource pressure, and pauses dequeuing on high Redis latency.
+// High/low watermarks give each signal hysteresis so the limit does not flap.
+type AdaptiveController struct {
+	cfg     config.AdaptiveConfig
+	min     int
+	max     int
+	mu      sync.RWMutex
+	limit   int
+	paused  bool
+	reason  string
+	changed chan struct{} // Closed and replaced whenever limit or paused changes
+}
+
+// NewAdaptiveController creates a controller that starts at full concurrency.
+// maxConcurrency is used when cfg.MaxConcurrency is unset.
+func NewAdaptiveController(cfg config.AdaptiveConfig, maxConcurrency int) *AdaptiveController {
+	maxC := cfg.MaxConcurrency
+	if maxC <= 0 {
+		maxC = maxConcurrency
+	}
+	if maxC <= 0 {
+		maxC = 1
+	}
+	minC := cfg.MinConcurrency
+	if minC <= 0 {
+		minC = 1
+	}
+	if minC > maxC {
+		minC = maxC
+	}
+
+	return &AdaptiveController{
+		cfg:     cfg,
+		min:     minC,
+		max:     maxC,
+		limit:   maxC,
+		changed: make(chan struct{}),
+	}
+}
+
+// MaxConcurrency returns the upper bound on worker slots
+func (c *AdaptiveController) MaxConcurrency() int {
+	return c.max
+}
+
+// Evaluate applies one sample and returns the resulting decision
+func (c *AdaptiveController) Evaluate(s ResourceSample) AdaptiveDecision {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	action := AdaptiveActionHold
+	reason := ""
+	prevLimit, prevPaused := c.limit, c.paused
+
+	// Redis latency gates consumption entirely (FR-8.6).
+	if c.cfg.RedisLatencyHigh > 0 {
+		switch {
+		case s.RedisErr != nil:
+			if !c.paused {
+				c.paused = true
+				action = AdaptiveActionPause
+				reason = fmt.Sprintf("redis unreachable: %v", s.RedisErr)
+			}
+		case !c.paused && s.RedisLatency >= c.cfg.RedisLatencyHigh:
+			c.paused = true
+			action = AdaptiveActionPause
+			reason = fmt.Sprintf("redis latency %s >= %s", s.RedisLatency, c.cfg.RedisLatencyHigh)
+		case c.paused && s.RedisLatency <= c.cfg.RedisLatencyLow:
+			c.paused = false
+			action = AdaptiveActionResume
+			reason = fmt.Sprintf("redis latency %s <= %s", s.RedisLatency, c.cfg.RedisLatencyLow)
+		}
+	}
+
+	// CPU and memory pressure scale the slot count (FR-8.3).
+	// Shrink multiplicatively, grow one slot at a time.
+	if action == AdaptiveActionHold {
+		rssMB := s.RSSBytes >> 20
+		cpuHigh := c.cfg.CPUHighPercent > 0 && s.CPUPercent >= c.cfg.CPUHighPercent
+		memHigh := c.cfg.MemoryHighMB > 0 && rssMB >= c.cfg.MemoryHighMB
+		cpuLow := c.cfg.CPUHighPercent <= 0 || s.CPUPercent <= c.cfg.CPULowPercent
+		memLow := c.cfg.MemoryHighMB <= 0 || rssMB <= c.cfg.MemoryLowMB
+
+		switch {
+		case (cpuHigh || memHigh) && c.limit > c.min:
+			step := c.limit / 4
+			if step < 1 {
+				step = 1
+			}
+			c.limit -= step
+			if c.limit < c.min {
+				c.limit = c.min
+			}
+			action = AdaptiveActionThrottle
+			if cpuHigh {
+				reason = fmt.Sprintf("cpu %.1f%% >= %.1f%%", s.CPUPercent, c.cfg.CPUHighPercent)
+			} else {
+				reason = fmt.Sprintf("rss %dMB >= %dMB", rssMB, c.cfg.MemoryHighMB)
+			}
+		case cpuLow && memLow && c.limit < c.max:
+			c.limit++
+			action = AdaptiveActionExpand
+			reason = "resource pressure relieved"
+		}
+	}
+
+	if action != AdaptiveActionHold {
+		c.reason = reason
+	}
+	if c.limit != prevLimit || c.paused != prevPaused {
+		close(c.changed)
+		c.changed = make(chan struct{})
+	}
+
+	return AdaptiveDecision{
+		Limit:  c.limit,
+		Paused: c.paused,
+		Action: action,
+		Reason: reason,
+	}
+}
+
+// Admit reports whether the worker in the given slot may dequeue now.
+// When it may not, the returned channel is closed on the next change.
+func (c *AdaptiveController) Admit(slot int) (bool, <-chan struct{}) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.paused && slot < c.limit, c.changed
+}
+
+// Status returns the current limit, pause flag and the reason for the last change
+func (c *AdaptiveController) Status() (limit int, paused bool, reason string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.limit, c.paused, c.reason
+}
+
+// resourceSampler reads process CPU and RSS from /proc and Redis latency via PING
+type resourceSampler struct {
+	client    *redis.Client
+	lastTicks uint64
+	lastAt    time.Time
+}
+
+func newResourceSampler(client *redis.Client) *resourceSampler {
+	return &resourceSampler{client: client}
+}
+
+// Sample takes one reading. Missing /proc (non-Linux) leaves CPU and RSS at zero.
+func (s *resourceSampler) Sample(ctx context.Context) ResourceSample {
+	var sample ResourceSample
+
+	now := time.Now()
+	if ticks, err := readProcCPUTicks(); err == nil {
+		if !s.lastAt.IsZero() && ticks >= s.lastTicks {
+			elapsed := now.Sub(s.lastAt).Seconds()
+			if elapsed > 0 {
+				cpuSec := float64(ticks-s.lastTicks) / clockTicksPerSecond
+				sample.CPUPercent = cpuSec / elapsed / float64(runtime.NumCPU()) * 100
+			}
+		}
+		s.lastTicks = ticks
+		s.lastAt = now
+	}
+
+	if rss, err := readProcRSS(); err == nil {
+		sample.RSSBytes = rss
+	}
+
+	if s.client != nil {
+		start := time.Now()
+		sample.RedisErr = s.client.Ping(ctx).Err()
+		sample.RedisLatency = time.Since(start)
+	}
+
+	return sample
+}
+
+// readProcCPUTicks returns utime+stime for this process in clock ticks
+func readProcCPUTicks() (uint64, error) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, err
+	}
+	return parseProcStatTicks(data)
+}
+
+// parseProcStatTicks extracts utime (field 14) and stime (field 15) from /proc/<pid>/stat.
+// The command name in field 2 may contain spaces, so fields are counted after its closing paren.
+func parseProcStatTicks(data []byte) (uint64, error) {
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("malformed /proc stat")
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	// fields[0] is field 3 (state), so utime is fields[11] and stime fields[12]
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("malformed /proc stat: %d fields", len(fields))
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid utime: %w", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid stime: %w", err)
+	}
+	return utime + stime, nil
+}
+
+// readProcRSS returns this process's resident set size in bytes
+func readProcRSS() (int64, error) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed /proc statm")
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resident pages: %w", err)
+	}
+	return pages * int64(os.Getpagesize()), nil
+}
@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+)
+
+func adaptiveTestConfig() config.AdaptiveConfig {
+	return config.AdaptiveConfig{
+		Enabled:          true,
+		MinConcurrency:   2,
+		MaxConcurrency:   8,
+		CPUHighPercent:   80,
+		CPULowPercent:    50,
+		MemoryHighMB:     512,
+		MemoryLowMB:      256,
+		RedisLatencyHigh: 200 * time.Millisecond,
+		RedisLatencyLow:  50 * time.Millisecond,
+	}
+}
+
+func TestNewAdaptiveController_Bounds(t *testing.T) {
+	c := NewAdaptiveController(config.AdaptiveConfig{}, 10)
+	assert.Equal(t, 10, c.MaxConcurrency())
+	limit, paused, _ := c.Status()
+	assert.Equal(t, 10, limit, "controller starts at full concurrency")
+	assert.False(t, paused)
+
+	c = NewAdaptiveController(config.AdaptiveConfig{MinConcurrency: 20, MaxConcurrency: 4}, 10)
+	assert.Equal(t, 4, c.max)
+	assert.Equal(t, 4, c.min, "min is clamped to max")
+}
+
+func TestAdaptiveController_ThrottleAndExpand(t *testing.T) {
+	c := NewAdaptiveController(adaptiveTestConfig(), 8)
+
+	// CPU above high watermark shrinks the limit until it hits the minimum.
+	d := c.Evaluate(ResourceSample{CPUPercent: 95})
+	assert.Equal(t, AdaptiveActionThrottle, d.Action)
+	assert.Equal(t, 6, d.Limit)
+
+	for i := 0; i < 10; i++ {
+		d = c.Evaluate(ResourceSample{CPUPercent: 95})
+	}
+	assert.Equal(t, 2, d.Limit)
+	assert.Equal(t, AdaptiveActionHold, d.Action, "no further action at the minimum")
+
+	// Between watermarks the limit holds (hysteresis).
+	d = c.Evaluate(ResourceSample{CPUPercent: 65})
+	assert.Equal(t, AdaptiveActionHold, d.Action)
+	assert.Equal(t, 2, d.Limit)
+
+	// Below the low watermark the limit grows one slot at a time.
+	d = c.Evaluate(ResourceSample{CPUPercent: 10})
+	assert.Equal(t, AdaptiveActionExpand, d.Action)
+	assert.Equal(t, 3, d.Limit)
+}
+
+func TestAdaptiveController_MemoryPressure(t *testing.T) {
+	c := NewAdaptiveController(adaptiveTestConfig(), 8)
+
+	d := c.Evaluate(ResourceSample{CPUPercent: 10, RSSBytes: 600 << 20})
+	assert.Equal(t, AdaptiveActionThrottle, d.Action)
+	assert.Contains(t, d.Reason, "rss")
+
+	// RSS between watermarks blocks expansion even with idle CPU.
+	d = c.Evaluate(ResourceSample{CPUPercent: 10, RSSBytes: 300 << 20})
+	assert.Equal(t, AdaptiveActionHold, d.Action)
+}
+
+func TestAdaptiveController_RedisLatencyPause(t *testing.T) {
+	c := NewAdaptiveController(adaptiveTestConfig(), 8)
+
+	d := c.Evaluate(ResourceSample{RedisLatency: 300 * time.Millisecond})
+	assert.Equal(t, AdaptiveActionPause, d.Action)
+	assert.True(t, d.Paused)
+
+	ok, _ := c.Admit(0)
+	assert.False(t, ok, "no slot may dequeue while paused")
+
+	// Latency between watermarks keeps the pause.
+	d = c.Evaluate(ResourceSample{RedisLatency: 100 * time.Millisecond})
+	assert.True(t, d.Paused)
+
+	d = c.Evaluate(ResourceSample{RedisLatency: 10 * time.Millisecond})
+	assert.Equal(t, AdaptiveActionResume, d.Action)
+	assert.False(t, d.Paused)
+
+	d = c.Evaluate(ResourceSample{RedisErr: errors.New("connection refused")})
+	assert.Equal(t, AdaptiveActionPause, d.Action)
+	assert.Contains(t, d.Reason, "unreachable")
+}
+
+func TestAdaptiveController_AdmitWakesOnChange(t *testing.T) {
+	c := NewAdaptiveController(adaptiveTestConfig(), 8)
+	c.Evaluate(ResourceSample{CPUPercent: 95}) // limit 8 -> 6
+
+	ok, changed := c.Admit(7)
+	require.False(t, ok)
+
+	c.Evaluate(ResourceSample{CPUPercent: 10}) // limit 6 -> 7
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("admit channel not closed after limit change")
+	}
+
+	ok, _ = c.Admit(6)
+	assert.True(t, ok)
+}
+
+func TestParseProcStatTicks(t *testing.T) {
+	// Command names may contain spaces and parens.
+	stat := []byte("1234 (my (odd) proc) S 1 1234 1234 0 -1 4194560 500 0 0 0 150 50 0 0 20 0 8 0 100 0 0")
+	ticks, err := parseProcStatTicks(stat)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(200), ticks)
+
+	_, err = parseProcStatTicks([]byte("garbage"))
+	assert.Error(t, err)
+}
@@ -14,10 +14,15 @@ import (
 // TaskHandler is a function that processes a task
 type TaskHandler func(ctx context.Context, t *task.Task) (map[string]interface{}, error)
 
+// Middleware wraps a TaskHandler with cross-cutting behavior
+type Middleware func(TaskHandler) TaskHandler
+
 // Executor executes tasks using registered handlers
 type Executor struct {
-	handlers    map[string]TaskHandler
-	retryPolicy *task.RetryPolicy
+	handlers       map[string]TaskHandler
+	retryPolicy    *task.RetryPolicy
+	middleware     []Middleware            // Applied to every task type
+	typeMiddleware map[string][]Middleware // Applied to a single task type
 }
 
 // NewExecutor creates a new task executor
@@ -29,8 +34,9 @@ func NewExecutor(handlers map[string]TaskHandler, retryPolicy *task.RetryPolicy)
 		retryPolicy = task.DefaultRetryPolicy()
 	}
 	return &Executor{
-		handlers:    handlers,
-		retryPolicy: retryPolicy,
+		handlers:       handlers,
+		retryPolicy:    retryPolicy,
+		typeMiddleware: make(map[string][]Middleware),
 	}
 }
 
@@ -39,6 +45,30 @@ func (e *Executor) RegisterHandler(taskType string, handler TaskHandler) {
 	e.handlers[taskType] = handler
 }
 
+// Use appends middleware applied to every task type.
+// The first middleware is the outermost. Call before the pool starts.
+func (e *Executor) Use(mw ...Middleware) {
+	e.middleware = append(e.middleware, mw...)
+}
+
+// UseFor appends middleware applied only to the given task type.
+// Per-type middleware runs inside the global chain. Call before the pool starts.
+func (e *Executor) UseFor(taskType string, mw ...Middleware) {
+	e.typeMiddleware[taskType] = append(e.typeMiddleware[taskType], mw...)
+}
+
+// wrap builds the middleware chain around a handler: global -> per-type -> handler
+func (e *Executor) wrap(taskType string, handler TaskHandler) TaskHandler {
+	typeMW := e.typeMiddleware[taskType]
+	for i := len(typeMW) - 1; i >= 0; i-- {
+		handler = typeMW[i](handler)
+	}
+	for i := len(e.middleware) - 1; i >= 0; i-- {
+		handler = e.middleware[i](handler)
+	}
+	return handler
+}
+
 // Execute runs the appropriate handler for a task
 func (e *Executor) Execute(ctx context.Context, t *task.Task) (result map[string]interface{}, err error) {
 	// Panic recovery
@@ -59,6 +89,12 @@ func (e *Executor) Execute(ctx context.Context, t *task.Task) (result map[string
 	if !ok {
 		return nil, ErrHandlerNotFound
 	}
+	handler = e.wrap(t.Type, handler)
+
+	// The queue could not decrypt the payload; another worker may have the key
+	if t.Sealed != nil {
+		return nil, ErrTaskEncrypted
+	}
 
 	log := logger.WithTask(t.ID)
 	log.Debug().
@@ -107,4 +143,67 @@ var (
 	ErrHandlerNotFound = errors.New("handler not found for task type")
 	ErrTaskTimeout     = errors.New("task execution timed out")
 	ErrTaskCanceled    = errors.New("task execution canceled")
+	ErrCircuitOpen     = errors.New("circuit breaker open for task type")
+	ErrResultTooLarge  = errors.New("task result exceeds size limit")
+	ErrTaskEncrypted   = errors.New("task payload is encrypted with a key this worker does not have")
 )
+
+// PermanentError marks a failure that retrying cannot fix.
+// The pool sends such tasks straight to the DLQ regardless of attempts left.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return "permanent failure: " + e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so the task is not retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err (or any error it wraps) is a PermanentError
+func IsPermanent(err error) bool {
+	var pe *PermanentError
+	return errors.As(err, &pe)
+}
+
+// RetryAfterError asks for the next retry to wait at least After.
+// It is used when a downstream service tells us when to come back.
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter wraps err with a minimum delay before the next attempt
+func RetryAfter(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryAfterError{Err: err, After: after}
+}
+
+// RetryDelay returns the minimum delay requested by a RetryAfterError in err's chain
+func RetryDelay(err error) (time.Duration, bool) {
+	var re *RetryAfterError
+	if errors.As(err, &re) {
+		return re.After, true
+	}
+	return 0, false
+}
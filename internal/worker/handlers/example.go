@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/redact"
 	"github.com/maumercado/task-queue-go/internal/task"
 )
 
@@ -15,7 +16,7 @@ import (
 func EchoHandler(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
 	logger.Info().
 		Str("task_id", t.ID).
-		Interface("payload", t.Payload).
+		Interface("payload", redact.Data(t.Type, t.Payload)).
 		Msg("Echo handler processing task")
 
 	return map[string]interface{}{
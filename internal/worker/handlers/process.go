@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/task"
+	"github.com/maumercado/task-queue-go/internal/worker"
+)
+
+const (
+	defaultMaxOutputBytes = 1 << 20
+	maxStderrBytes        = 4 << 10
+
+	// processWaitDelay bounds how long Wait blocks on pipes held open by
+	// grandchildren after the process group has been killed
+	processWaitDelay = 2 * time.Second
+)
+
+// ErrOutputTooLarge is returned when a process writes more than MaxOutputBytes to stdout
+var ErrOutputTooLarge = errors.New("process output too large")
+
+// ProcessHandler runs tasks as an external executable.
+// The payload is written to stdin as JSON and stdout is parsed as the JSON result.
+type ProcessHandler struct {
+	cfg       config.ProcessConfig
+	permanent map[int]bool
+}
+
+// NewProcessHandler validates cfg and creates a ProcessHandler
+func NewProcessHandler(cfg config.ProcessConfig) (*ProcessHandler, error) {
+	if cfg.TaskType == "" {
+		return nil, fmt.Errorf("process handler: task type is required")
+	}
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("process handler %q: command is required", cfg.TaskType)
+	}
+	if hasLimits(cfg.Limits) && !limitsSupported {
+		return nil, fmt.Errorf("process handler %q: resource limits are not supported on this platform", cfg.TaskType)
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = defaultMaxOutputBytes
+	}
+
+	permanent := make(map[int]bool, len(cfg.PermanentExitCodes))
+	for _, code := range cfg.PermanentExitCodes {
+		permanent[code] = true
+	}
+
+	return &ProcessHandler{cfg: cfg, permanent: permanent}, nil
+}
+
+// ProcessHandlers builds a handler for every configured process, keyed by task type
+func ProcessHandlers(cfgs []config.ProcessConfig) (map[string]worker.TaskHandler, error) {
+	handlers := make(map[string]worker.TaskHandler, len(cfgs))
+	for _, cfg := range cfgs {
+		if _, exists := handlers[cfg.TaskType]; exists {
+			return nil, fmt.Errorf("process handler %q: duplicate task type", cfg.TaskType)
+		}
+		h, err := NewProcessHandler(cfg)
+		if err != nil {
+			return nil, err
+		}
+		handlers[cfg.TaskType] = h.Handle
+	}
+	return handlers, nil
+}
+
+// Handle runs the process for a task. Task.Timeout is enforced by killing the
+// whole process group, so helpers spawned by the script do not outlive it.
+func (h *ProcessHandler) Handle(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+	payload := t.Payload
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return nil, worker.Permanent(fmt.Errorf("failed to encode payload: %w", err))
+	}
+
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+
+	name, args := limitCommand(h.cfg.Command, h.cfg.Args, h.cfg.Limits)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = h.cfg.WorkDir
+	cmd.Env = h.environ(t)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = processWaitDelay
+	configureProcessGroup(cmd)
+
+	stdout := &limitedBuffer{limit: h.cfg.MaxOutputBytes}
+	stderr := &tailBuffer{limit: maxStderrBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	logger.Info().
+		Str("task_id", t.ID).
+		Str("command", h.cfg.Command).
+		Msg("Process handler processing task")
+
+	runErr := cmd.Run()
+
+	// Report timeouts and cancellation as such so the executor classifies them
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return nil, fmt.Errorf("failed to run process: %w", runErr)
+		}
+
+		code := exitErr.ExitCode()
+		err := fmt.Errorf("process exited with code %d: %s", code, stderr.String())
+		if code == -1 {
+			err = fmt.Errorf("process terminated by %s: %s", exitErr.ProcessState, stderr.String())
+		}
+		if h.permanent[code] {
+			return nil, worker.Permanent(err)
+		}
+		return nil, err
+	}
+
+	if stdout.overflow {
+		return nil, worker.Permanent(fmt.Errorf("%w: exceeded %d bytes", ErrOutputTooLarge, h.cfg.MaxOutputBytes))
+	}
+
+	return parseProcessOutput(stdout.Bytes())
+}
+
+// environ builds the child environment: optional inherited vars, task identity, then configured vars
+func (h *ProcessHandler) environ(t *task.Task) []string {
+	env := []string{}
+	if h.cfg.InheritEnv {
+		env = append(env, os.Environ()...)
+	}
+	env = append(env,
+		"TASK_ID="+t.ID,
+		"TASK_TYPE="+t.Type,
+		"TASK_ATTEMPT="+strconv.Itoa(t.Attempts),
+	)
+	return append(env, h.cfg.Env...)
+}
+
+// parseProcessOutput decodes stdout as JSON; empty output means no result
+func parseProcessOutput(out []byte) (map[string]interface{}, error) {
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(out, &value); err != nil {
+		return nil, worker.Permanent(fmt.Errorf("invalid JSON on stdout: %w", err))
+	}
+	return worker.EncodeResult(value)
+}
+
+func hasLimits(l config.ProcessLimits) bool {
+	return l.CPUSeconds > 0 || l.MemoryMB > 0 || l.OpenFiles > 0 || l.FileSizeMB > 0
+}
+
+// limitedBuffer keeps the first limit bytes and records whether more were written.
+// The buffer is not embedded so io.Copy cannot bypass Write via ReadFrom.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - int64(b.buf.Len())
+	if int64(len(p)) > remaining {
+		b.overflow = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		// Keep draining so the process is not blocked on a full pipe
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// tailBuffer keeps the last limit bytes written, which is where errors usually are
+type tailBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return strings.TrimSpace(string(b.buf))
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package handlers
+
+import (
+	"os/exec"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+)
+
+const limitsSupported = false
+
+// configureProcessGroup is a no-op; only the direct child is killed on timeout
+func configureProcessGroup(cmd *exec.Cmd) {}
+
+func limitCommand(command string, args []string, limits config.ProcessLimits) (string, []string) {
+	return command, args
+}
@@ -0,0 +1,138 @@
+//go:build unix
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/task"
+	"github.com/maumercado/task-queue-go/internal/worker"
+)
+
+func shellHandler(t *testing.T, script string, mutate ...func(*config.ProcessConfig)) *ProcessHandler {
+	t.Helper()
+	cfg := config.ProcessConfig{
+		TaskType:           "script",
+		Command:            "/bin/sh",
+		Args:               []string{"-c", script},
+		PermanentExitCodes: []int{65},
+	}
+	for _, m := range mutate {
+		m(&cfg)
+	}
+	h, err := NewProcessHandler(cfg)
+	require.NoError(t, err)
+	return h
+}
+
+func TestProcessHandler_PayloadAndResult(t *testing.T) {
+	// Echo stdin back so the result is the payload
+	h := shellHandler(t, "cat")
+	tsk := task.New("script", map[string]interface{}{"name": "alice", "n": float64(3)}, task.PriorityNormal)
+
+	result, err := h.Handle(context.Background(), tsk)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", result["name"])
+	assert.Equal(t, float64(3), result["n"])
+}
+
+func TestProcessHandler_NonObjectAndEmptyOutput(t *testing.T) {
+	result, err := shellHandler(t, "echo 42").Handle(context.Background(), task.New("script", nil, task.PriorityNormal))
+	require.NoError(t, err)
+	assert.Equal(t, float64(42), result["value"])
+
+	result, err = shellHandler(t, "true").Handle(context.Background(), task.New("script", nil, task.PriorityNormal))
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestProcessHandler_InvalidOutputIsPermanent(t *testing.T) {
+	_, err := shellHandler(t, "echo not-json").Handle(context.Background(), task.New("script", nil, task.PriorityNormal))
+	require.Error(t, err)
+	assert.True(t, worker.IsPermanent(err))
+}
+
+func TestProcessHandler_ExitCodes(t *testing.T) {
+	tsk := task.New("script", nil, task.PriorityNormal)
+
+	_, err := shellHandler(t, "echo 'upstream busy' >&2; exit 75").Handle(context.Background(), tsk)
+	require.Error(t, err)
+	assert.False(t, worker.IsPermanent(err), "unlisted exit codes are retryable")
+	assert.Contains(t, err.Error(), "code 75")
+	assert.Contains(t, err.Error(), "upstream busy")
+
+	_, err = shellHandler(t, "echo 'bad input' >&2; exit 65").Handle(context.Background(), tsk)
+	require.Error(t, err)
+	assert.True(t, worker.IsPermanent(err))
+	assert.Contains(t, err.Error(), "bad input")
+}
+
+func TestProcessHandler_TimeoutKillsProcessGroup(t *testing.T) {
+	// The background sleep keeps stdout open; only a group kill ends it promptly
+	h := shellHandler(t, "sleep 30 & sleep 30")
+	tsk := task.New("script", nil, task.PriorityNormal)
+	tsk.Timeout = 200 * time.Millisecond
+
+	start := time.Now()
+	_, err := h.Handle(context.Background(), tsk)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), processWaitDelay)
+}
+
+func TestProcessHandler_EnvAndWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	h := shellHandler(t, `printf '{"dir":"%s","type":"%s","mode":"%s","home":"%s"}' "$(pwd -P)" "$TASK_TYPE" "$MODE" "$HOME"`,
+		func(c *config.ProcessConfig) {
+			c.WorkDir = dir
+			c.Env = []string{"MODE=batch"}
+		})
+
+	result, err := h.Handle(context.Background(), task.New("script", nil, task.PriorityNormal))
+	require.NoError(t, err)
+	assert.Contains(t, result["dir"], dir[1:])
+	assert.Equal(t, "script", result["type"])
+	assert.Equal(t, "batch", result["mode"])
+	assert.Equal(t, "", result["home"], "environment is not inherited by default")
+}
+
+func TestProcessHandler_Limits(t *testing.T) {
+	h := shellHandler(t, `printf '{"nofile":"%s"}' "$(ulimit -n)"`, func(c *config.ProcessConfig) {
+		c.Limits = config.ProcessLimits{OpenFiles: 64}
+	})
+
+	result, err := h.Handle(context.Background(), task.New("script", nil, task.PriorityNormal))
+	require.NoError(t, err)
+	assert.Equal(t, "64", result["nofile"])
+}
+
+func TestProcessHandler_OutputTooLarge(t *testing.T) {
+	h := shellHandler(t, `printf '{"data":"%0200d"}' 0`, func(c *config.ProcessConfig) {
+		c.MaxOutputBytes = 64
+	})
+
+	_, err := h.Handle(context.Background(), task.New("script", nil, task.PriorityNormal))
+	assert.ErrorIs(t, err, ErrOutputTooLarge)
+	assert.True(t, worker.IsPermanent(err))
+}
+
+func TestProcessHandlers_Validation(t *testing.T) {
+	_, err := ProcessHandlers([]config.ProcessConfig{{TaskType: "a"}})
+	assert.Error(t, err, "command is required")
+
+	_, err = ProcessHandlers([]config.ProcessConfig{
+		{TaskType: "a", Command: "/bin/true"},
+		{TaskType: "a", Command: "/bin/true"},
+	})
+	assert.Error(t, err, "duplicate task type")
+
+	handlers, err := ProcessHandlers([]config.ProcessConfig{{TaskType: "a", Command: "/bin/true"}})
+	require.NoError(t, err)
+	assert.Contains(t, handlers, "a")
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package handlers
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+)
+
+const limitsSupported = true
+
+// configureProcessGroup starts the process in its own group and kills the
+// whole group when the context is done
+func configureProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// limitCommand wraps the command in a shell that applies rlimits before exec,
+// so limits are in place before the program's first instruction
+func limitCommand(command string, args []string, limits config.ProcessLimits) (string, []string) {
+	if !hasLimits(limits) {
+		return command, args
+	}
+
+	var script []string
+	if limits.CPUSeconds > 0 {
+		script = append(script, fmt.Sprintf("ulimit -t %d", limits.CPUSeconds))
+	}
+	if limits.MemoryMB > 0 {
+		script = append(script, fmt.Sprintf("ulimit -v %d", limits.MemoryMB*1024)) // KB
+	}
+	if limits.OpenFiles > 0 {
+		script = append(script, fmt.Sprintf("ulimit -n %d", limits.OpenFiles))
+	}
+	if limits.FileSizeMB > 0 {
+		script = append(script, fmt.Sprintf("ulimit -f %d", limits.FileSizeMB*2048)) // 512-byte blocks
+	}
+	script = append(script, `exec "$0" "$@"`)
+
+	return "/bin/sh", append([]string{"-c", strings.Join(script, " && "), command}, args...)
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/task"
+	"github.com/maumercado/task-queue-go/internal/worker"
+)
+
+// Headers sent with every push delivery
+const (
+	SignatureHeader = "X-TaskQueue-Signature"
+	TimestampHeader = "X-TaskQueue-Timestamp"
+	TaskIDHeader    = "X-TaskQueue-Task-ID"
+	AttemptHeader   = "X-TaskQueue-Attempt"
+)
+
+const (
+	defaultEndpointTimeout = 30 * time.Second
+	maxResponseBytes       = 1 << 20
+	maxErrorBodyBytes      = 512
+)
+
+// PushHandler delivers tasks to a remote HTTP endpoint.
+// The full task is POSTed as JSON and the response body becomes the task result.
+type PushHandler struct {
+	cfg    config.EndpointConfig
+	client *http.Client
+	sem    chan struct{}
+}
+
+// NewPushHandler validates cfg and creates a PushHandler.
+// client may be nil, in which case a client with the endpoint timeout is used.
+func NewPushHandler(cfg config.EndpointConfig, client *http.Client) (*PushHandler, error) {
+	if cfg.TaskType == "" {
+		return nil, fmt.Errorf("push handler: task type is required")
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("push handler %q: url must be http or https", cfg.TaskType)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultEndpointTimeout
+	}
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	h := &PushHandler{cfg: cfg, client: client}
+	if cfg.MaxConcurrency > 0 {
+		h.sem = make(chan struct{}, cfg.MaxConcurrency)
+	}
+	return h, nil
+}
+
+// PushHandlers builds a handler for every configured endpoint, keyed by task type
+func PushHandlers(cfgs []config.EndpointConfig) (map[string]worker.TaskHandler, error) {
+	handlers := make(map[string]worker.TaskHandler, len(cfgs))
+	for _, cfg := range cfgs {
+		if _, exists := handlers[cfg.TaskType]; exists {
+			return nil, fmt.Errorf("push handler %q: duplicate task type", cfg.TaskType)
+		}
+		h, err := NewPushHandler(cfg, nil)
+		if err != nil {
+			return nil, err
+		}
+		handlers[cfg.TaskType] = h.Handle
+	}
+	return handlers, nil
+}
+
+// Handle delivers a task and maps the response status:
+// 2xx completes, 429 and 5xx retry (honoring Retry-After), other 4xx fail permanently.
+func (h *PushHandler) Handle(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+	if h.sem != nil {
+		select {
+		case h.sem <- struct{}{}:
+			defer func() { <-h.sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	body, err := json.Marshal(t)
+	if err != nil {
+		return nil, worker.Permanent(fmt.Errorf("failed to encode task: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, worker.Permanent(fmt.Errorf("failed to create request: %w", err))
+	}
+	for k, v := range h.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TaskIDHeader, t.ID)
+	req.Header.Set(AttemptHeader, strconv.Itoa(t.Attempts))
+	if h.cfg.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, ts)
+		req.Header.Set(SignatureHeader, Sign(h.cfg.Secret, ts, body))
+	}
+
+	logger.Info().
+		Str("task_id", t.ID).
+		Str("url", h.cfg.URL).
+		Msg("Push handler delivering task")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("delivery failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		if len(respBody) > maxResponseBytes {
+			return nil, worker.Permanent(fmt.Errorf("%w: response exceeded %d bytes", worker.ErrResultTooLarge, maxResponseBytes))
+		}
+		return parseResponseBody(respBody)
+
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		statusErr := endpointError(resp.StatusCode, respBody)
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return nil, worker.RetryAfter(statusErr, after)
+		}
+		return nil, statusErr
+
+	case resp.StatusCode >= 400:
+		return nil, worker.Permanent(endpointError(resp.StatusCode, respBody))
+
+	default:
+		return nil, fmt.Errorf("unexpected status %d from endpoint", resp.StatusCode)
+	}
+}
+
+// Sign returns the signature header value for a delivery:
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// Receivers recompute it and compare with hmac.Equal.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseResponseBody decodes a JSON response; an empty body means no result
+func parseResponseBody(body []byte) (map[string]interface{}, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, worker.Permanent(fmt.Errorf("invalid JSON response: %w", err))
+	}
+	return worker.EncodeResult(value)
+}
+
+// parseRetryAfter accepts delay-seconds or an HTTP-date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func endpointError(status int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > maxErrorBodyBytes {
+		msg = msg[:maxErrorBodyBytes]
+	}
+	if msg == "" {
+		return fmt.Errorf("endpoint returned status %d", status)
+	}
+	return fmt.Errorf("endpoint returned status %d: %s", status, msg)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/task"
+	"github.com/maumercado/task-queue-go/internal/worker"
+)
+
+func pushHandler(t *testing.T, srv *httptest.Server, mutate ...func(*config.EndpointConfig)) *PushHandler {
+	t.Helper()
+	cfg := config.EndpointConfig{TaskType: "notify", URL: srv.URL, Timeout: time.Second}
+	for _, m := range mutate {
+		m(&cfg)
+	}
+	h, err := NewPushHandler(cfg, srv.Client())
+	require.NoError(t, err)
+	return h
+}
+
+func TestPushHandler_DeliversSignedEnvelope(t *testing.T) {
+	var received task.Task
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		expected := Sign("s3cret", r.Header.Get(TimestampHeader), body)
+		if !hmac.Equal([]byte(expected), []byte(r.Header.Get(SignatureHeader))) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &received)
+		assert.Equal(t, received.ID, r.Header.Get(TaskIDHeader))
+		assert.Equal(t, "abc", r.Header.Get("X-Api-Key"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"delivered": true}`))
+	}))
+	defer srv.Close()
+
+	h := pushHandler(t, srv, func(c *config.EndpointConfig) {
+		c.Secret = "s3cret"
+		c.Headers = map[string]string{"x-api-key": "abc"}
+	})
+	tsk := task.New("notify", map[string]interface{}{"user": "alice"}, task.PriorityHigh)
+
+	result, err := h.Handle(context.Background(), tsk)
+	require.NoError(t, err)
+	assert.Equal(t, true, result["delivered"])
+	assert.Equal(t, tsk.ID, received.ID)
+	assert.Equal(t, "alice", received.Payload["user"])
+}
+
+func TestPushHandler_StatusMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		permanent  bool
+		delay      time.Duration
+	}{
+		{name: "bad request is permanent", status: http.StatusBadRequest, permanent: true},
+		{name: "not found is permanent", status: http.StatusNotFound, permanent: true},
+		{name: "server error retries", status: http.StatusBadGateway},
+		{name: "rate limited honors retry-after", status: http.StatusTooManyRequests, retryAfter: "120", delay: 120 * time.Second},
+		{name: "unavailable honors retry-after", status: http.StatusServiceUnavailable, retryAfter: "7", delay: 7 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("nope"))
+			}))
+			defer srv.Close()
+
+			_, err := pushHandler(t, srv).Handle(context.Background(), task.New("notify", nil, task.PriorityNormal))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "nope")
+			assert.Equal(t, tt.permanent, worker.IsPermanent(err))
+
+			delay, ok := worker.RetryDelay(err)
+			assert.Equal(t, tt.delay != 0, ok)
+			assert.Equal(t, tt.delay, delay)
+		})
+	}
+}
+
+func TestPushHandler_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	h := pushHandler(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := h.Handle(ctx, task.New("notify", nil, task.PriorityNormal))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPushHandler_MaxConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	defer srv.Close()
+
+	h := pushHandler(t, srv, func(c *config.EndpointConfig) { c.MaxConcurrency = 2 })
+
+	done := make(chan struct{})
+	for i := 0; i < 6; i++ {
+		go func() {
+			_, _ = h.Handle(context.Background(), task.New("notify", nil, task.PriorityNormal))
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 6; i++ {
+		<-done
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+}
+
+func TestPushHandler_InvalidResponseIsPermanent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>ok</html>"))
+	}))
+	defer srv.Close()
+
+	_, err := pushHandler(t, srv).Handle(context.Background(), task.New("notify", nil, task.PriorityNormal))
+	assert.True(t, worker.IsPermanent(err))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	d, ok := parseRetryAfter("30", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Second, d)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+}
+
+func TestNewPushHandler_Validation(t *testing.T) {
+	_, err := NewPushHandler(config.EndpointConfig{TaskType: "a", URL: "ftp://x"}, nil)
+	assert.Error(t, err)
+
+	_, err = PushHandlers([]config.EndpointConfig{
+		{TaskType: "a", URL: "http://x"},
+		{TaskType: "a", URL: "http://y"},
+	})
+	assert.Error(t, err)
+}
@@ -29,6 +29,11 @@ type WorkerInfo struct {
 	ActiveTasks   int       `json:"active_tasks"`
 	Concurrency   int       `json:"concurrency"`
 	Version       string    `json:"version,omitempty"`
+
+	// Adaptive concurrency state; omitted when the controller is disabled
+	EffectiveConcurrency int    `json:"effective_concurrency,omitempty"`
+	ConsumptionPaused    bool   `json:"consumption_paused,omitempty"`
+	ThrottleReason       string `json:"throttle_reason,omitempty"`
 }
 
 // Heartbeat manages worker heartbeat mechanism
@@ -109,6 +114,15 @@ func (h *Heartbeat) UpdateConcurrency(concurrency int) {
 	h.infoMu.Unlock()
 }
 
+// UpdateAdaptive updates the adaptive controller's current decision
+func (h *Heartbeat) UpdateAdaptive(limit int, paused bool, reason string) {
+	h.infoMu.Lock()
+	h.info.EffectiveConcurrency = limit
+	h.info.ConsumptionPaused = paused
+	h.info.ThrottleReason = reason
+	h.infoMu.Unlock()
+}
+
 func (h *Heartbeat) heartbeatLoop(ctx context.Context) {
 	defer h.wg.Done()
 
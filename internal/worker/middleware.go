@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maumercado/task-queue-go/internal/logger"
+	"github.com/maumercado/task-queue-go/internal/redact"
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// RedactedValue replaces sensitive payload values in logs
+const RedactedValue = redact.Value
+
+// LogPayload logs each task's payload before execution and its outcome after,
+// replacing the values of the named fields (case-insensitive, at any depth) or
+// JSON paths, in addition to the configured redaction rules.
+func LogPayload(redactFields ...string) Middleware {
+	return func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			log := logger.WithTask(t.ID)
+			log.Info().
+				Str("type", t.Type).
+				Interface("payload", RedactPayload(redact.Data(t.Type, t.Payload), redactFields)).
+				Msg("task payload")
+
+			start := time.Now()
+			result, err := next(ctx, t)
+			if err != nil {
+				log.Info().Err(err).Dur("duration", time.Since(start)).Msg("task handler returned error")
+				return result, err
+			}
+			log.Info().Dur("duration", time.Since(start)).Msg("task handler returned")
+			return result, nil
+		}
+	}
+}
+
+// RedactPayload returns a copy of payload with the named fields replaced.
+// Nested objects and arrays are walked; the original map is not modified.
+func RedactPayload(payload map[string]interface{}, fields []string) map[string]interface{} {
+	return redact.Fields(payload, fields)
+}
+
+// Timeout bounds handler execution to d, in addition to Task.Timeout.
+// Use with Executor.UseFor to give a task type a tighter deadline.
+func Timeout(d time.Duration) Middleware {
+	return func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			if d <= 0 {
+				return next(ctx, t)
+			}
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, t)
+		}
+	}
+}
+
+// MaxResultSize rejects results whose JSON encoding exceeds maxBytes.
+// Oversized results would otherwise be written to task:{id} and every event.
+func MaxResultSize(maxBytes int) Middleware {
+	return func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			result, err := next(ctx, t)
+			if err != nil || result == nil || maxBytes <= 0 {
+				return result, err
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode result: %w", err)
+			}
+			if len(data) > maxBytes {
+				return nil, fmt.Errorf("%w: %d bytes > %d", ErrResultTooLarge, len(data), maxBytes)
+			}
+			return result, nil
+		}
+	}
+}
+
+// breakerState tracks consecutive failures for one task type
+type breakerState struct {
+	failures int
+	openedAt time.Time
+	probing  bool // A half-open trial is in flight
+}
+
+// CircuitBreaker stops calling a task type's handler after threshold consecutive
+// failures. While open, tasks fail fast with ErrCircuitOpen (and follow the normal
+// retry path). After cooldown a single trial task is let through; success closes
+// the circuit, failure reopens it. State is tracked per task type.
+func CircuitBreaker(threshold int, cooldown time.Duration) Middleware {
+	var mu sync.Mutex
+	states := make(map[string]*breakerState)
+
+	return func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			if threshold <= 0 {
+				return next(ctx, t)
+			}
+
+			mu.Lock()
+			st, ok := states[t.Type]
+			if !ok {
+				st = &breakerState{}
+				states[t.Type] = st
+			}
+			if st.failures >= threshold {
+				if st.probing || time.Since(st.openedAt) < cooldown {
+					mu.Unlock()
+					return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, t.Type)
+				}
+				st.probing = true // Half-open: admit one trial
+			}
+			probe := st.probing
+			mu.Unlock()
+
+			result, err := next(ctx, t)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if probe {
+				st.probing = false
+			}
+			if err != nil {
+				st.failures++
+				if st.failures >= threshold {
+					if !probe {
+						logger.Warn().Str("type", t.Type).Int("failures", st.failures).Msg("circuit breaker opened")
+					}
+					st.openedAt = time.Now()
+				}
+				return result, err
+			}
+			if st.failures >= threshold {
+				logger.Info().Str("type", t.Type).Msg("circuit breaker closed")
+			}
+			st.failures = 0
+			st.openedAt = time.Time{}
+			return result, nil
+		}
+	}
+}
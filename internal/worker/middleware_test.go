@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// tagMiddleware appends name to the "trace" result field so chain order is observable.
+func tagMiddleware(name string) Middleware {
+	return func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			result, err := next(ctx, t)
+			if result != nil {
+				result["trace"] = result["trace"].(string) + name
+			}
+			return result, err
+		}
+	}
+}
+
+func TestExecutor_MiddlewareOrder(t *testing.T) {
+	executor := NewExecutor(map[string]TaskHandler{
+		"a": func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			return map[string]interface{}{"trace": ""}, nil
+		},
+		"b": func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			return map[string]interface{}{"trace": ""}, nil
+		},
+	}, nil)
+
+	executor.Use(tagMiddleware("G1"), tagMiddleware("G2"))
+	executor.UseFor("a", tagMiddleware("A"))
+
+	// Results unwind inner-first: per-type, then globals in reverse registration order.
+	result, err := executor.Execute(context.Background(), task.New("a", nil, task.PriorityNormal))
+	require.NoError(t, err)
+	assert.Equal(t, "AG2G1", result["trace"])
+
+	result, err = executor.Execute(context.Background(), task.New("b", nil, task.PriorityNormal))
+	require.NoError(t, err)
+	assert.Equal(t, "G2G1", result["trace"], "per-type middleware does not leak to other types")
+}
+
+func TestExecutor_MiddlewareContextInjection(t *testing.T) {
+	type tenantKey struct{}
+
+	executor := NewExecutor(map[string]TaskHandler{
+		"scoped": func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			return map[string]interface{}{"tenant": ctx.Value(tenantKey{})}, nil
+		},
+	}, nil)
+	executor.Use(func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			return next(context.WithValue(ctx, tenantKey{}, t.Metadata["tenant"]), t)
+		}
+	})
+
+	tsk := task.New("scoped", nil, task.PriorityNormal)
+	tsk.Metadata["tenant"] = "acme"
+
+	result, err := executor.Execute(context.Background(), tsk)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", result["tenant"])
+}
+
+func TestRedactPayload(t *testing.T) {
+	payload := map[string]interface{}{
+		"user":     "alice",
+		"Password": "hunter2",
+		"card": map[string]interface{}{
+			"card_number": "4111111111111111",
+			"brand":       "visa",
+		},
+		"items": []interface{}{
+			map[string]interface{}{"password": "x"},
+		},
+	}
+
+	redacted := RedactPayload(payload, []string{"password", "card_number"})
+
+	assert.Equal(t, "alice", redacted["user"])
+	assert.Equal(t, RedactedValue, redacted["Password"])
+	assert.Equal(t, RedactedValue, redacted["card"].(map[string]interface{})["card_number"])
+	assert.Equal(t, "visa", redacted["card"].(map[string]interface{})["brand"])
+	assert.Equal(t, RedactedValue, redacted["items"].([]interface{})[0].(map[string]interface{})["password"])
+
+	// Original is untouched
+	assert.Equal(t, "hunter2", payload["Password"])
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	executor := NewExecutor(map[string]TaskHandler{
+		"slow": func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			select {
+			case <-time.After(5 * time.Second):
+				return map[string]interface{}{}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}, nil)
+	executor.UseFor("slow", Timeout(20*time.Millisecond))
+
+	_, err := executor.Execute(context.Background(), task.New("slow", nil, task.PriorityNormal))
+	assert.Equal(t, ErrTaskTimeout, err)
+}
+
+func TestMaxResultSizeMiddleware(t *testing.T) {
+	executor := NewExecutor(map[string]TaskHandler{
+		"big": func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			return map[string]interface{}{"data": strings.Repeat("x", 100)}, nil
+		},
+	}, nil)
+	executor.Use(MaxResultSize(50))
+
+	_, err := executor.Execute(context.Background(), task.New("big", nil, task.PriorityNormal))
+	assert.ErrorIs(t, err, ErrResultTooLarge)
+}
+
+func TestCircuitBreakerMiddleware(t *testing.T) {
+	calls := 0
+	fail := true
+	executor := NewExecutor(map[string]TaskHandler{
+		"flaky": func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+			calls++
+			if fail {
+				return nil, errors.New("downstream unavailable")
+			}
+			return map[string]interface{}{"ok": true}, nil
+		},
+	}, nil)
+	executor.Use(CircuitBreaker(2, 50*time.Millisecond))
+
+	run := func() error {
+		_, err := executor.Execute(context.Background(), task.New("flaky", nil, task.PriorityNormal))
+		return err
+	}
+
+	assert.Error(t, run())
+	assert.Error(t, run())
+	assert.ErrorIs(t, run(), ErrCircuitOpen, "third call fails fast")
+	assert.Equal(t, 2, calls)
+
+	// After cooldown one trial goes through and closes the circuit on success.
+	time.Sleep(60 * time.Millisecond)
+	fail = false
+	assert.NoError(t, run())
+	assert.NoError(t, run())
+	assert.Equal(t, 4, calls)
+}
@@ -19,6 +19,10 @@ import (
 // ScheduleTaskFunc schedules a task for delayed execution via the sorted set.
 type ScheduleTaskFunc func(ctx context.Context, t *task.Task, scheduledAt time.Time) error
 
+// TaskDoneFunc is called after a task's outcome has been persisted.
+// err is the handler error, or nil if the task completed.
+type TaskDoneFunc func(ctx context.Context, t *task.Task, err error)
+
 // State represents the worker pool's current operational state
 type State int
 
@@ -55,6 +59,8 @@ type Pool struct {
 	retryPolicy    *task.RetryPolicy   // Policy governing backoff for automatic retries
 	scheduleTask   ScheduleTaskFunc    // Schedules delayed task via sorted set
 	publisher      *events.RedisPubSub // Publishes lifecycle events
+	adaptive       *AdaptiveController // Resource-aware concurrency; nil when disabled
+	taskDoneHooks  []TaskDoneFunc      // Called after each task's outcome is persisted
 	config         *config.WorkerConfig
 	state          State
 	stateMu        sync.RWMutex
@@ -92,19 +98,29 @@ func NewPool(cfg *config.WorkerConfig, queueCfg *config.QueueConfig, q *queue.Re
 		JitterFactor:   queueCfg.RetryJitterFactor,
 	}
 
+	// With adaptive concurrency, spawn enough slots for the configured maximum;
+	// the controller decides how many of them may dequeue at any time.
+	var adaptive *AdaptiveController
+	slots := cfg.Concurrency
+	if cfg.Adaptive.Enabled {
+		adaptive = NewAdaptiveController(cfg.Adaptive, cfg.Concurrency)
+		slots = adaptive.MaxConcurrency()
+	}
+
 	p := &Pool{
 		id:             workerID,
 		queue:          q,
 		dlq:            dlq,
 		retryPolicy:    retryPolicy,
-		scheduleTask:   queue.ScheduleTaskFunc(q.Client()),
+		scheduleTask:   q.ScheduleTask,
 		publisher:      publisher,
+		adaptive:       adaptive,
 		config:         cfg,
 		state:          StateIdle,
 		stopCh:         make(chan struct{}),
 		pauseCh:        make(chan struct{}),
 		resumeCh:       make(chan struct{}),
-		concurrencySem: make(chan struct{}, cfg.Concurrency), // Buffer = max concurrent tasks
+		concurrencySem: make(chan struct{}, slots), // Buffer = max concurrent tasks
 	}
 
 	p.executor = NewExecutor(handlers, retryPolicy)
@@ -119,11 +135,18 @@ func (p *Pool) Start(ctx context.Context) error {
 	p.state = StateBusy
 	p.stateMu.Unlock()
 
+	slots := cap(p.concurrencySem)
+	p.heartbeat.UpdateConcurrency(slots)
+	if p.adaptive != nil {
+		limit, paused, reason := p.adaptive.Status()
+		p.heartbeat.UpdateAdaptive(limit, paused, reason)
+	}
+
 	// Start heartbeat to register with Redis
 	p.heartbeat.Start(ctx)
 
 	// Spawn worker goroutines (one per concurrency slot)
-	for i := 0; i < p.config.Concurrency; i++ {
+	for i := 0; i < slots; i++ {
 		p.wg.Add(1)
 		go p.worker(ctx, i)
 	}
@@ -132,9 +155,16 @@ func (p *Pool) Start(ctx context.Context) error {
 	p.wg.Add(1)
 	go p.recoveryLoop(ctx)
 
+	// Spawn adaptive controller to throttle on resource pressure
+	if p.adaptive != nil {
+		p.wg.Add(1)
+		go p.adaptiveLoop(ctx)
+	}
+
 	logger.Info().
 		Str("worker_id", p.id).
-		Int("concurrency", p.config.Concurrency).
+		Int("concurrency", slots).
+		Bool("adaptive", p.adaptive != nil).
 		Msg("worker pool started")
 
 	return nil
@@ -207,6 +237,17 @@ func (p *Pool) ID() string {
 	return p.id
 }
 
+// OnTaskDone registers a hook called after each task completes, is retried or is dead-lettered.
+// Call before Start.
+func (p *Pool) OnTaskDone(fn TaskDoneFunc) {
+	p.taskDoneHooks = append(p.taskDoneHooks, fn)
+}
+
+// Executor returns the pool's executor so callers can register handlers and middleware
+func (p *Pool) Executor() *Executor {
+	return p.executor
+}
+
 // ActiveTasks returns the count of currently running tasks
 func (p *Pool) ActiveTasks() int {
 	count := 0
@@ -258,6 +299,20 @@ func (p *Pool) worker(ctx context.Context, workerNum int) {
 			}
 		}
 
+		// Block while the adaptive controller has throttled this slot or paused dequeuing
+		if p.adaptive != nil {
+			if ok, changed := p.adaptive.Admit(workerNum); !ok {
+				select {
+				case <-changed:
+					continue
+				case <-p.stopCh:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
 		// Acquire semaphore slot (limits concurrency)
 		select {
 		case p.concurrencySem <- struct{}{}:
@@ -289,6 +344,25 @@ func (p *Pool) processNextTask(ctx context.Context) error {
 		return nil // No task available (timeout)
 	}
 
+	// A task past its deadline is discarded instead of run
+	if t.IsExpired(time.Now()) {
+		return p.handleTaskExpired(ctx, t, messageID)
+	}
+
+	// Respect the tenant's concurrency limit. Dequeue skips saturated tenants,
+	// but other workers may have taken the last slot since.
+	acquired, err := p.queue.AcquireTenantSlot(ctx, t)
+	if err != nil {
+		logger.Warn().Err(err).Str("task_id", t.ID).Msg("failed to acquire tenant slot, running anyway")
+	} else if !acquired {
+		return p.deferForTenant(ctx, t, messageID)
+	}
+	defer func() {
+		if err := p.queue.ReleaseTenantSlot(ctx, t); err != nil {
+			logger.Warn().Err(err).Str("task_id", t.ID).Msg("failed to release tenant slot")
+		}
+	}()
+
 	// Create timeout context for this task's execution
 	taskCtx, cancel := context.WithTimeout(ctx, t.Timeout)
 	defer cancel()
@@ -322,10 +396,64 @@ func (p *Pool) processNextTask(ctx context.Context) error {
 	// Handle success or failure
 	if execErr != nil {
 		p.handleTaskFailure(ctx, t, messageID, execErr, duration)
+		p.notifyTaskDone(ctx, t, execErr)
 		return nil
 	}
 
-	return p.handleTaskSuccess(ctx, t, messageID, result, duration)
+	err = p.handleTaskSuccess(ctx, t, messageID, result, duration)
+	p.notifyTaskDone(ctx, t, nil)
+	return err
+}
+
+// deferForTenant puts a task back at the end of its stream because its tenant
+// has no free concurrency slot
+func (p *Pool) deferForTenant(ctx context.Context, t *task.Task, messageID string) error {
+	if err := p.queue.Enqueue(ctx, t); err != nil {
+		// Leave the message pending; orphan recovery will pick it up
+		return fmt.Errorf("failed to requeue task for tenant limit: %w", err)
+	}
+	if err := p.queue.Acknowledge(ctx, t, messageID); err != nil {
+		logger.Error().Err(err).Str("task_id", t.ID).Msg("failed to acknowledge deferred task")
+	}
+	logger.Debug().Str("task_id", t.ID).Str("tenant", t.Tenant).Msg("tenant at concurrency limit, task requeued")
+	return nil
+}
+
+// notifyTaskDone runs the registered task-done hooks
+func (p *Pool) notifyTaskDone(ctx context.Context, t *task.Task, execErr error) {
+	for _, fn := range p.taskDoneHooks {
+		fn(ctx, t, execErr)
+	}
+}
+
+// handleTaskExpired marks a task that was not started before its deadline as
+// expired and acknowledges the message without running it
+func (p *Pool) handleTaskExpired(ctx context.Context, t *task.Task, messageID string) error {
+	sm := task.NewStateMachine(t)
+	if err := sm.Expire(); err != nil {
+		return fmt.Errorf("failed to expire task: %w", err)
+	}
+
+	if err := p.queue.UpdateTask(ctx, t); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	if err := p.queue.Acknowledge(ctx, t, messageID); err != nil {
+		return fmt.Errorf("failed to acknowledge: %w", err)
+	}
+
+	metrics.RecordTaskExpired(t.Type, "worker")
+	p.publishTaskEvent(ctx, events.EventTaskExpired, t, map[string]interface{}{
+		"expires_at": t.ExpiresAt,
+	})
+
+	logger.Info().
+		Str("task_id", t.ID).
+		Str("type", t.Type).
+		Time("expires_at", *t.ExpiresAt).
+		Msg("task expired before it could run")
+
+	return nil
 }
 
 // handleTaskSuccess marks task as completed and acknowledges the message
@@ -362,7 +490,7 @@ func (p *Pool) handleTaskSuccess(ctx context.Context, t *task.Task, messageID st
 
 // handleTaskFailure handles retry logic or moves to DLQ.
 // Retryable failures are scheduled for delayed retry via the sorted set using
-// exponential backoff. Exhausted tasks go to the dead letter queue.
+// exponential backoff. Exhausted tasks and permanent errors go to the dead letter queue.
 // The stream message is ACK'd only after the retry/DLQ path is safely committed.
 func (p *Pool) handleTaskFailure(ctx context.Context, t *task.Task, messageID string, execErr error, duration time.Duration) {
 	log := logger.WithTask(t.ID)
@@ -370,7 +498,8 @@ func (p *Pool) handleTaskFailure(ctx context.Context, t *task.Task, messageID st
 
 	sm := task.NewStateMachine(t)
 
-	if t.CanRetry() {
+	permanent := IsPermanent(execErr)
+	if t.CanRetry() && !permanent {
 		// Transition running -> failed first so ScheduleRetry can go failed -> retrying.
 		if err := sm.Fail(execErr.Error()); err != nil {
 			log.Error().Err(err).Msg("failed to mark task as failed before retry")
@@ -387,6 +516,13 @@ func (p *Pool) handleTaskFailure(ctx context.Context, t *task.Task, messageID st
 			return
 		}
 
+		// Honor a delay requested by the handler if it is later than the backoff.
+		if after, ok := RetryDelay(execErr); ok && t.ScheduledAt != nil {
+			if at := time.Now().UTC().Add(after); at.After(*t.ScheduledAt) {
+				t.ScheduledAt = &at
+			}
+		}
+
 		// Persist retrying state + ScheduledAt before adding to sorted set.
 		if err := p.queue.UpdateTask(ctx, t); err != nil {
 			log.Error().Err(err).Msg("failed to persist retrying task")
@@ -430,14 +566,18 @@ func (p *Pool) handleTaskFailure(ctx context.Context, t *task.Task, messageID st
 		return
 	}
 
-	// Max retries exceeded — move to dead letter queue.
+	// Max retries exceeded or permanent failure — move to dead letter queue.
+	reason := "max retries exceeded"
+	if permanent {
+		reason = "permanent failure"
+	}
 	if err := sm.Fail(execErr.Error()); err != nil {
 		log.Error().Err(err).Msg("failed to mark task as failed")
 	}
 	if err := p.queue.UpdateTask(ctx, t); err != nil {
 		log.Error().Err(err).Msg("failed to update task before DLQ")
 	}
-	if err := p.dlq.Add(ctx, t, "max retries exceeded"); err != nil {
+	if err := p.dlq.Add(ctx, t, reason); err != nil {
 		log.Error().Err(err).Msg("failed to add task to DLQ")
 	}
 
@@ -445,6 +585,7 @@ func (p *Pool) handleTaskFailure(ctx context.Context, t *task.Task, messageID st
 	metrics.IncrementDLQAdded()
 	p.publishTaskEvent(ctx, events.EventTaskFailed, t, map[string]interface{}{
 		"error":       execErr.Error(),
+		"reason":      reason,
 		"duration_ms": duration.Milliseconds(),
 	})
 
@@ -472,6 +613,52 @@ func (p *Pool) recoveryLoop(ctx context.Context) {
 	}
 }
 
+// adaptiveLoop periodically samples resources and applies the controller's decision
+func (p *Pool) adaptiveLoop(ctx context.Context) {
+	defer p.wg.Done()
+
+	interval := p.config.Adaptive.SampleInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sampler := newResourceSampler(p.queue.Client())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			p.applyAdaptiveSample(sampler.Sample(ctx))
+		}
+	}
+}
+
+// applyAdaptiveSample feeds one sample to the controller and publishes the outcome
+func (p *Pool) applyAdaptiveSample(sample ResourceSample) {
+	decision := p.adaptive.Evaluate(sample)
+
+	metrics.RecordAdaptiveSample(p.id, sample.CPUPercent, sample.RSSBytes, sample.RedisLatency.Seconds())
+	metrics.RecordAdaptiveDecision(p.id, decision.Action, decision.Limit, decision.Paused)
+
+	_, _, reason := p.adaptive.Status()
+	p.heartbeat.UpdateAdaptive(decision.Limit, decision.Paused, reason)
+
+	if decision.Action != AdaptiveActionHold {
+		logger.Info().
+			Str("worker_id", p.id).
+			Str("action", decision.Action).
+			Int("limit", decision.Limit).
+			Bool("paused", decision.Paused).
+			Str("reason", decision.Reason).
+			Msg("adaptive concurrency changed")
+	}
+}
+
 // recoverOrphanedTasks claims and re-queues tasks from dead workers
 func (p *Pool) recoverOrphanedTasks(ctx context.Context) {
 	// Claim tasks that have been pending too long (worker likely crashed)
@@ -518,6 +705,9 @@ func (p *Pool) publishTaskEvent(ctx context.Context, eventType events.EventType,
 		"attempts":  t.Attempts,
 		"worker_id": p.id,
 	}
+	if t.Tenant != "" {
+		data["tenant"] = t.Tenant
+	}
 	if t.ScheduledAt != nil {
 		data["scheduled_at"] = t.ScheduledAt
 	}
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+// TypedHandler processes a decoded payload and returns a typed result
+type TypedHandler[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Register registers a typed handler for a task type.
+// The payload is decoded into In, rejecting unknown fields; a decode failure is a
+// permanent error so malformed tasks go to the DLQ instead of being retried.
+// Out is encoded into Task.Result; non-object results are stored under "value".
+func Register[In, Out any](e *Executor, taskType string, fn TypedHandler[In, Out]) {
+	e.RegisterHandler(taskType, Typed(fn))
+}
+
+// Typed adapts a TypedHandler to a TaskHandler
+func Typed[In, Out any](fn TypedHandler[In, Out]) TaskHandler {
+	return func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+		var in In
+		if err := DecodePayload(t.Payload, &in); err != nil {
+			return nil, Permanent(fmt.Errorf("invalid payload for task type %q: %w", t.Type, err))
+		}
+
+		out, err := fn(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := EncodeResult(out)
+		if err != nil {
+			return nil, Permanent(fmt.Errorf("failed to encode result for task type %q: %w", t.Type, err))
+		}
+		return result, nil
+	}
+}
+
+// DecodePayload decodes a task payload into v, rejecting fields v does not declare
+func DecodePayload(payload map[string]interface{}, v interface{}) error {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// EncodeResult converts a handler result into the map stored in Task.Result
+func EncodeResult(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err == nil {
+		return result, nil
+	}
+
+	// Scalars and arrays are wrapped so Result stays a JSON object
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"value": value}, nil
+}
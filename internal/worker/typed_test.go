@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/task"
+)
+
+type resizeInput struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type resizeOutput struct {
+	Thumbnail string `json:"thumbnail"`
+	Bytes     int    `json:"bytes"`
+}
+
+func TestRegister_TypedHandler(t *testing.T) {
+	executor := NewExecutor(nil, nil)
+	Register(executor, "resize", func(ctx context.Context, in resizeInput) (resizeOutput, error) {
+		return resizeOutput{Thumbnail: in.URL + "?w=" + "100", Bytes: in.Width * in.Height}, nil
+	})
+
+	// Payloads arrive from JSON, so numbers are float64
+	tsk := task.New("resize", map[string]interface{}{
+		"url":    "s3://img.png",
+		"width":  float64(10),
+		"height": float64(20),
+	}, task.PriorityNormal)
+
+	result, err := executor.Execute(context.Background(), tsk)
+	require.NoError(t, err)
+	assert.Equal(t, "s3://img.png?w=100", result["thumbnail"])
+	assert.Equal(t, float64(200), result["bytes"])
+}
+
+func TestRegister_UnknownFieldIsPermanent(t *testing.T) {
+	executor := NewExecutor(nil, nil)
+	called := false
+	Register(executor, "resize", func(ctx context.Context, in resizeInput) (resizeOutput, error) {
+		called = true
+		return resizeOutput{}, nil
+	})
+
+	tsk := task.New("resize", map[string]interface{}{"url": "x", "colour": "red"}, task.PriorityNormal)
+
+	_, err := executor.Execute(context.Background(), tsk)
+	require.Error(t, err)
+	assert.True(t, IsPermanent(err))
+	assert.Contains(t, err.Error(), `invalid payload for task type "resize"`)
+	assert.Contains(t, err.Error(), "colour")
+	assert.False(t, called)
+}
+
+func TestRegister_WrongTypeIsPermanent(t *testing.T) {
+	executor := NewExecutor(nil, nil)
+	Register(executor, "resize", func(ctx context.Context, in resizeInput) (resizeOutput, error) {
+		return resizeOutput{}, nil
+	})
+
+	tsk := task.New("resize", map[string]interface{}{"width": "wide"}, task.PriorityNormal)
+
+	_, err := executor.Execute(context.Background(), tsk)
+	assert.True(t, IsPermanent(err))
+}
+
+func TestRegister_HandlerErrorIsRetryable(t *testing.T) {
+	executor := NewExecutor(nil, nil)
+	Register(executor, "resize", func(ctx context.Context, in resizeInput) (resizeOutput, error) {
+		return resizeOutput{}, errors.New("storage unavailable")
+	})
+
+	_, err := executor.Execute(context.Background(), task.New("resize", nil, task.PriorityNormal))
+	require.Error(t, err)
+	assert.False(t, IsPermanent(err))
+}
+
+func TestEncodeResult(t *testing.T) {
+	result, err := EncodeResult("done")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"value": "done"}, result)
+
+	result, err = EncodeResult([]int{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, result["value"])
+
+	var nilOut *resizeOutput
+	result, err = EncodeResult(nilOut)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestPermanentError(t *testing.T) {
+	base := errors.New("bad input")
+	err := Permanent(base)
+
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, base)
+	assert.Equal(t, "permanent failure: bad input", err.Error())
+	assert.Nil(t, Permanent(nil))
+	assert.False(t, IsPermanent(base))
+}
+
+func TestRetryAfterError(t *testing.T) {
+	base := errors.New("rate limited")
+	err := fmt.Errorf("delivery: %w", RetryAfter(base, 30*time.Second))
+
+	delay, ok := RetryDelay(err)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, delay)
+	assert.ErrorIs(t, err, base)
+	assert.False(t, IsPermanent(err))
+
+	_, ok = RetryDelay(base)
+	assert.False(t, ok)
+}
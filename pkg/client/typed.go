@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TaskOption configures a task submitted with Enqueue.
+type TaskOption func(*CreateTaskRequest)
+
+// WithTaskPriority sets the priority (0=low, 1=normal, 2=high, 3=critical).
+func WithTaskPriority(priority int) TaskOption {
+	return func(r *CreateTaskRequest) {
+		r.Priority = &priority
+	}
+}
+
+// WithTaskMaxRetries sets the maximum number of retry attempts.
+func WithTaskMaxRetries(maxRetries int) TaskOption {
+	return func(r *CreateTaskRequest) {
+		r.MaxRetries = &maxRetries
+	}
+}
+
+// WithTaskTimeout sets the execution timeout.
+func WithTaskTimeout(timeout time.Duration) TaskOption {
+	return func(r *CreateTaskRequest) {
+		seconds := int(timeout / time.Second)
+		r.Timeout = &seconds
+	}
+}
+
+// WithTaskScheduledAt schedules the task for a future time.
+func WithTaskScheduledAt(at time.Time) TaskOption {
+	return func(r *CreateTaskRequest) {
+		r.ScheduledAt = &at
+	}
+}
+
+// WithTaskMetadata sets custom metadata on the task.
+func WithTaskMetadata(metadata map[string]string) TaskOption {
+	return func(r *CreateTaskRequest) {
+		r.Metadata = &metadata
+	}
+}
+
+// Enqueue submits a task whose payload is encoded from a typed value.
+// The payload must encode to a JSON object; its field names should match the
+// struct the worker registered for taskType.
+func Enqueue[P any](ctx context.Context, c *TaskQueueClient, taskType string, payload P, opts ...TaskOption) (*TaskResponse, error) {
+	req, err := NewTaskRequest(taskType, payload, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.SubmitTask(ctx, req)
+}
+
+// NewTaskRequest builds a CreateTaskRequest from a typed payload.
+func NewTaskRequest[P any](taskType string, payload P, opts ...TaskOption) (CreateTaskRequest, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return CreateTaskRequest{}, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return CreateTaskRequest{}, fmt.Errorf("payload must encode to a JSON object: %w", err)
+	}
+
+	req := CreateTaskRequest{Type: taskType}
+	if m != nil {
+		req.Payload = &m
+	}
+	for _, opt := range opts {
+		opt(&req)
+	}
+	return req, nil
+}
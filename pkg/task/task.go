@@ -0,0 +1,64 @@
+// Package task exposes the task model handled by workers.
+//
+// The types here are aliases of the types used inside the server, so a
+// *task.Task received by a pkg/worker handler is the same value the queue
+// stores. Exported identifiers in this package follow semantic versioning:
+// they will not be removed or change meaning within a major version. New
+// fields may be added to Task; do not rely on positional struct literals.
+package task
+
+import (
+	internal "github.com/maumercado/task-queue-go/internal/task"
+)
+
+// Task represents a unit of work in the queue
+type Task = internal.Task
+
+// Priority levels for task ordering
+type Priority = internal.Priority
+
+// State represents the current state of a task
+type State = internal.State
+
+// Priority levels
+const (
+	PriorityLow      = internal.PriorityLow
+	PriorityNormal   = internal.PriorityNormal
+	PriorityHigh     = internal.PriorityHigh
+	PriorityCritical = internal.PriorityCritical
+)
+
+// Task states
+const (
+	StatePending    = internal.StatePending
+	StateScheduled  = internal.StateScheduled
+	StateRunning    = internal.StateRunning
+	StateCompleted  = internal.StateCompleted
+	StateFailed     = internal.StateFailed
+	StateRetrying   = internal.StateRetrying
+	StateCanceled   = internal.StateCanceled
+	StateDeadLetter = internal.StateDeadLetter
+	StateExpired    = internal.StateExpired
+)
+
+// Error definitions
+var (
+	ErrTaskNotFound    = internal.ErrTaskNotFound
+	ErrInvalidTaskData = internal.ErrInvalidTaskData
+)
+
+// New creates a new Task with default values
+func New(taskType string, payload map[string]interface{}, priority Priority) *Task {
+	return internal.New(taskType, payload, priority)
+}
+
+// ParsePriority converts a priority name ("low", "normal", "high", "critical") to a Priority.
+// Unknown names map to PriorityNormal.
+func ParsePriority(s string) Priority {
+	return internal.ParsePriority(s)
+}
+
+// ParseState converts a state name to a State. Unknown names map to StatePending.
+func ParseState(s string) State {
+	return internal.ParseState(s)
+}
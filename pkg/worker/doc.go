@@ -0,0 +1,72 @@
+// Package worker provides an embeddable task queue worker.
+//
+// It is the public counterpart of the worker binary: register handlers,
+// configure the pool in code, and start it inside your own service.
+//
+// # Basic Usage
+//
+//	cfg := worker.DefaultConfig()
+//	cfg.RedisAddr = "redis:6379"
+//	cfg.Concurrency = 20
+//
+//	w, err := worker.New(cfg)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	w.Register("email", func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+//	    to, _ := t.Payload["to"].(string)
+//	    // Send email...
+//	    return map[string]interface{}{"sent_to": to}, nil
+//	})
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+//	defer stop()
+//	if err := w.Run(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// # Typed Handlers
+//
+// Register decodes the payload into a struct and stores the returned value as
+// the task result. Payloads with unknown or mistyped fields fail permanently.
+//
+//	type EmailInput struct {
+//	    To      string `json:"to"`
+//	    Subject string `json:"subject"`
+//	}
+//
+//	worker.Register(w, "email", func(ctx context.Context, in EmailInput) (map[string]string, error) {
+//	    return map[string]string{"sent_to": in.To}, nil
+//	})
+//
+// Return Permanent(err) from any handler to dead-letter a task without retrying it.
+//
+// # Lifecycle Hooks
+//
+//	w.OnStart(func(ctx context.Context) { ready.Store(true) })
+//	w.OnStop(func(ctx context.Context) { ready.Store(false) })
+//	w.OnTaskDone(func(ctx context.Context, t *task.Task, err error) {
+//	    auditLog(t.ID, t.State, err)
+//	})
+//
+// # Embedding
+//
+// Use WithRedisClient to share a connection your service already owns, and
+// Start/Stop instead of Run to tie the worker to your own shutdown sequence.
+//
+// # Tenants
+//
+// Set Tenants to the API server's queue.tenants so the worker knows every
+// tenant's streams and concurrency limits, and ServeTenants to consume only
+// some of them:
+//
+//	cfg.Tenants = []worker.TenantConfig{{Name: "billing", MaxConcurrency: 5}, {Name: "search"}}
+//	cfg.ServeTenants = []string{"billing"}
+//
+// # Compatibility
+//
+// This package and pkg/task follow semantic versioning. Exported identifiers
+// will not be removed or change behavior within a major version. Fields may be
+// added to Config; construct it from DefaultConfig and set fields by name.
+package worker
@@ -0,0 +1,365 @@
+package worker
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/maumercado/task-queue-go/internal/config"
+	"github.com/maumercado/task-queue-go/internal/encryption"
+	"github.com/maumercado/task-queue-go/internal/events"
+	"github.com/maumercado/task-queue-go/internal/queue"
+	internal "github.com/maumercado/task-queue-go/internal/worker"
+	"github.com/maumercado/task-queue-go/pkg/task"
+)
+
+// Handler processes a task and returns its result
+type Handler = internal.TaskHandler
+
+// Middleware wraps a Handler with cross-cutting behavior
+type Middleware = internal.Middleware
+
+// TypedHandler processes a decoded payload and returns a typed result
+type TypedHandler[In, Out any] = internal.TypedHandler[In, Out]
+
+// PermanentError marks a handler error that must not be retried
+type PermanentError = internal.PermanentError
+
+// TenantConfig declares a tenant and its quotas, as in queue.tenants
+type TenantConfig = config.TenantConfig
+
+// Hook is called when the worker starts or stops
+type Hook func(ctx context.Context)
+
+// TaskDoneHook is called after each task's outcome has been persisted.
+// err is the handler error, or nil if the task completed.
+type TaskDoneHook func(ctx context.Context, t *task.Task, err error)
+
+// Error definitions
+var (
+	ErrHandlerNotFound = internal.ErrHandlerNotFound
+	ErrTaskTimeout     = internal.ErrTaskTimeout
+	ErrTaskCanceled    = internal.ErrTaskCanceled
+	ErrCircuitOpen     = internal.ErrCircuitOpen
+	ErrResultTooLarge  = internal.ErrResultTooLarge
+	ErrTaskEncrypted   = internal.ErrTaskEncrypted
+	ErrAlreadyStarted  = errors.New("worker already started")
+	ErrNotStarted      = errors.New("worker not started")
+)
+
+// Config configures a Worker programmatically
+type Config struct {
+	ID                string // Auto-generated if empty
+	Concurrency       int
+	HeartbeatInterval time.Duration
+	HeartbeatTimeout  time.Duration
+	ShutdownTimeout   time.Duration
+
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	RedisPoolSize int
+	RedisTLS      *tls.Config // nil = no TLS
+
+	StreamPrefix  string
+	ConsumerGroup string
+	BlockTimeout  time.Duration
+	ClaimMinIdle  time.Duration
+
+	Tenants      []TenantConfig // Known tenants; must match the API server's queue.tenants
+	ServeTenants []string       // Tenants to consume from ("default" = untenanted); empty = all
+
+	RetryMaxAttempts    int
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+	RetryBackoffFactor  float64
+	RetryJitterFactor   float64
+
+	DisableEvents bool // Skip publishing lifecycle events to Redis Pub/Sub
+
+	KeyringFile      string   // Keyring for encrypted payloads and results; "" = no encryption
+	EncryptTaskTypes []string // Task types encrypted at rest (empty = all)
+}
+
+// DefaultConfig returns the same defaults the worker binary uses
+func DefaultConfig() Config {
+	return Config{
+		Concurrency:         10,
+		HeartbeatInterval:   5 * time.Second,
+		HeartbeatTimeout:    15 * time.Second,
+		ShutdownTimeout:     30 * time.Second,
+		RedisAddr:           "localhost:6379",
+		RedisPoolSize:       100,
+		StreamPrefix:        "tasks",
+		ConsumerGroup:       "workers",
+		BlockTimeout:        5 * time.Second,
+		ClaimMinIdle:        30 * time.Second,
+		RetryMaxAttempts:    3,
+		RetryInitialBackoff: 1 * time.Second,
+		RetryMaxBackoff:     5 * time.Minute,
+		RetryBackoffFactor:  2.0,
+		RetryJitterFactor:   0.1,
+	}
+}
+
+// Option configures a Worker
+type Option func(*options)
+
+type options struct {
+	client *redis.Client
+}
+
+// WithRedisClient reuses an existing Redis client instead of dialing RedisAddr.
+// The worker does not close a client it did not create.
+func WithRedisClient(client *redis.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// Worker consumes tasks from the queue and runs registered handlers
+type Worker struct {
+	cfg        Config
+	client     *redis.Client
+	ownsClient bool
+	queue      *queue.RedisQueue
+	publisher  *events.RedisPubSub
+	pool       *internal.Pool
+	onStart    []Hook
+	onStop     []Hook
+	mu         sync.Mutex
+	started    bool
+}
+
+// New connects to Redis and creates a Worker. Register handlers before calling Start.
+func New(cfg Config, opts ...Option) (*Worker, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	client := o.client
+	ownsClient := false
+	if client == nil {
+		client = redis.NewClient(&redis.Options{
+			Addr:      cfg.RedisAddr,
+			Password:  cfg.RedisPassword,
+			DB:        cfg.RedisDB,
+			PoolSize:  cfg.RedisPoolSize,
+			TLSConfig: cfg.RedisTLS,
+		})
+		ownsClient = true
+	}
+
+	queueCfg := cfg.queueConfig()
+	q, err := queue.NewRedisQueueWithClient(client, queueCfg)
+	if err == nil {
+		err = q.ServeTenants(cfg.ServeTenants)
+	}
+	if err != nil {
+		if ownsClient {
+			_ = client.Close()
+		}
+		return nil, err
+	}
+
+	sealer, err := encryption.NewSealer(&config.EncryptionConfig{
+		Enabled:     cfg.KeyringFile != "",
+		KeyringFile: cfg.KeyringFile,
+		TaskTypes:   cfg.EncryptTaskTypes,
+	})
+	if err != nil {
+		if ownsClient {
+			_ = client.Close()
+		}
+		return nil, err
+	}
+	q.SetSealer(sealer)
+	dlq := queue.NewDLQ(client)
+	dlq.SetSealer(sealer)
+
+	var publisher *events.RedisPubSub
+	if !cfg.DisableEvents {
+		publisher = events.NewRedisPubSub(client)
+	}
+
+	pool := internal.NewPool(cfg.workerConfig(), queueCfg, q, dlq, nil, publisher)
+
+	return &Worker{
+		cfg:        cfg,
+		client:     client,
+		ownsClient: ownsClient,
+		queue:      q,
+		publisher:  publisher,
+		pool:       pool,
+	}, nil
+}
+
+// ID returns the worker's unique identifier
+func (w *Worker) ID() string {
+	return w.pool.ID()
+}
+
+// Register registers a handler for a task type
+func (w *Worker) Register(taskType string, h Handler) {
+	w.pool.Executor().RegisterHandler(taskType, h)
+}
+
+// Register registers a typed handler for a task type on w.
+// The payload is decoded into In (unknown fields are rejected) and Out is
+// stored as the task result. Payloads that fail to decode are dead-lettered
+// without retries.
+func Register[In, Out any](w *Worker, taskType string, fn TypedHandler[In, Out]) {
+	internal.Register(w.pool.Executor(), taskType, fn)
+}
+
+// Permanent wraps err so the task is dead-lettered instead of retried
+func Permanent(err error) error {
+	return internal.Permanent(err)
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	return internal.IsPermanent(err)
+}
+
+// RetryAfter wraps err so the next retry waits at least d, even if the backoff is shorter
+func RetryAfter(err error, d time.Duration) error {
+	return internal.RetryAfter(err, d)
+}
+
+// Use appends middleware applied to every task type. The first is outermost.
+func (w *Worker) Use(mw ...Middleware) {
+	w.pool.Executor().Use(mw...)
+}
+
+// UseFor appends middleware applied only to the given task type
+func (w *Worker) UseFor(taskType string, mw ...Middleware) {
+	w.pool.Executor().UseFor(taskType, mw...)
+}
+
+// OnStart registers a hook run after the worker has started consuming
+func (w *Worker) OnStart(fn Hook) {
+	w.onStart = append(w.onStart, fn)
+}
+
+// OnStop registers a hook run after in-flight tasks have drained
+func (w *Worker) OnStop(fn Hook) {
+	w.onStop = append(w.onStop, fn)
+}
+
+// OnTaskDone registers a hook run after each task completes, is retried or is dead-lettered
+func (w *Worker) OnTaskDone(fn TaskDoneHook) {
+	w.pool.OnTaskDone(internal.TaskDoneFunc(fn))
+}
+
+// Start begins consuming tasks in background goroutines and returns immediately
+func (w *Worker) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.started {
+		return ErrAlreadyStarted
+	}
+	if err := w.pool.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start worker pool: %w", err)
+	}
+	w.started = true
+
+	for _, fn := range w.onStart {
+		fn(ctx)
+	}
+	return nil
+}
+
+// Stop waits for in-flight tasks (bounded by ShutdownTimeout and ctx), runs
+// OnStop hooks and releases resources the worker created.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.started {
+		return ErrNotStarted
+	}
+	w.started = false
+
+	// Hooks and closes run even if the pool did not drain in time
+	poolErr := w.pool.Stop(ctx)
+
+	for _, fn := range w.onStop {
+		fn(ctx)
+	}
+
+	if w.publisher != nil {
+		_ = w.publisher.Close()
+	}
+	var closeErr error
+	if w.ownsClient {
+		closeErr = w.client.Close()
+	}
+	return errors.Join(poolErr, closeErr)
+}
+
+// Run starts the worker and blocks until ctx is canceled, then stops it.
+// This is the simplest way to embed a worker in an existing service.
+func (w *Worker) Run(ctx context.Context) error {
+	// The pool's goroutines must outlive ctx so in-flight tasks can drain on Stop.
+	if err := w.Start(context.WithoutCancel(ctx)); err != nil {
+		return err
+	}
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), w.cfg.ShutdownTimeout)
+	defer cancel()
+	return w.Stop(shutdownCtx)
+}
+
+// LogPayload logs payloads with the named fields redacted
+func LogPayload(redactFields ...string) Middleware {
+	return internal.LogPayload(redactFields...)
+}
+
+// Timeout bounds handler execution to d, in addition to the task's own timeout
+func Timeout(d time.Duration) Middleware {
+	return internal.Timeout(d)
+}
+
+// CircuitBreaker fails a task type fast after threshold consecutive failures
+func CircuitBreaker(threshold int, cooldown time.Duration) Middleware {
+	return internal.CircuitBreaker(threshold, cooldown)
+}
+
+// MaxResultSize rejects results whose JSON encoding exceeds maxBytes
+func MaxResultSize(maxBytes int) Middleware {
+	return internal.MaxResultSize(maxBytes)
+}
+
+func (c Config) workerConfig() *config.WorkerConfig {
+	return &config.WorkerConfig{
+		ID:                c.ID,
+		Concurrency:       c.Concurrency,
+		HeartbeatInterval: c.HeartbeatInterval,
+		HeartbeatTimeout:  c.HeartbeatTimeout,
+		ShutdownTimeout:   c.ShutdownTimeout,
+	}
+}
+
+func (c Config) queueConfig() *config.QueueConfig {
+	return &config.QueueConfig{
+		StreamPrefix:        c.StreamPrefix,
+		ConsumerGroup:       c.ConsumerGroup,
+		BlockTimeout:        c.BlockTimeout,
+		ClaimMinIdle:        c.ClaimMinIdle,
+		Tenants:             c.Tenants,
+		RetryMaxAttempts:    c.RetryMaxAttempts,
+		RetryInitialBackoff: c.RetryInitialBackoff,
+		RetryMaxBackoff:     c.RetryMaxBackoff,
+		RetryBackoffFactor:  c.RetryBackoffFactor,
+		RetryJitterFactor:   c.RetryJitterFactor,
+	}
+}
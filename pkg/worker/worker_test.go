@@ -0,0 +1,162 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maumercado/task-queue-go/internal/queue"
+	"github.com/maumercado/task-queue-go/pkg/task"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	cfg := DefaultConfig()
+
+	assert.Equal(t, 10, cfg.Concurrency)
+	assert.Equal(t, "tasks", cfg.StreamPrefix)
+	assert.Equal(t, "workers", cfg.ConsumerGroup)
+	assert.Equal(t, 3, cfg.RetryMaxAttempts)
+	assert.False(t, cfg.DisableEvents)
+}
+
+func TestConfig_InternalMapping(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ID = "embedded-1"
+	cfg.Concurrency = 4
+	cfg.RetryInitialBackoff = 250 * time.Millisecond
+
+	wcfg := cfg.workerConfig()
+	assert.Equal(t, "embedded-1", wcfg.ID)
+	assert.Equal(t, 4, wcfg.Concurrency)
+	assert.Equal(t, cfg.ShutdownTimeout, wcfg.ShutdownTimeout)
+
+	qcfg := cfg.queueConfig()
+	assert.Equal(t, "tasks", qcfg.StreamPrefix)
+	assert.Equal(t, 250*time.Millisecond, qcfg.RetryInitialBackoff)
+	assert.Equal(t, 2.0, qcfg.RetryBackoffFactor)
+}
+
+func TestNew_UnreachableRedis(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RedisAddr = "127.0.0.1:1" // Nothing listens on port 1
+
+	w, err := New(cfg)
+	require.Error(t, err)
+	assert.Nil(t, w)
+}
+
+// fakeTenantRedis serves one task on a tenant's normal-priority stream and
+// answers every other command with an empty reply
+type fakeTenantRedis struct {
+	stream string
+	task   []byte
+
+	mu        sync.Mutex
+	delivered bool
+	xgroups   []string // Streams given consumer groups
+}
+
+func (f *fakeTenantRedis) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (f *fakeTenantRedis) ProcessPipelineHook(redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			_ = f.process(ctx, cmd)
+		}
+		return nil
+	}
+}
+
+func (f *fakeTenantRedis) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return f.process
+}
+
+func (f *fakeTenantRedis) process(ctx context.Context, cmd redis.Cmder) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	args := cmd.Args()
+	switch cmd.Name() {
+	case "xgroup":
+		f.xgroups = append(f.xgroups, args[2].(string))
+	case "xreadgroup":
+		streams := make([]string, 0, len(args))
+		for _, a := range args {
+			streams = append(streams, fmt.Sprint(a))
+		}
+		if !f.delivered && slices.Contains(streams, f.stream) {
+			f.delivered = true
+			cmd.(*redis.XStreamSliceCmd).SetVal([]redis.XStream{{
+				Stream:   f.stream,
+				Messages: []redis.XMessage{{ID: "1-0", Values: map[string]interface{}{"task_id": "t1", "type": "email", "gen": "0"}}},
+			}})
+			return nil
+		}
+		f.mu.Unlock()
+		select { // A blocking read that times out
+		case <-ctx.Done():
+		case <-time.After(10 * time.Millisecond):
+		}
+		f.mu.Lock()
+		cmd.SetErr(redis.Nil)
+	case "get":
+		if args[1] == "tenant:billing:task:t1" {
+			cmd.(*redis.StringCmd).SetVal(string(f.task))
+			return nil
+		}
+		cmd.SetErr(redis.Nil)
+	case "evalsha", "eval":
+		cmd.(*redis.Cmd).SetVal(int64(1))
+	}
+	return cmd.Err()
+}
+
+func TestWorker_ServesTenantStream(t *testing.T) {
+	tk := task.New("email", map[string]interface{}{"to": "a@example.com"}, task.PriorityNormal)
+	tk.ID = "t1"
+	tk.Tenant = "billing"
+	data, err := json.Marshal(tk)
+	require.NoError(t, err)
+
+	fake := &fakeTenantRedis{stream: "tenant:billing:tasks:normal", task: data}
+	client := redis.NewClient(&redis.Options{Addr: "fake:6379"})
+	client.AddHook(fake)
+	defer client.Close()
+
+	cfg := DefaultConfig()
+	cfg.Concurrency = 1
+	cfg.BlockTimeout = 10 * time.Millisecond
+	cfg.DisableEvents = true
+	cfg.Tenants = []TenantConfig{{Name: "billing"}, {Name: "search"}}
+	cfg.ServeTenants = []string{"billing"}
+
+	w, err := New(cfg, WithRedisClient(client))
+	require.NoError(t, err)
+	assert.Contains(t, fake.xgroups, fake.stream, "tenant streams get consumer groups")
+
+	got := make(chan string, 1)
+	w.Register("email", func(ctx context.Context, t *task.Task) (map[string]interface{}, error) {
+		got <- t.Tenant
+		return nil, nil
+	})
+
+	require.NoError(t, w.Start(t.Context()))
+	select {
+	case tenant := <-got:
+		assert.Equal(t, "billing", tenant)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the tenant's task was not consumed")
+	}
+	_ = w.Stop(t.Context())
+
+	_, err = New(Config{ServeTenants: []string{"unknown"}}, WithRedisClient(client))
+	assert.ErrorIs(t, err, queue.ErrUnknownTenant)
+}
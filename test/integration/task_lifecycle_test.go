@@ -73,7 +73,8 @@ func setupTestServer(t *testing.T) (*api.Server, *queue.RedisQueue, func()) {
 
 	dlq := queue.NewDLQ(redisQueue.Client())
 	publisher := events.NewRedisPubSub(redisQueue.Client())
-	server := api.NewServer(cfg, redisQueue, dlq, publisher)
+	server, err := api.NewServer(cfg, redisQueue, dlq, publisher)
+	require.NoError(t, err)
 
 	cleanup := func() {
 		// Clean up test data
@@ -218,15 +219,30 @@ func TestTaskLifecycle_GetNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
-func TestAdminEndpoints_Health(t *testing.T) {
+func TestAdminEndpoints_NotOnPublicListener(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
+	// The fixture uses a separate admin port, so admin routes live on AdminHandler
+	require.NotNil(t, server.AdminHandler())
+
 	req := httptest.NewRequest(http.MethodGet, "/admin/health", nil)
 	w := httptest.NewRecorder()
 
 	server.ServeHTTP(w, req)
 
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminEndpoints_Health(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/health", nil)
+	w := httptest.NewRecorder()
+
+	server.AdminHandler().ServeHTTP(w, req)
+
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var resp map[string]interface{}
@@ -244,7 +260,7 @@ func TestAdminEndpoints_ListWorkers(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/admin/workers", nil)
 	w := httptest.NewRecorder()
 
-	server.ServeHTTP(w, req)
+	server.AdminHandler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
@@ -263,7 +279,7 @@ func TestAdminEndpoints_GetQueues(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/admin/queues", nil)
 	w := httptest.NewRecorder()
 
-	server.ServeHTTP(w, req)
+	server.AdminHandler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
@@ -284,7 +300,7 @@ func TestAdminEndpoints_DLQ(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/admin/dlq", nil)
 	w := httptest.NewRecorder()
 
-	server.ServeHTTP(w, req)
+	server.AdminHandler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
@@ -14,6 +14,7 @@ const taskSubmissionDuration = new Trend('task_submission_duration');
 
 // Configuration
 const BASE_URL = __ENV.BASE_URL || 'http://localhost:8080';
+const ADMIN_URL = __ENV.ADMIN_URL || 'http://localhost:8081';
 const API_KEY = __ENV.API_KEY || '';
 
 // Test options
@@ -121,7 +122,7 @@ function generateTask() {
 // Setup - runs once before test
 export function setup() {
   // Verify API is reachable
-  const healthRes = http.get(`${BASE_URL}/admin/health`);
+  const healthRes = http.get(`${ADMIN_URL}/admin/health`);
 
   if (healthRes.status !== 200) {
     throw new Error(`API not healthy: ${healthRes.status}`);
@@ -194,7 +195,7 @@ export function teardown(data) {
   console.log(`Test completed in ${duration.toFixed(2)}s`);
 
   // Get final queue stats
-  const res = http.get(`${BASE_URL}/admin/queues`);
+  const res = http.get(`${ADMIN_URL}/admin/queues`);
   if (res.status === 200) {
     console.log(`Final queue stats: ${res.body}`);
   }